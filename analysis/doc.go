@@ -0,0 +1,2 @@
+// Package analysis provides static queries over ECMAScript syntax trees.
+package analysis
@@ -0,0 +1,49 @@
+package analysis
+
+import "es6-interpreter/ast"
+
+// FlatScope reports whether fn creates no closures: it contains no nested
+// functions, arrows or classes, so no code can capture its scope and outlive
+// a call. When that holds, it also returns the names bound directly in the
+// function's own scope (parameters, var declarations anywhere in the body and
+// top-level lexical declarations) in order of declaration without
+// duplicates. Such functions can keep those bindings in fixed slots rather
+// than a name-keyed map. fn must be a function node, as for FreeVariables.
+func FlatScope(fn ast.Node) ([]string, bool) {
+	c := collectFunction(fn)
+	if c == nil || c.nested {
+		return nil, false
+	}
+
+	var params []ast.Pattern
+	var body ast.Node
+	switch f := fn.(type) {
+	case *ast.FunctionDeclaration:
+		params, body = f.Params, f.Body
+	case *ast.FunctionExpression:
+		params, body = f.Params, f.Body
+	case *ast.ArrowFunctionExpression:
+		params, body = f.Params, f.Body
+	}
+
+	var locals []string
+	seen := make(map[string]bool)
+	add := func(names []string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				locals = append(locals, name)
+			}
+		}
+	}
+	for _, param := range params {
+		add(boundNames(param))
+	}
+	if block, ok := body.(*ast.BlockStatement); ok {
+		for _, stmt := range block.Body {
+			add(varNames(stmt))
+			add(lexicalNames(stmt))
+		}
+	}
+	return locals, true
+}
@@ -0,0 +1,479 @@
+package analysis
+
+import "es6-interpreter/ast"
+
+// FreeVariables returns the identifiers referenced inside fn that are not bound
+// by its parameters or by declarations in its body, in order of first use.
+// Free variables of nested functions that the enclosing function does not bind
+// are included. fn must be a *ast.FunctionDeclaration, *ast.FunctionExpression
+// or *ast.ArrowFunctionExpression; other nodes yield nil.
+func FreeVariables(fn ast.Node) []string {
+	c := collectFunction(fn)
+	if c == nil {
+		return nil
+	}
+	return c.free
+}
+
+// collectFunction walks the function fn, returning nil when fn is not a
+// function node.
+func collectFunction(fn ast.Node) *freeVarCollector {
+	c := &freeVarCollector{seen: make(map[string]bool)}
+	switch f := fn.(type) {
+	case *ast.FunctionDeclaration:
+		c.function(nil, f.Params, f.Body)
+	case *ast.FunctionExpression:
+		var s *scope
+		if f.ID != nil {
+			s = newScope(nil)
+			s.declare(f.ID.Name)
+		}
+		c.function(s, f.Params, f.Body)
+	case *ast.ArrowFunctionExpression:
+		c.function(nil, f.Params, f.Body)
+	default:
+		return nil
+	}
+	return c
+}
+
+type scope struct {
+	names  map[string]bool
+	parent *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{names: make(map[string]bool), parent: parent}
+}
+
+func (s *scope) declare(names ...string) {
+	for _, name := range names {
+		s.names[name] = true
+	}
+}
+
+func (s *scope) resolves(name string) bool {
+	for cur := s; cur != nil; cur = cur.parent {
+		if cur.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+type freeVarCollector struct {
+	free []string
+	seen map[string]bool
+
+	depth  int  // functions currently being walked
+	nested bool // a function, arrow or class was found inside the outermost one
+}
+
+func (c *freeVarCollector) reference(s *scope, name string) {
+	if s.resolves(name) || c.seen[name] {
+		return
+	}
+	c.seen[name] = true
+	c.free = append(c.free, name)
+}
+
+func (c *freeVarCollector) function(outer *scope, params []ast.Pattern, body ast.Node) {
+	if c.depth > 0 {
+		c.nested = true
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+
+	s := newScope(outer)
+	for _, param := range params {
+		s.declare(boundNames(param)...)
+	}
+	if block, ok := body.(*ast.BlockStatement); ok {
+		for _, stmt := range block.Body {
+			s.declare(varNames(stmt)...)
+		}
+	}
+	for _, param := range params {
+		c.pattern(s, param)
+	}
+	switch b := body.(type) {
+	case *ast.BlockStatement:
+		c.statements(s, b.Body)
+	case ast.Expression:
+		c.expression(s, b)
+	}
+}
+
+func (c *freeVarCollector) statements(s *scope, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		s.declare(lexicalNames(stmt)...)
+	}
+	for _, stmt := range stmts {
+		c.statement(s, stmt)
+	}
+}
+
+func (c *freeVarCollector) statement(s *scope, stmt ast.Node) {
+	switch st := stmt.(type) {
+	case nil:
+	case *ast.BlockStatement:
+		c.statements(newScope(s), st.Body)
+	case *ast.ExpressionStatement:
+		c.expression(s, st.Expression)
+	case *ast.VariableDeclaration:
+		for _, d := range st.Declarations {
+			c.pattern(s, d.ID)
+			if d.Init != nil {
+				c.expression(s, d.Init)
+			}
+		}
+	case *ast.FunctionDeclaration:
+		c.function(s, st.Params, st.Body)
+	case *ast.ClassDeclaration:
+		c.class(s, st.ID, st.SuperClass, st.Body)
+	case *ast.ReturnStatement:
+		if st.Argument != nil {
+			c.expression(s, st.Argument)
+		}
+	case *ast.ThrowStatement:
+		c.expression(s, st.Argument)
+	case *ast.IfStatement:
+		c.expression(s, st.Test)
+		c.statement(s, st.Consequent)
+		if st.Alternate != nil {
+			c.statement(s, st.Alternate)
+		}
+	case *ast.WhileStatement:
+		c.expression(s, st.Test)
+		c.statement(s, st.Body)
+	case *ast.DoWhileStatement:
+		c.statement(s, st.Body)
+		c.expression(s, st.Test)
+	case *ast.ForStatement:
+		loop := newScope(s)
+		if st.Init != nil {
+			loop.declare(lexicalNames(st.Init)...)
+			if expr, ok := st.Init.(ast.Expression); ok {
+				c.expression(loop, expr)
+			} else {
+				c.statement(loop, st.Init)
+			}
+		}
+		if st.Test != nil {
+			c.expression(loop, st.Test)
+		}
+		if st.Update != nil {
+			c.expression(loop, st.Update)
+		}
+		c.statement(loop, st.Body)
+	case *ast.ForInStatement:
+		c.forInOf(s, st.Left, st.Right, st.Body)
+	case *ast.ForOfStatement:
+		c.forInOf(s, st.Left, st.Right, st.Body)
+	case *ast.SwitchStatement:
+		c.expression(s, st.Discriminant)
+		inner := newScope(s)
+		for _, sc := range st.Cases {
+			for _, stmt := range sc.Consequent {
+				inner.declare(lexicalNames(stmt)...)
+			}
+		}
+		for _, sc := range st.Cases {
+			if sc.Test != nil {
+				c.expression(inner, sc.Test)
+			}
+			for _, stmt := range sc.Consequent {
+				c.statement(inner, stmt)
+			}
+		}
+	case *ast.WithStatement:
+		c.expression(s, st.Object)
+		c.statement(s, st.Body)
+	case *ast.LabeledStatement:
+		c.statement(s, st.Body)
+	case *ast.TryStatement:
+		c.statement(s, st.Block)
+		if st.Handler != nil {
+			catch := newScope(s)
+			if st.Handler.Param != nil {
+				catch.declare(boundNames(st.Handler.Param)...)
+				c.pattern(catch, st.Handler.Param)
+			}
+			c.statement(catch, st.Handler.Body)
+		}
+		if st.Finalizer != nil {
+			c.statement(s, st.Finalizer)
+		}
+	case ast.Expression:
+		c.expression(s, st)
+	}
+}
+
+func (c *freeVarCollector) forInOf(s *scope, left ast.Node, right ast.Expression, body ast.Statement) {
+	loop := newScope(s)
+	loop.declare(lexicalNames(left)...)
+	switch l := left.(type) {
+	case *ast.VariableDeclaration:
+		c.statement(loop, l)
+	case ast.Expression:
+		c.expression(loop, l)
+	case ast.Pattern:
+		c.pattern(loop, l)
+	}
+	c.expression(loop, right)
+	c.statement(loop, body)
+}
+
+// pattern visits the expressions nested in a binding pattern, such as default
+// values and computed keys. Bound identifiers are declared by the caller.
+func (c *freeVarCollector) pattern(s *scope, p ast.Pattern) {
+	switch pt := p.(type) {
+	case *ast.AssignmentPattern:
+		c.pattern(s, pt.Left)
+		c.expression(s, pt.Right)
+	case *ast.RestElement:
+		c.pattern(s, pt.Argument)
+	case *ast.ArrayPattern:
+		for _, elem := range pt.Elements {
+			if elem != nil {
+				c.pattern(s, elem)
+			}
+		}
+		if pt.Rest != nil {
+			c.pattern(s, pt.Rest)
+		}
+	case *ast.ObjectPattern:
+		for _, prop := range pt.Properties {
+			if prop.Computed {
+				c.expression(s, prop.Key)
+			}
+			c.pattern(s, prop.Value)
+		}
+		if pt.Rest != nil {
+			c.pattern(s, pt.Rest)
+		}
+	}
+}
+
+func (c *freeVarCollector) expression(s *scope, expr ast.Expression) {
+	switch e := expr.(type) {
+	case nil:
+	case *ast.Identifier:
+		c.reference(s, e.Name)
+	case *ast.MemberExpression:
+		c.expression(s, e.Object)
+		if e.Computed {
+			c.expression(s, e.Property)
+		}
+	case *ast.CallExpression:
+		c.expression(s, e.Callee)
+		c.expressions(s, e.Arguments)
+	case *ast.ChainExpression:
+		c.expression(s, e.Expression)
+	case *ast.NewExpression:
+		c.expression(s, e.Callee)
+		c.expressions(s, e.Arguments)
+	case *ast.TaggedTemplateExpression:
+		c.expression(s, e.Tag)
+		c.expression(s, e.Quasi)
+	case *ast.TemplateLiteral:
+		c.expressions(s, e.Expressions)
+	case *ast.BinaryExpression:
+		c.expression(s, e.Left)
+		c.expression(s, e.Right)
+	case *ast.LogicalExpression:
+		c.expression(s, e.Left)
+		c.expression(s, e.Right)
+	case *ast.AssignmentExpression:
+		c.expression(s, e.Left)
+		c.expression(s, e.Right)
+	case *ast.UnaryExpression:
+		c.expression(s, e.Argument)
+	case *ast.UpdateExpression:
+		c.expression(s, e.Argument)
+	case *ast.ConditionalExpression:
+		c.expression(s, e.Test)
+		c.expression(s, e.Consequent)
+		c.expression(s, e.Alternate)
+	case *ast.SequenceExpression:
+		c.expressions(s, e.Expressions)
+	case *ast.ArrayLiteral:
+		c.expressions(s, e.Elements)
+	case *ast.SpreadElement:
+		c.expression(s, e.Argument)
+	case *ast.ObjectLiteral:
+		for _, prop := range e.Properties {
+			switch p := prop.(type) {
+			case *ast.ObjectProperty:
+				if p.Computed {
+					c.expression(s, p.Key)
+				}
+				c.expression(s, p.Value)
+			case *ast.SpreadElement:
+				c.expression(s, p.Argument)
+			}
+		}
+	case *ast.ArrowFunctionExpression:
+		c.function(s, e.Params, e.Body)
+	case *ast.FunctionExpression:
+		// A named function expression binds its own name inside itself.
+		inner := s
+		if e.ID != nil {
+			inner = newScope(s)
+			inner.declare(e.ID.Name)
+		}
+		c.function(inner, e.Params, e.Body)
+	case *ast.ClassExpression:
+		c.class(s, e.ID, e.SuperClass, e.Body)
+	}
+}
+
+// class visits a class definition. The heritage and member bodies see the
+// class name, and every method counts as a nested function.
+func (c *freeVarCollector) class(s *scope, id *ast.Identifier, superClass ast.Expression, body *ast.ClassBody) {
+	c.nested = c.nested || c.depth > 0
+	inner := newScope(s)
+	if id != nil {
+		inner.declare(id.Name)
+	}
+	c.expression(inner, superClass)
+	for _, elem := range body.Body {
+		switch m := elem.(type) {
+		case *ast.MethodDefinition:
+			if m.Computed {
+				c.expression(inner, m.Key)
+			}
+			c.function(inner, m.Value.Params, m.Value.Body)
+		case *ast.StaticBlock:
+			// A static block runs like the body of a function without parameters.
+			c.function(inner, nil, ast.NewBlockStatement(m.Body, m.Loc()))
+		}
+	}
+}
+
+func (c *freeVarCollector) expressions(s *scope, exprs []ast.Expression) {
+	for _, expr := range exprs {
+		c.expression(s, expr)
+	}
+}
+
+// lexicalNames lists the block-scoped bindings a statement introduces into the
+// statement list that contains it.
+func lexicalNames(node ast.Node) []string {
+	switch n := node.(type) {
+	case *ast.VariableDeclaration:
+		if n.DeclareKind == ast.VarKind {
+			return nil
+		}
+		var names []string
+		for _, d := range n.Declarations {
+			names = append(names, boundNames(d.ID)...)
+		}
+		return names
+	case *ast.FunctionDeclaration:
+		return []string{n.ID.Name}
+	case *ast.ClassDeclaration:
+		return []string{n.ID.Name}
+	default:
+		return nil
+	}
+}
+
+// varNames lists the var bindings declared anywhere within stmt without
+// crossing into nested functions.
+func varNames(stmt ast.Node) []string {
+	switch s := stmt.(type) {
+	case *ast.VariableDeclaration:
+		if s.DeclareKind != ast.VarKind {
+			return nil
+		}
+		var names []string
+		for _, d := range s.Declarations {
+			names = append(names, boundNames(d.ID)...)
+		}
+		return names
+	case *ast.BlockStatement:
+		var names []string
+		for _, inner := range s.Body {
+			names = append(names, varNames(inner)...)
+		}
+		return names
+	case *ast.IfStatement:
+		names := varNames(s.Consequent)
+		if s.Alternate != nil {
+			names = append(names, varNames(s.Alternate)...)
+		}
+		return names
+	case *ast.WhileStatement:
+		return varNames(s.Body)
+	case *ast.DoWhileStatement:
+		return varNames(s.Body)
+	case *ast.ForStatement:
+		var names []string
+		if s.Init != nil {
+			names = varNames(s.Init)
+		}
+		return append(names, varNames(s.Body)...)
+	case *ast.ForInStatement:
+		return append(varNames(s.Left), varNames(s.Body)...)
+	case *ast.ForOfStatement:
+		return append(varNames(s.Left), varNames(s.Body)...)
+	case *ast.LabeledStatement:
+		return varNames(s.Body)
+	case *ast.WithStatement:
+		return varNames(s.Body)
+	case *ast.SwitchStatement:
+		var names []string
+		for _, sc := range s.Cases {
+			for _, inner := range sc.Consequent {
+				names = append(names, varNames(inner)...)
+			}
+		}
+		return names
+	case *ast.TryStatement:
+		names := varNames(s.Block)
+		if s.Handler != nil {
+			names = append(names, varNames(s.Handler.Body)...)
+		}
+		if s.Finalizer != nil {
+			names = append(names, varNames(s.Finalizer)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+// boundNames lists the identifiers bound by a binding pattern.
+func boundNames(p ast.Pattern) []string {
+	switch pt := p.(type) {
+	case *ast.Identifier:
+		return []string{pt.Name}
+	case *ast.AssignmentPattern:
+		return boundNames(pt.Left)
+	case *ast.RestElement:
+		return boundNames(pt.Argument)
+	case *ast.ArrayPattern:
+		var names []string
+		for _, elem := range pt.Elements {
+			if elem != nil {
+				names = append(names, boundNames(elem)...)
+			}
+		}
+		if pt.Rest != nil {
+			names = append(names, boundNames(pt.Rest)...)
+		}
+		return names
+	case *ast.ObjectPattern:
+		var names []string
+		for _, prop := range pt.Properties {
+			names = append(names, boundNames(prop.Value)...)
+		}
+		if pt.Rest != nil {
+			names = append(names, boundNames(pt.Rest)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
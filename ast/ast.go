@@ -112,32 +112,39 @@ type Visitor interface {
 	Visit(Node) error
 }
 
-// Walk performs a depth-first traversal using the provided walker function.
-// Concrete node types should provide specific child visitation logic via helper methods.
+// Walk visits n and then its descendants depth-first, in source order, as
+// given by Children. Traversal stops at the first error returned by v, which
+// Walk returns.
 func Walk(v Visitor, n Node) error {
-	if n == nil || v == nil {
+	if isNilNode(n) || v == nil {
 		return nil
 	}
-	return v.Visit(n)
+	if err := v.Visit(n); err != nil {
+		return err
+	}
+	for _, child := range Children(n) {
+		if err := Walk(v, child); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// DebugString prints a compact textual representation of a node tree using a supplied writer function.
-func DebugString(n Node, children func(Node) []Node) string {
-	if n == nil {
+// DebugString prints a compact textual representation of a node tree, one
+// node per line, indented by depth.
+func DebugString(n Node) string {
+	if isNilNode(n) {
 		return "<nil>"
 	}
 	var b strings.Builder
 	var walk func(Node, int)
 	walk = func(cur Node, depth int) {
-		if cur == nil {
-			return
-		}
 		b.WriteString(strings.Repeat("  ", depth))
 		b.WriteString(string(cur.Kind()))
 		b.WriteString(" ")
 		b.WriteString(cur.Loc().String())
 		b.WriteByte('\n')
-		for _, child := range children(cur) {
+		for _, child := range Children(cur) {
 			walk(child, depth+1)
 		}
 	}
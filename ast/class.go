@@ -0,0 +1,123 @@
+package ast
+
+import "fmt"
+
+const (
+	ClassDeclarationKind NodeKind = "ClassDeclaration"
+	ClassExpressionKind  NodeKind = "ClassExpression"
+	ClassBodyKind        NodeKind = "ClassBody"
+	MethodDefinitionKind NodeKind = "MethodDefinition"
+	StaticBlockKind      NodeKind = "StaticBlock"
+)
+
+// MethodKind distinguishes the roles a method definition can play in a class.
+type MethodKind string
+
+const (
+	MethodConstructor MethodKind = "constructor"
+	MethodMethod      MethodKind = "method"
+	MethodGet         MethodKind = "get"
+	MethodSet         MethodKind = "set"
+)
+
+// ClassElement is implemented by the members that may appear in a class body.
+type ClassElement interface {
+	Node
+	classElement()
+}
+
+// ClassDeclaration represents class declarations in statement position.
+type ClassDeclaration struct {
+	BaseNode
+	ID         *Identifier
+	SuperClass Expression // nil without an extends clause
+	Body       *ClassBody
+}
+
+func NewClassDeclaration(id *Identifier, superClass Expression, body *ClassBody, loc Location) *ClassDeclaration {
+	return &ClassDeclaration{BaseNode: NewBaseNode(ClassDeclarationKind, loc), ID: id, SuperClass: superClass, Body: body}
+}
+
+func (c *ClassDeclaration) node()        {}
+func (c *ClassDeclaration) statement()   {}
+func (c *ClassDeclaration) declaration() {}
+func (c *ClassDeclaration) String() string {
+	return "ClassDeclaration"
+}
+
+// ClassExpression represents class definitions in expression position. ID is
+// nil for anonymous classes.
+type ClassExpression struct {
+	BaseNode
+	ID         *Identifier
+	SuperClass Expression
+	Body       *ClassBody
+}
+
+func NewClassExpression(id *Identifier, superClass Expression, body *ClassBody, loc Location) *ClassExpression {
+	return &ClassExpression{BaseNode: NewBaseNode(ClassExpressionKind, loc), ID: id, SuperClass: superClass, Body: body}
+}
+
+func (c *ClassExpression) node()       {}
+func (c *ClassExpression) expression() {}
+func (c *ClassExpression) String() string {
+	return "ClassExpression"
+}
+
+// ClassBody holds the members of a class in source order.
+type ClassBody struct {
+	BaseNode
+	Body []ClassElement
+}
+
+func NewClassBody(body []ClassElement, loc Location) *ClassBody {
+	return &ClassBody{BaseNode: NewBaseNode(ClassBodyKind, loc), Body: body}
+}
+
+func (c *ClassBody) node() {}
+func (c *ClassBody) String() string {
+	return "ClassBody"
+}
+
+// MethodDefinition represents a constructor, method or accessor in a class body.
+type MethodDefinition struct {
+	BaseNode
+	Key        Expression
+	Value      *FunctionExpression
+	MethodKind MethodKind
+	Static     bool
+	Computed   bool
+}
+
+func NewMethodDefinition(key Expression, value *FunctionExpression, kind MethodKind, static, computed bool, loc Location) *MethodDefinition {
+	return &MethodDefinition{
+		BaseNode:   NewBaseNode(MethodDefinitionKind, loc),
+		Key:        key,
+		Value:      value,
+		MethodKind: kind,
+		Static:     static,
+		Computed:   computed,
+	}
+}
+
+func (m *MethodDefinition) node()         {}
+func (m *MethodDefinition) classElement() {}
+func (m *MethodDefinition) String() string {
+	return fmt.Sprintf("MethodDefinition(kind=%s, static=%t)", m.MethodKind, m.Static)
+}
+
+// StaticBlock represents a static { } initialization block in a class body.
+type StaticBlock struct {
+	BaseNode
+	Body []Statement
+}
+
+func NewStaticBlock(body []Statement, loc Location) *StaticBlock {
+	return &StaticBlock{BaseNode: NewBaseNode(StaticBlockKind, loc), Body: body}
+}
+
+func (s *StaticBlock) node()         {}
+func (s *StaticBlock) classElement() {}
+func (s *StaticBlock) String() string {
+	return "StaticBlock"
+}
@@ -0,0 +1,69 @@
+package ast
+
+import "reflect"
+
+// Clone returns a deep copy of n and all of its descendants, locations
+// included, so the copy can be modified without affecting n. A node reachable
+// along several paths is copied once and shared in the same way by the copy.
+func Clone(n Node) Node {
+	if isNilNode(n) {
+		return n
+	}
+	c := cloner{seen: make(map[clonedPointer]reflect.Value)}
+	return c.value(reflect.ValueOf(n)).Interface().(Node)
+}
+
+type clonedPointer struct {
+	typ  reflect.Type
+	addr uintptr
+}
+
+type cloner struct {
+	seen map[clonedPointer]reflect.Value
+}
+
+func (c *cloner) value(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		key := clonedPointer{v.Type(), v.Pointer()}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+		cp := reflect.New(v.Type().Elem())
+		c.seen[key] = cp
+		cp.Elem().Set(c.value(v.Elem()))
+		return cp
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(c.value(v.Elem()))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for idx := 0; idx < v.Len(); idx++ {
+			cp.Index(idx).Set(c.value(v.Index(idx)))
+		}
+		return cp
+	case reflect.Struct:
+		// Copying the whole struct carries over unexported fields such as the
+		// location in BaseNode; exported fields are then copied deeply.
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for idx := 0; idx < v.NumField(); idx++ {
+			if field := cp.Field(idx); field.CanSet() {
+				field.Set(c.value(v.Field(idx)))
+			}
+		}
+		return cp
+	default:
+		return v
+	}
+}
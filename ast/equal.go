@@ -0,0 +1,49 @@
+package ast
+
+import "reflect"
+
+var locationType = reflect.TypeOf(Location{})
+
+// Equal reports whether a and b are structurally equal: nodes of the same
+// types whose fields and children are equal. Source locations are ignored.
+func Equal(a, b Node) bool {
+	return equalValues(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equalValues(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalValues(a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for idx := 0; idx < a.Len(); idx++ {
+			if !equalValues(a.Index(idx), b.Index(idx)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		if a.Type() == locationType {
+			return true
+		}
+		for idx := 0; idx < a.NumField(); idx++ {
+			if !equalValues(a.Field(idx), b.Field(idx)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Equal(b)
+	}
+}
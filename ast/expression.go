@@ -8,6 +8,7 @@ const (
 	NewExpressionKind            NodeKind = "NewExpression"
 	TaggedTemplateExpressionKind NodeKind = "TaggedTemplateExpression"
 	ArrowFunctionExpressionKind  NodeKind = "ArrowFunctionExpression"
+	FunctionExpressionKind       NodeKind = "FunctionExpression"
 	BinaryExpressionKind         NodeKind = "BinaryExpression"
 	LogicalExpressionKind        NodeKind = "LogicalExpression"
 	AssignmentExpressionKind     NodeKind = "AssignmentExpression"
@@ -15,6 +16,8 @@ const (
 	UpdateExpressionKind         NodeKind = "UpdateExpression"
 	ConditionalExpressionKind    NodeKind = "ConditionalExpression"
 	SequenceExpressionKind       NodeKind = "SequenceExpression"
+	ChainExpressionKind          NodeKind = "ChainExpression"
+	YieldExpressionKind          NodeKind = "YieldExpression"
 )
 
 // MemberExpression represents property access such as obj.prop or obj[expr].
@@ -23,6 +26,7 @@ type MemberExpression struct {
 	Object   Expression
 	Property Expression
 	Computed bool
+	Optional bool // obj?.prop or obj?.[expr]
 }
 
 func NewMemberExpression(object, property Expression, computed bool, loc Location) *MemberExpression {
@@ -48,6 +52,7 @@ type CallExpression struct {
 	BaseNode
 	Callee    Expression
 	Arguments []Expression
+	Optional  bool // fn?.()
 }
 
 func NewCallExpression(callee Expression, args []Expression, loc Location) *CallExpression {
@@ -60,6 +65,23 @@ func (c *CallExpression) String() string {
 	return "CallExpression"
 }
 
+// ChainExpression wraps an optional chain such as a?.b.c(). When an optional
+// link meets null or undefined, the entire chain evaluates to undefined.
+type ChainExpression struct {
+	BaseNode
+	Expression Expression
+}
+
+func NewChainExpression(expr Expression, loc Location) *ChainExpression {
+	return &ChainExpression{BaseNode: NewBaseNode(ChainExpressionKind, loc), Expression: expr}
+}
+
+func (c *ChainExpression) node()       {}
+func (c *ChainExpression) expression() {}
+func (c *ChainExpression) String() string {
+	return "ChainExpression"
+}
+
 // NewExpression represents the `new` operator with arguments.
 type NewExpression struct {
 	BaseNode
@@ -224,6 +246,9 @@ type ArrowFunctionExpression struct {
 	Params         []Pattern
 	Body           Node
 	ExpressionBody bool
+	// Directives and Strict are as for FunctionDeclaration.
+	Directives []string
+	Strict     bool
 }
 
 func NewArrowFunctionExpression(params []Pattern, body Node, expressionBody bool, loc Location) *ArrowFunctionExpression {
@@ -235,3 +260,46 @@ func (a *ArrowFunctionExpression) expression() {}
 func (a *ArrowFunctionExpression) String() string {
 	return "ArrowFunctionExpression"
 }
+
+// FunctionExpression represents function values in expression position,
+// including the bodies of object literal methods and accessors. ID is nil for
+// anonymous functions.
+type FunctionExpression struct {
+	BaseNode
+	ID        *Identifier
+	Params    []Pattern
+	Body      *BlockStatement
+	Generator bool
+	// Directives and Strict are as for FunctionDeclaration.
+	Directives []string
+	Strict     bool
+}
+
+func NewFunctionExpression(id *Identifier, params []Pattern, body *BlockStatement, generator bool, loc Location) *FunctionExpression {
+	return &FunctionExpression{BaseNode: NewBaseNode(FunctionExpressionKind, loc), ID: id, Params: params, Body: body, Generator: generator}
+}
+
+func (f *FunctionExpression) node()       {}
+func (f *FunctionExpression) expression() {}
+func (f *FunctionExpression) String() string {
+	return "FunctionExpression"
+}
+
+// YieldExpression represents yield inside a generator body. Argument is nil
+// for a bare yield; Delegate marks yield*, which yields every value of an
+// iterable in turn.
+type YieldExpression struct {
+	BaseNode
+	Argument Expression
+	Delegate bool
+}
+
+func NewYieldExpression(arg Expression, delegate bool, loc Location) *YieldExpression {
+	return &YieldExpression{BaseNode: NewBaseNode(YieldExpressionKind, loc), Argument: arg, Delegate: delegate}
+}
+
+func (y *YieldExpression) node()       {}
+func (y *YieldExpression) expression() {}
+func (y *YieldExpression) String() string {
+	return "YieldExpression"
+}
@@ -0,0 +1,359 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// MarshalJSON encodes n as an ESTree object, so that the output can be
+// compared with that of JavaScript parsers such as acorn or espree. Every
+// object carries start and end offsets and a loc with one-based lines and
+// zero-based columns. String literals carry no raw text, which the AST does
+// not keep, but directives carry theirs in a directive field.
+func MarshalJSON(n Node) ([]byte, error) {
+	var e estreeEncoder
+	v := e.node(n)
+	if e.err != nil {
+		return nil, e.err
+	}
+	return json.Marshal(v)
+}
+
+// object is a JSON object that keeps its keys in insertion order, matching
+// the field order used by ESTree producers.
+type object []field
+
+type field struct {
+	key   string
+	value any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for idx, f := range o {
+		if idx > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// estreeEncoder converts nodes to ESTree objects, remembering the first node
+// it cannot represent.
+type estreeEncoder struct {
+	err error
+}
+
+func (e *estreeEncoder) nodes(list any) []any {
+	v := reflect.ValueOf(list)
+	out := make([]any, v.Len())
+	for idx := range out {
+		elem, _ := v.Index(idx).Interface().(Node)
+		out[idx] = e.node(elem)
+	}
+	return out
+}
+
+func (e *estreeEncoder) object(n Node, typ string, fields ...field) object {
+	loc := n.Loc()
+	obj := object{
+		{"type", typ},
+		{"start", loc.Start.Offset},
+		{"end", loc.End.Offset},
+		{"loc", object{
+			{"start", object{{"line", loc.Start.Line}, {"column", loc.Start.Column}}},
+			{"end", object{{"line", loc.End.Line}, {"column", loc.End.Column}}},
+		}},
+	}
+	return append(obj, fields...)
+}
+
+func (e *estreeEncoder) function(n Node, typ string, id *Identifier, params []Pattern, body Node, directives []string, expression, generator bool) object {
+	encodedBody := e.node(body)
+	if block, ok := body.(*BlockStatement); ok && !isNilNode(block) {
+		encodedBody = e.object(block, "BlockStatement", field{"body", e.body(directives, block.Body)})
+	}
+	return e.object(n, typ,
+		field{"id", e.node(id)},
+		field{"expression", expression},
+		field{"generator", generator},
+		field{"async", false},
+		field{"params", e.nodes(params)},
+		field{"body", encodedBody},
+	)
+}
+
+// body encodes the statements of a program or function body, adding to each
+// statement of the directive prologue its raw text without quotes.
+func (e *estreeEncoder) body(directives []string, list []Statement) []any {
+	out := e.nodes(list)
+	for idx, raw := range directives {
+		if idx >= len(out) {
+			break
+		}
+		if stmt, ok := out[idx].(object); ok {
+			out[idx] = append(stmt, field{"directive", raw[1 : len(raw)-1]})
+		}
+	}
+	return out
+}
+
+func (e *estreeEncoder) node(n Node) any {
+	if isNilNode(n) || e.err != nil {
+		return nil
+	}
+	switch n := n.(type) {
+	// Program and statements.
+	case *Program:
+		return e.object(n, "Program", field{"body", e.body(n.Directives, n.Body)}, field{"sourceType", string(n.SourceType)})
+	case *BlockStatement:
+		return e.object(n, "BlockStatement", field{"body", e.nodes(n.Body)})
+	case *ExpressionStatement:
+		return e.object(n, "ExpressionStatement", field{"expression", e.node(n.Expression)})
+	case *EmptyStatement:
+		return e.object(n, "EmptyStatement")
+	case *DebuggerStatement:
+		return e.object(n, "DebuggerStatement")
+	case *ReturnStatement:
+		return e.object(n, "ReturnStatement", field{"argument", e.node(n.Argument)})
+	case *BreakStatement:
+		return e.object(n, "BreakStatement", field{"label", e.node(n.Label)})
+	case *ContinueStatement:
+		return e.object(n, "ContinueStatement", field{"label", e.node(n.Label)})
+	case *ThrowStatement:
+		return e.object(n, "ThrowStatement", field{"argument", e.node(n.Argument)})
+	case *IfStatement:
+		return e.object(n, "IfStatement",
+			field{"test", e.node(n.Test)},
+			field{"consequent", e.node(n.Consequent)},
+			field{"alternate", e.node(n.Alternate)},
+		)
+	case *WhileStatement:
+		return e.object(n, "WhileStatement", field{"test", e.node(n.Test)}, field{"body", e.node(n.Body)})
+	case *DoWhileStatement:
+		return e.object(n, "DoWhileStatement", field{"body", e.node(n.Body)}, field{"test", e.node(n.Test)})
+	case *ForStatement:
+		return e.object(n, "ForStatement",
+			field{"init", e.node(n.Init)},
+			field{"test", e.node(n.Test)},
+			field{"update", e.node(n.Update)},
+			field{"body", e.node(n.Body)},
+		)
+	case *ForInStatement:
+		return e.object(n, "ForInStatement",
+			field{"left", e.node(n.Left)},
+			field{"right", e.node(n.Right)},
+			field{"body", e.node(n.Body)},
+		)
+	case *ForOfStatement:
+		return e.object(n, "ForOfStatement",
+			field{"await", n.Await},
+			field{"left", e.node(n.Left)},
+			field{"right", e.node(n.Right)},
+			field{"body", e.node(n.Body)},
+		)
+	case *SwitchStatement:
+		return e.object(n, "SwitchStatement", field{"discriminant", e.node(n.Discriminant)}, field{"cases", e.nodes(n.Cases)})
+	case *SwitchCase:
+		return e.object(n, "SwitchCase", field{"test", e.node(n.Test)}, field{"consequent", e.nodes(n.Consequent)})
+	case *WithStatement:
+		return e.object(n, "WithStatement", field{"object", e.node(n.Object)}, field{"body", e.node(n.Body)})
+	case *LabeledStatement:
+		return e.object(n, "LabeledStatement", field{"label", e.node(n.Label)}, field{"body", e.node(n.Body)})
+	case *TryStatement:
+		return e.object(n, "TryStatement",
+			field{"block", e.node(n.Block)},
+			field{"handler", e.node(n.Handler)},
+			field{"finalizer", e.node(n.Finalizer)},
+		)
+	case *CatchClause:
+		return e.object(n, "CatchClause", field{"param", e.node(n.Param)}, field{"body", e.node(n.Body)})
+	case *VariableDeclaration:
+		return e.object(n, "VariableDeclaration", field{"declarations", e.nodes(n.Declarations)}, field{"kind", string(n.DeclareKind)})
+	case *VariableDeclarator:
+		return e.object(n, "VariableDeclarator", field{"id", e.node(n.ID)}, field{"init", e.node(n.Init)})
+	case *FunctionDeclaration:
+		return e.function(n, "FunctionDeclaration", n.ID, n.Params, n.Body, n.Directives, false, n.Generator)
+	case *ClassDeclaration:
+		return e.object(n, "ClassDeclaration", field{"id", e.node(n.ID)}, field{"superClass", e.node(n.SuperClass)}, field{"body", e.node(n.Body)})
+	case *ClassExpression:
+		return e.object(n, "ClassExpression", field{"id", e.node(n.ID)}, field{"superClass", e.node(n.SuperClass)}, field{"body", e.node(n.Body)})
+	case *ClassBody:
+		return e.object(n, "ClassBody", field{"body", e.nodes(n.Body)})
+	case *MethodDefinition:
+		return e.object(n, "MethodDefinition",
+			field{"static", n.Static},
+			field{"computed", n.Computed},
+			field{"key", e.node(n.Key)},
+			field{"kind", string(n.MethodKind)},
+			field{"value", e.node(n.Value)},
+		)
+	case *StaticBlock:
+		return e.object(n, "StaticBlock", field{"body", e.nodes(n.Body)})
+
+	// Modules.
+	case *ImportDeclaration:
+		return e.object(n, "ImportDeclaration", field{"specifiers", e.nodes(n.Specifiers)}, field{"source", e.node(n.Source)})
+	case *ImportSpecifier:
+		return e.object(n, "ImportSpecifier", field{"imported", e.node(n.Imported)}, field{"local", e.node(n.Local)})
+	case *ImportDefaultSpecifier:
+		return e.object(n, "ImportDefaultSpecifier", field{"local", e.node(n.Local)})
+	case *ImportNamespaceSpecifier:
+		return e.object(n, "ImportNamespaceSpecifier", field{"local", e.node(n.Local)})
+	case *ExportNamedDeclaration:
+		return e.object(n, "ExportNamedDeclaration",
+			field{"declaration", e.node(n.Declaration)},
+			field{"specifiers", e.nodes(n.Specifiers)},
+			field{"source", e.node(n.Source)},
+		)
+	case *ExportSpecifier:
+		return e.object(n, "ExportSpecifier", field{"local", e.node(n.Local)}, field{"exported", e.node(n.Exported)})
+	case *ExportDefaultDeclaration:
+		return e.object(n, "ExportDefaultDeclaration", field{"declaration", e.node(n.Declaration)})
+	case *ExportAllDeclaration:
+		return e.object(n, "ExportAllDeclaration", field{"exported", e.node(n.Exported)}, field{"source", e.node(n.Source)})
+
+	// Expressions.
+	case *Identifier:
+		return e.object(n, "Identifier", field{"name", n.Name})
+	case *ThisExpression:
+		return e.object(n, "ThisExpression")
+	case *Super:
+		return e.object(n, "Super")
+	case *MetaProperty:
+		return e.object(n, "MetaProperty", field{"meta", e.node(n.Meta)}, field{"property", e.node(n.Property)})
+	case *MemberExpression:
+		return e.object(n, "MemberExpression",
+			field{"object", e.node(n.Object)},
+			field{"property", e.node(n.Property)},
+			field{"computed", n.Computed},
+			field{"optional", n.Optional},
+		)
+	case *CallExpression:
+		return e.object(n, "CallExpression",
+			field{"callee", e.node(n.Callee)},
+			field{"arguments", e.nodes(n.Arguments)},
+			field{"optional", n.Optional},
+		)
+	case *ChainExpression:
+		return e.object(n, "ChainExpression", field{"expression", e.node(n.Expression)})
+	case *NewExpression:
+		return e.object(n, "NewExpression", field{"callee", e.node(n.Callee)}, field{"arguments", e.nodes(n.Arguments)})
+	case *TaggedTemplateExpression:
+		return e.object(n, "TaggedTemplateExpression", field{"tag", e.node(n.Tag)}, field{"quasi", e.node(n.Quasi)})
+	case *BinaryExpression:
+		return e.object(n, "BinaryExpression", field{"left", e.node(n.Left)}, field{"operator", n.Operator}, field{"right", e.node(n.Right)})
+	case *LogicalExpression:
+		return e.object(n, "LogicalExpression", field{"left", e.node(n.Left)}, field{"operator", n.Operator}, field{"right", e.node(n.Right)})
+	case *AssignmentExpression:
+		return e.object(n, "AssignmentExpression", field{"operator", n.Operator}, field{"left", e.node(n.Left)}, field{"right", e.node(n.Right)})
+	case *UnaryExpression:
+		return e.object(n, "UnaryExpression", field{"operator", n.Operator}, field{"prefix", n.Prefix}, field{"argument", e.node(n.Argument)})
+	case *UpdateExpression:
+		return e.object(n, "UpdateExpression", field{"operator", n.Operator}, field{"prefix", n.Prefix}, field{"argument", e.node(n.Argument)})
+	case *ConditionalExpression:
+		return e.object(n, "ConditionalExpression",
+			field{"test", e.node(n.Test)},
+			field{"consequent", e.node(n.Consequent)},
+			field{"alternate", e.node(n.Alternate)},
+		)
+	case *SequenceExpression:
+		return e.object(n, "SequenceExpression", field{"expressions", e.nodes(n.Expressions)})
+	case *ArrowFunctionExpression:
+		return e.function(n, "ArrowFunctionExpression", nil, n.Params, n.Body, n.Directives, n.ExpressionBody, false)
+	case *FunctionExpression:
+		return e.function(n, "FunctionExpression", n.ID, n.Params, n.Body, n.Directives, false, n.Generator)
+	case *SpreadElement:
+		return e.object(n, "SpreadElement", field{"argument", e.node(n.Argument)})
+	case *YieldExpression:
+		return e.object(n, "YieldExpression", field{"argument", e.node(n.Argument)}, field{"delegate", n.Delegate})
+
+	// Literals.
+	case *NumberLiteral:
+		value, err := n.Float64()
+		if err != nil {
+			e.err = fmt.Errorf("ast: invalid numeric literal %q: %v", n.Value, err)
+			return nil
+		}
+		return e.object(n, "Literal", field{"value", value}, field{"raw", n.Value})
+	case *BigIntLiteral:
+		return e.object(n, "Literal", field{"value", nil}, field{"raw", n.Value + "n"}, field{"bigint", n.Value})
+	case *StringLiteral:
+		return e.object(n, "Literal", field{"value", n.Value})
+	case *BooleanLiteral:
+		return e.object(n, "Literal", field{"value", n.Value}, field{"raw", fmt.Sprint(n.Value)})
+	case *NullLiteral:
+		return e.object(n, "Literal", field{"value", nil}, field{"raw", "null"})
+	case *RegExpLiteral:
+		return e.object(n, "Literal",
+			field{"value", nil},
+			field{"raw", "/" + n.Pattern + "/" + n.Flags},
+			field{"regex", object{{"pattern", n.Pattern}, {"flags", n.Flags}}},
+		)
+	case *TemplateLiteral:
+		return e.object(n, "TemplateLiteral", field{"expressions", e.nodes(n.Expressions)}, field{"quasis", e.nodes(n.Quasis)})
+	case *TemplateElement:
+		return e.object(n, "TemplateElement", field{"value", object{{"raw", n.Raw}, {"cooked", n.Cooked}}}, field{"tail", n.Tail})
+	case *ArrayLiteral:
+		return e.object(n, "ArrayExpression", field{"elements", e.nodes(n.Elements)})
+	case *ObjectLiteral:
+		return e.object(n, "ObjectExpression", field{"properties", e.nodes(n.Properties)})
+	case *ObjectProperty:
+		kind := n.PropKind
+		if kind == PropertyMethod {
+			kind = PropertyInit
+		}
+		return e.object(n, "Property",
+			field{"method", n.Method},
+			field{"shorthand", n.Shorthand},
+			field{"computed", n.Computed},
+			field{"key", e.node(n.Key)},
+			field{"value", e.node(n.Value)},
+			field{"kind", string(kind)},
+		)
+
+	// Patterns. ESTree lists a rest element as the last element or property.
+	case *ArrayPattern:
+		elements := e.nodes(n.Elements)
+		if n.Rest != nil {
+			elements = append(elements, e.node(n.Rest))
+		}
+		return e.object(n, "ArrayPattern", field{"elements", elements})
+	case *ObjectPattern:
+		properties := e.nodes(n.Properties)
+		if n.Rest != nil {
+			properties = append(properties, e.node(n.Rest))
+		}
+		return e.object(n, "ObjectPattern", field{"properties", properties})
+	case *ObjectPatternProperty:
+		return e.object(n, "Property",
+			field{"method", false},
+			field{"shorthand", n.Shorthand},
+			field{"computed", n.Computed},
+			field{"key", e.node(n.Key)},
+			field{"value", e.node(n.Value)},
+			field{"kind", string(PropertyInit)},
+		)
+	case *AssignmentPattern:
+		return e.object(n, "AssignmentPattern", field{"left", e.node(n.Left)}, field{"right", e.node(n.Right)})
+	case *RestElement:
+		return e.object(n, "RestElement", field{"argument", e.node(n.Argument)})
+	}
+	e.err = fmt.Errorf("ast: cannot encode %T as ESTree", n)
+	return nil
+}
@@ -1,9 +1,14 @@
 package ast
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 const (
 	NumberLiteralKind   NodeKind = "NumberLiteral"
+	BigIntLiteralKind   NodeKind = "BigIntLiteral"
 	StringLiteralKind   NodeKind = "StringLiteral"
 	BooleanLiteralKind  NodeKind = "BooleanLiteral"
 	NullLiteralKind     NodeKind = "NullLiteral"
@@ -37,6 +42,59 @@ func (n *NumberLiteral) expression()    {}
 func (n *NumberLiteral) literal()       {}
 func (n *NumberLiteral) String() string { return fmt.Sprintf("NumberLiteral(%s)", n.Value) }
 
+// BigIntLiteral represents integer literals carrying the n suffix. Value holds
+// the digits with any radix prefix kept and separators and suffix removed.
+// Float64 returns the numeric value of the literal, accepting the hex, octal,
+// binary and legacy octal forms as well as numeric separators.
+func (n *NumberLiteral) Float64() (float64, error) {
+	s := strings.ReplaceAll(n.Value, "_", "")
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := strconv.ParseUint(s[2:], 16, 64)
+		if err != nil {
+			return 0, err
+		}
+		return float64(v), nil
+	}
+	if strings.HasPrefix(s, "0o") || strings.HasPrefix(s, "0O") {
+		v, err := strconv.ParseUint(s[2:], 8, 64)
+		if err != nil {
+			return 0, err
+		}
+		return float64(v), nil
+	}
+	if strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B") {
+		v, err := strconv.ParseUint(s[2:], 2, 64)
+		if err != nil {
+			return 0, err
+		}
+		return float64(v), nil
+	}
+	if strings.HasPrefix(s, "0") && len(s) > 1 && s[1] >= '0' && s[1] <= '7' && !strings.ContainsAny(s, "89") {
+		v, err := strconv.ParseUint(s[1:], 8, 64)
+		if err == nil {
+			return float64(v), nil
+		}
+	}
+	if strings.HasSuffix(s, "n") {
+		return 0, fmt.Errorf("bigint literals are not supported")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+type BigIntLiteral struct {
+	BaseNode
+	Value string
+}
+
+func NewBigIntLiteral(value string, loc Location) *BigIntLiteral {
+	return &BigIntLiteral{BaseNode: NewBaseNode(BigIntLiteralKind, loc), Value: value}
+}
+
+func (b *BigIntLiteral) node()          {}
+func (b *BigIntLiteral) expression()    {}
+func (b *BigIntLiteral) literal()       {}
+func (b *BigIntLiteral) String() string { return fmt.Sprintf("BigIntLiteral(%sn)", b.Value) }
+
 // StringLiteral represents quoted string literals.
 type StringLiteral struct {
 	BaseNode
@@ -0,0 +1,163 @@
+package ast
+
+const (
+	ImportDeclarationKind        NodeKind = "ImportDeclaration"
+	ImportSpecifierKind          NodeKind = "ImportSpecifier"
+	ImportDefaultSpecifierKind   NodeKind = "ImportDefaultSpecifier"
+	ImportNamespaceSpecifierKind NodeKind = "ImportNamespaceSpecifier"
+	ExportNamedDeclarationKind   NodeKind = "ExportNamedDeclaration"
+	ExportSpecifierKind          NodeKind = "ExportSpecifier"
+	ExportDefaultDeclarationKind NodeKind = "ExportDefaultDeclaration"
+	ExportAllDeclarationKind     NodeKind = "ExportAllDeclaration"
+)
+
+// ImportClause is implemented by the specifiers that may appear in an import
+// declaration.
+type ImportClause interface {
+	Node
+	importClause()
+}
+
+// ImportDeclaration models import statements. Specifiers is empty for an
+// import evaluated only for its side effects, as in import "m".
+type ImportDeclaration struct {
+	BaseNode
+	Specifiers []ImportClause
+	Source     *StringLiteral
+}
+
+func NewImportDeclaration(specifiers []ImportClause, source *StringLiteral, loc Location) *ImportDeclaration {
+	return &ImportDeclaration{BaseNode: NewBaseNode(ImportDeclarationKind, loc), Specifiers: specifiers, Source: source}
+}
+
+func (i *ImportDeclaration) node()      {}
+func (i *ImportDeclaration) statement() {}
+func (i *ImportDeclaration) String() string {
+	return "ImportDeclaration"
+}
+
+// ImportSpecifier binds the export Imported of the source module to Local, as
+// in { a } or { a as b }. Both identifiers are the same name when no alias is
+// given.
+type ImportSpecifier struct {
+	BaseNode
+	Imported *Identifier
+	Local    *Identifier
+}
+
+func NewImportSpecifier(imported, local *Identifier, loc Location) *ImportSpecifier {
+	return &ImportSpecifier{BaseNode: NewBaseNode(ImportSpecifierKind, loc), Imported: imported, Local: local}
+}
+
+func (i *ImportSpecifier) node()         {}
+func (i *ImportSpecifier) importClause() {}
+func (i *ImportSpecifier) String() string {
+	return "ImportSpecifier"
+}
+
+// ImportDefaultSpecifier binds the default export of the source module.
+type ImportDefaultSpecifier struct {
+	BaseNode
+	Local *Identifier
+}
+
+func NewImportDefaultSpecifier(local *Identifier, loc Location) *ImportDefaultSpecifier {
+	return &ImportDefaultSpecifier{BaseNode: NewBaseNode(ImportDefaultSpecifierKind, loc), Local: local}
+}
+
+func (i *ImportDefaultSpecifier) node()         {}
+func (i *ImportDefaultSpecifier) importClause() {}
+func (i *ImportDefaultSpecifier) String() string {
+	return "ImportDefaultSpecifier"
+}
+
+// ImportNamespaceSpecifier binds the namespace object of the source module,
+// as in * as ns.
+type ImportNamespaceSpecifier struct {
+	BaseNode
+	Local *Identifier
+}
+
+func NewImportNamespaceSpecifier(local *Identifier, loc Location) *ImportNamespaceSpecifier {
+	return &ImportNamespaceSpecifier{BaseNode: NewBaseNode(ImportNamespaceSpecifierKind, loc), Local: local}
+}
+
+func (i *ImportNamespaceSpecifier) node()         {}
+func (i *ImportNamespaceSpecifier) importClause() {}
+func (i *ImportNamespaceSpecifier) String() string {
+	return "ImportNamespaceSpecifier"
+}
+
+// ExportNamedDeclaration models export statements that name their exports.
+// Either Declaration is set, as in export const x = 1, or Specifiers lists
+// the exported bindings, which come from Source when it is non-nil.
+type ExportNamedDeclaration struct {
+	BaseNode
+	Declaration Declaration
+	Specifiers  []*ExportSpecifier
+	Source      *StringLiteral
+}
+
+func NewExportNamedDeclaration(declaration Declaration, specifiers []*ExportSpecifier, source *StringLiteral, loc Location) *ExportNamedDeclaration {
+	return &ExportNamedDeclaration{BaseNode: NewBaseNode(ExportNamedDeclarationKind, loc), Declaration: declaration, Specifiers: specifiers, Source: source}
+}
+
+func (e *ExportNamedDeclaration) node()      {}
+func (e *ExportNamedDeclaration) statement() {}
+func (e *ExportNamedDeclaration) String() string {
+	return "ExportNamedDeclaration"
+}
+
+// ExportSpecifier exports the binding Local under the name Exported, as in
+// { a } or { a as b }.
+type ExportSpecifier struct {
+	BaseNode
+	Local    *Identifier
+	Exported *Identifier
+}
+
+func NewExportSpecifier(local, exported *Identifier, loc Location) *ExportSpecifier {
+	return &ExportSpecifier{BaseNode: NewBaseNode(ExportSpecifierKind, loc), Local: local, Exported: exported}
+}
+
+func (e *ExportSpecifier) node() {}
+func (e *ExportSpecifier) String() string {
+	return "ExportSpecifier"
+}
+
+// ExportDefaultDeclaration models export default. Declaration is a
+// *FunctionDeclaration or *ClassDeclaration for named declarations and an
+// Expression otherwise.
+type ExportDefaultDeclaration struct {
+	BaseNode
+	Declaration Node
+}
+
+func NewExportDefaultDeclaration(declaration Node, loc Location) *ExportDefaultDeclaration {
+	return &ExportDefaultDeclaration{BaseNode: NewBaseNode(ExportDefaultDeclarationKind, loc), Declaration: declaration}
+}
+
+func (e *ExportDefaultDeclaration) node()      {}
+func (e *ExportDefaultDeclaration) statement() {}
+func (e *ExportDefaultDeclaration) String() string {
+	return "ExportDefaultDeclaration"
+}
+
+// ExportAllDeclaration re-exports every named export of Source, or its
+// namespace object under the name Exported when that is non-nil, as in
+// export * as ns from "m".
+type ExportAllDeclaration struct {
+	BaseNode
+	Exported *Identifier
+	Source   *StringLiteral
+}
+
+func NewExportAllDeclaration(exported *Identifier, source *StringLiteral, loc Location) *ExportAllDeclaration {
+	return &ExportAllDeclaration{BaseNode: NewBaseNode(ExportAllDeclarationKind, loc), Exported: exported, Source: source}
+}
+
+func (e *ExportAllDeclaration) node()      {}
+func (e *ExportAllDeclaration) statement() {}
+func (e *ExportAllDeclaration) String() string {
+	return "ExportAllDeclaration"
+}
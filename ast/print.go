@@ -0,0 +1,1006 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Print renders n as ECMAScript source text. Statements are printed one per
+// line with two-space indentation, and parentheses are only inserted where
+// precedence or statement position requires them, so parsing the output
+// yields a tree Equal to n.
+func Print(n Node) string {
+	var p printer
+	switch n := n.(type) {
+	case *Program:
+		p.statements(n.Directives, n.Body)
+	case Statement:
+		p.statement(n)
+	case Expression:
+		p.expression(n, precSequence)
+	case Pattern:
+		p.pattern(n)
+	default:
+		p.node(n)
+	}
+	return p.b.String()
+}
+
+// Expression precedence levels, from loosest to tightest binding, mirroring
+// those used by the parser.
+const (
+	precSequence = iota + 1
+	precAssignment
+	precConditional
+	precNullish
+	precLogicalOr
+	precLogicalAnd
+	precBitwiseOr
+	precBitwiseXor
+	precBitwiseAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precExponent
+	precPrefix
+	precPostfix
+	precCall
+	precPrimary
+)
+
+var binaryPrecedence = map[string]int{
+	"|":          precBitwiseOr,
+	"^":          precBitwiseXor,
+	"&":          precBitwiseAnd,
+	"==":         precEquality,
+	"!=":         precEquality,
+	"===":        precEquality,
+	"!==":        precEquality,
+	"<":          precRelational,
+	"<=":         precRelational,
+	">":          precRelational,
+	">=":         precRelational,
+	"in":         precRelational,
+	"instanceof": precRelational,
+	"<<":         precShift,
+	">>":         precShift,
+	">>>":        precShift,
+	"+":          precAdditive,
+	"-":          precAdditive,
+	"*":          precMultiplicative,
+	"/":          precMultiplicative,
+	"%":          precMultiplicative,
+	"**":         precExponent,
+}
+
+func precedenceOf(e Expression) int {
+	switch e := e.(type) {
+	case *SequenceExpression:
+		return precSequence
+	case *AssignmentExpression, *ArrowFunctionExpression, *YieldExpression:
+		return precAssignment
+	case *ConditionalExpression:
+		return precConditional
+	case *LogicalExpression:
+		switch e.Operator {
+		case "??":
+			return precNullish
+		case "||":
+			return precLogicalOr
+		}
+		return precLogicalAnd
+	case *BinaryExpression:
+		if prec, ok := binaryPrecedence[e.Operator]; ok {
+			return prec
+		}
+		return precMultiplicative
+	case *UnaryExpression:
+		return precPrefix
+	case *UpdateExpression:
+		if e.Prefix {
+			return precPrefix
+		}
+		return precPostfix
+	case *MemberExpression, *CallExpression, *NewExpression, *TaggedTemplateExpression, *ChainExpression:
+		return precCall
+	}
+	return precPrimary
+}
+
+type printer struct {
+	b      strings.Builder
+	indent int
+	// noIn parenthesizes every in operator while printing the head of a for
+	// statement, where a bare in would start a for-in loop.
+	noIn bool
+}
+
+func (p *printer) write(parts ...string) {
+	for _, s := range parts {
+		p.b.WriteString(s)
+	}
+}
+
+func (p *printer) newline() {
+	p.b.WriteByte('\n')
+	p.b.WriteString(strings.Repeat("  ", p.indent))
+}
+
+// Statements -----------------------------------------------------------------
+
+func (p *printer) statements(directives []string, list []Statement) {
+	for idx, s := range list {
+		if idx > 0 {
+			p.newline()
+		}
+		p.bodyStatement(directives, idx, s)
+	}
+	if len(list) > 0 {
+		p.b.WriteByte('\n')
+	}
+}
+
+// block prints the statements of a braced body, one per indented line.
+func (p *printer) block(list []Statement) {
+	p.functionBody(nil, list)
+}
+
+// functionBody prints a braced body that opens with the directive prologue
+// directives.
+func (p *printer) functionBody(directives []string, list []Statement) {
+	if len(list) == 0 {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	p.indent++
+	for idx, s := range list {
+		p.newline()
+		p.bodyStatement(directives, idx, s)
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// bodyStatement prints the statement at idx of a body. Directives are
+// printed from their raw text, since requoting them could turn an escaped
+// "use strict" into a real one.
+func (p *printer) bodyStatement(directives []string, idx int, s Statement) {
+	if idx < len(directives) {
+		p.write(directives[idx], ";")
+		return
+	}
+	p.statement(s)
+}
+
+// body prints the statement following a control-flow header.
+func (p *printer) body(s Statement) {
+	p.write(" ")
+	p.statement(s)
+}
+
+func (p *printer) statement(s Statement) {
+	switch s := s.(type) {
+	case *BlockStatement:
+		p.block(s.Body)
+	case *ExpressionStatement:
+		switch leftmost(s.Expression).(type) {
+		case *ObjectLiteral, *FunctionExpression, *ClassExpression:
+			p.write("(")
+			p.expression(s.Expression, precSequence)
+			p.write(");")
+		default:
+			p.expression(s.Expression, precSequence)
+			p.write(";")
+		}
+	case *EmptyStatement:
+		p.write(";")
+	case *DebuggerStatement:
+		p.write("debugger;")
+	case *ReturnStatement:
+		p.write("return")
+		if s.Argument != nil {
+			p.write(" ")
+			p.expression(s.Argument, precSequence)
+		}
+		p.write(";")
+	case *BreakStatement:
+		p.write("break")
+		if s.Label != nil {
+			p.write(" ", s.Label.Name)
+		}
+		p.write(";")
+	case *ContinueStatement:
+		p.write("continue")
+		if s.Label != nil {
+			p.write(" ", s.Label.Name)
+		}
+		p.write(";")
+	case *ThrowStatement:
+		p.write("throw ")
+		p.expression(s.Argument, precSequence)
+		p.write(";")
+	case *IfStatement:
+		p.write("if (")
+		p.expression(s.Test, precSequence)
+		p.write(")")
+		consequent := s.Consequent
+		if inner, ok := consequent.(*IfStatement); ok && inner.Alternate == nil && s.Alternate != nil {
+			// Keep the else attached to this if rather than the inner one.
+			consequent = NewBlockStatement([]Statement{inner}, inner.Loc())
+		}
+		p.body(consequent)
+		if s.Alternate != nil {
+			p.write(" else")
+			p.body(s.Alternate)
+		}
+	case *WhileStatement:
+		p.write("while (")
+		p.expression(s.Test, precSequence)
+		p.write(")")
+		p.body(s.Body)
+	case *DoWhileStatement:
+		p.write("do")
+		p.body(s.Body)
+		p.write(" while (")
+		p.expression(s.Test, precSequence)
+		p.write(");")
+	case *ForStatement:
+		p.write("for (")
+		if s.Init != nil {
+			p.forHead(s.Init)
+		}
+		p.write(";")
+		if s.Test != nil {
+			p.write(" ")
+			p.expression(s.Test, precSequence)
+		}
+		p.write(";")
+		if s.Update != nil {
+			p.write(" ")
+			p.expression(s.Update, precSequence)
+		}
+		p.write(")")
+		p.body(s.Body)
+	case *ForInStatement:
+		p.write("for (")
+		p.forHead(s.Left)
+		p.write(" in ")
+		p.expression(s.Right, precSequence)
+		p.write(")")
+		p.body(s.Body)
+	case *ForOfStatement:
+		p.write("for ")
+		if s.Await {
+			p.write("await ")
+		}
+		p.write("(")
+		p.forHead(s.Left)
+		p.write(" of ")
+		p.expression(s.Right, precAssignment)
+		p.write(")")
+		p.body(s.Body)
+	case *SwitchStatement:
+		p.write("switch (")
+		p.expression(s.Discriminant, precSequence)
+		p.write(") {")
+		for _, c := range s.Cases {
+			p.newline()
+			if c.Test != nil {
+				p.write("case ")
+				p.expression(c.Test, precSequence)
+				p.write(":")
+			} else {
+				p.write("default:")
+			}
+			p.indent++
+			for _, stmt := range c.Consequent {
+				p.newline()
+				p.statement(stmt)
+			}
+			p.indent--
+		}
+		p.newline()
+		p.write("}")
+	case *WithStatement:
+		p.write("with (")
+		p.expression(s.Object, precSequence)
+		p.write(")")
+		p.body(s.Body)
+	case *LabeledStatement:
+		p.write(s.Label.Name, ":")
+		p.body(s.Body)
+	case *TryStatement:
+		p.write("try ")
+		p.block(s.Block.Body)
+		if s.Handler != nil {
+			p.write(" catch ")
+			if s.Handler.Param != nil {
+				p.write("(")
+				p.pattern(s.Handler.Param)
+				p.write(") ")
+			}
+			p.block(s.Handler.Body.Body)
+		}
+		if s.Finalizer != nil {
+			p.write(" finally ")
+			p.block(s.Finalizer.Body)
+		}
+	case *VariableDeclaration:
+		p.variableDeclaration(s)
+		p.write(";")
+	case *FunctionDeclaration:
+		p.function(s.ID, s.Params, s.Body, s.Directives, s.Generator)
+	case *ClassDeclaration:
+		p.class(s.ID, s.SuperClass, s.Body)
+	case *ImportDeclaration:
+		p.importDeclaration(s)
+	case *ExportNamedDeclaration:
+		p.write("export ")
+		if s.Declaration != nil {
+			p.statement(s.Declaration)
+			break
+		}
+		p.write("{")
+		for idx, spec := range s.Specifiers {
+			if idx > 0 {
+				p.write(",")
+			}
+			p.write(" ", spec.Local.Name)
+			if spec.Exported.Name != spec.Local.Name {
+				p.write(" as ", spec.Exported.Name)
+			}
+		}
+		if len(s.Specifiers) > 0 {
+			p.write(" ")
+		}
+		p.write("}")
+		if s.Source != nil {
+			p.write(" from ", quote(s.Source.Value))
+		}
+		p.write(";")
+	case *ExportDefaultDeclaration:
+		p.write("export default ")
+		switch d := s.Declaration.(type) {
+		case Statement:
+			p.statement(d)
+		case Expression:
+			p.expression(d, precAssignment)
+			p.write(";")
+		default:
+			p.node(d)
+		}
+	case *ExportAllDeclaration:
+		p.write("export *")
+		if s.Exported != nil {
+			p.write(" as ", s.Exported.Name)
+		}
+		p.write(" from ", quote(s.Source.Value), ";")
+	default:
+		p.node(s)
+	}
+}
+
+// importDeclaration prints an import in the order the grammar requires: the
+// default binding, then a namespace or a list of named bindings.
+func (p *printer) importDeclaration(d *ImportDeclaration) {
+	p.write("import ")
+	var named []*ImportSpecifier
+	wrote := false
+	for _, spec := range d.Specifiers {
+		switch spec := spec.(type) {
+		case *ImportDefaultSpecifier:
+			p.write(spec.Local.Name)
+			wrote = true
+		case *ImportNamespaceSpecifier:
+			if wrote {
+				p.write(", ")
+			}
+			p.write("* as ", spec.Local.Name)
+			wrote = true
+		case *ImportSpecifier:
+			named = append(named, spec)
+		}
+	}
+	if len(named) > 0 {
+		if wrote {
+			p.write(", ")
+		}
+		p.write("{")
+		for idx, spec := range named {
+			if idx > 0 {
+				p.write(",")
+			}
+			p.write(" ", spec.Imported.Name)
+			if spec.Local.Name != spec.Imported.Name {
+				p.write(" as ", spec.Local.Name)
+			}
+		}
+		p.write(" }")
+		wrote = true
+	}
+	if wrote {
+		p.write(" from ")
+	}
+	p.write(quote(d.Source.Value), ";")
+}
+
+// forHead prints the init of a for statement or the left side of a for-in or
+// for-of statement.
+func (p *printer) forHead(n Node) {
+	outer := p.noIn
+	p.noIn = true
+	defer func() { p.noIn = outer }()
+	switch n := n.(type) {
+	case *VariableDeclaration:
+		p.variableDeclaration(n)
+	case Expression:
+		p.expression(n, precSequence)
+	case Pattern:
+		p.pattern(n)
+	default:
+		p.node(n)
+	}
+}
+
+func (p *printer) variableDeclaration(d *VariableDeclaration) {
+	p.write(string(d.DeclareKind), " ")
+	for idx, decl := range d.Declarations {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.pattern(decl.ID)
+		if decl.Init != nil {
+			p.write(" = ")
+			p.expression(decl.Init, precAssignment)
+		}
+	}
+}
+
+func (p *printer) function(id *Identifier, params []Pattern, body *BlockStatement, directives []string, generator bool) {
+	p.write("function")
+	if generator {
+		p.write("*")
+	}
+	if id != nil {
+		p.write(" ", id.Name)
+	}
+	p.params(params)
+	p.write(" ")
+	p.functionBody(directives, body.Body)
+}
+
+func (p *printer) params(params []Pattern) {
+	p.write("(")
+	for idx, param := range params {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.pattern(param)
+	}
+	p.write(")")
+}
+
+func (p *printer) class(id *Identifier, superClass Expression, body *ClassBody) {
+	p.write("class")
+	if id != nil {
+		p.write(" ", id.Name)
+	}
+	if superClass != nil {
+		p.write(" extends ")
+		p.expression(superClass, precCall)
+	}
+	p.write(" ")
+	if len(body.Body) == 0 {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	p.indent++
+	for _, element := range body.Body {
+		p.newline()
+		switch element := element.(type) {
+		case *MethodDefinition:
+			if element.Static {
+				p.write("static ")
+			}
+			kind := PropertyMethod
+			switch element.MethodKind {
+			case MethodGet:
+				kind = PropertyGet
+			case MethodSet:
+				kind = PropertySet
+			}
+			p.method(kind, element.Key, element.Computed, element.Value)
+		case *StaticBlock:
+			p.write("static ")
+			p.block(element.Body)
+		default:
+			p.node(element)
+		}
+	}
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// method prints an object literal or class method, including accessors.
+func (p *printer) method(kind PropertyKind, key Expression, computed bool, fn *FunctionExpression) {
+	switch kind {
+	case PropertyGet:
+		p.write("get ")
+	case PropertySet:
+		p.write("set ")
+	default:
+		if fn.Generator {
+			p.write("*")
+		}
+	}
+	p.propertyKey(key, computed)
+	p.params(fn.Params)
+	p.write(" ")
+	p.functionBody(fn.Directives, fn.Body.Body)
+}
+
+func (p *printer) propertyKey(key Expression, computed bool) {
+	if computed {
+		p.write("[")
+		p.expression(key, precAssignment)
+		p.write("]")
+		return
+	}
+	switch key := key.(type) {
+	case *Identifier:
+		p.write(key.Name)
+	default:
+		p.expression(key, precPrimary)
+	}
+}
+
+// Expressions ----------------------------------------------------------------
+
+// expression prints e, wrapping it in parentheses when it binds more loosely
+// than min.
+func (p *printer) expression(e Expression, min int) {
+	paren := precedenceOf(e) < min
+	if binary, ok := e.(*BinaryExpression); ok && binary.Operator == "in" && p.noIn {
+		paren = true
+	}
+	if paren {
+		p.write("(")
+		outer := p.noIn
+		p.noIn = false
+		defer func() { p.noIn = outer }()
+		defer p.write(")")
+	}
+
+	switch e := e.(type) {
+	case *Identifier:
+		p.write(e.Name)
+	case *ThisExpression:
+		p.write("this")
+	case *Super:
+		p.write("super")
+	case *MetaProperty:
+		p.write(e.Meta.Name, ".", e.Property.Name)
+	case *NumberLiteral:
+		p.write(e.Value)
+	case *BigIntLiteral:
+		p.write(e.Value, "n")
+	case *StringLiteral:
+		p.write(quote(e.Value))
+	case *BooleanLiteral:
+		p.write(fmt.Sprint(e.Value))
+	case *NullLiteral:
+		p.write("null")
+	case *RegExpLiteral:
+		p.write("/", e.Pattern, "/", e.Flags)
+	case *TemplateLiteral:
+		p.template(e)
+	case *ArrayLiteral:
+		p.write("[")
+		for idx, elem := range e.Elements {
+			if idx > 0 {
+				p.write(", ")
+			}
+			if elem != nil {
+				p.expression(elem, precAssignment)
+			}
+		}
+		if n := len(e.Elements); n > 0 && e.Elements[n-1] == nil {
+			p.write(",")
+		}
+		p.write("]")
+	case *ObjectLiteral:
+		p.objectLiteral(e)
+	case *SpreadElement:
+		p.write("...")
+		p.expression(e.Argument, precAssignment)
+	case *YieldExpression:
+		p.write("yield")
+		if e.Delegate {
+			p.write("*")
+		}
+		if e.Argument != nil {
+			p.write(" ")
+			p.expression(e.Argument, precAssignment)
+		}
+	case *FunctionExpression:
+		p.function(e.ID, e.Params, e.Body, e.Directives, e.Generator)
+	case *ArrowFunctionExpression:
+		p.arrow(e)
+	case *ClassExpression:
+		p.class(e.ID, e.SuperClass, e.Body)
+	case *SequenceExpression:
+		for idx, expr := range e.Expressions {
+			if idx > 0 {
+				p.write(", ")
+			}
+			p.expression(expr, precAssignment)
+		}
+	case *AssignmentExpression:
+		p.expression(e.Left, precCall)
+		p.write(" ", e.Operator, " ")
+		p.expression(e.Right, precAssignment)
+	case *ConditionalExpression:
+		p.expression(e.Test, precNullish)
+		p.write(" ? ")
+		p.expression(e.Consequent, precAssignment)
+		p.write(" : ")
+		p.expression(e.Alternate, precAssignment)
+	case *LogicalExpression:
+		prec := precedenceOf(e)
+		p.logicalOperand(e, e.Left, prec)
+		p.write(" ", e.Operator, " ")
+		p.logicalOperand(e, e.Right, prec+1)
+	case *BinaryExpression:
+		prec := precedenceOf(e)
+		if e.Operator == "**" {
+			// Exponentiation is right-associative and rejects a bare unary
+			// operand on its left.
+			p.expression(e.Left, precPostfix)
+			p.write(" ** ")
+			p.expression(e.Right, prec)
+			break
+		}
+		p.expression(e.Left, prec)
+		p.write(" ", e.Operator, " ")
+		p.expression(e.Right, prec+1)
+	case *UnaryExpression:
+		arg := printer{indent: p.indent, noIn: p.noIn}
+		arg.expression(e.Argument, precPrefix)
+		text := arg.b.String()
+		p.write(e.Operator)
+		switch {
+		case e.Operator == "typeof" || e.Operator == "void" || e.Operator == "delete":
+			p.write(" ")
+		case (e.Operator == "+" || e.Operator == "-") && strings.HasPrefix(text, e.Operator):
+			// Keep - -x from reading as --x.
+			p.write(" ")
+		}
+		p.write(text)
+	case *UpdateExpression:
+		if e.Prefix {
+			p.write(e.Operator)
+			p.expression(e.Argument, precCall)
+		} else {
+			p.expression(e.Argument, precCall)
+			p.write(e.Operator)
+		}
+	case *MemberExpression:
+		p.memberObject(e.Object)
+		if e.Computed {
+			if e.Optional {
+				p.write("?.")
+			}
+			p.write("[")
+			p.expression(e.Property, precSequence)
+			p.write("]")
+		} else {
+			if e.Optional {
+				p.write("?.")
+			} else {
+				p.write(".")
+			}
+			p.expression(e.Property, precPrimary)
+		}
+	case *CallExpression:
+		p.memberObject(e.Callee)
+		if e.Optional {
+			p.write("?.")
+		}
+		p.arguments(e.Arguments)
+	case *NewExpression:
+		p.write("new ")
+		if containsCall(e.Callee) {
+			p.write("(")
+			p.expression(e.Callee, precSequence)
+			p.write(")")
+		} else {
+			p.expression(e.Callee, precCall)
+		}
+		p.arguments(e.Arguments)
+	case *ChainExpression:
+		p.expression(e.Expression, precCall)
+	case *TaggedTemplateExpression:
+		p.memberObject(e.Tag)
+		p.template(e.Quasi)
+	default:
+		p.node(e)
+	}
+}
+
+// logicalOperand prints an operand of a logical expression, parenthesizing
+// ?? mixed with || or &&, which the grammar does not allow unparenthesized.
+func (p *printer) logicalOperand(parent *LogicalExpression, operand Expression, min int) {
+	if child, ok := operand.(*LogicalExpression); ok && (parent.Operator == "??") != (child.Operator == "??") {
+		min = precPrimary
+	}
+	p.expression(operand, min)
+}
+
+// memberObject prints the object of a member access, the callee of a call or
+// the tag of a template, where an optional chain must be parenthesized to
+// end it and an integer literal needs parentheses to keep its dot.
+func (p *printer) memberObject(e Expression) {
+	min := precCall
+	switch e := e.(type) {
+	case *ChainExpression:
+		min = precPrimary + 1
+	case *NumberLiteral:
+		if !strings.ContainsAny(e.Value, ".eExXoObB") {
+			min = precPrimary + 1
+		}
+	}
+	p.expression(e, min)
+}
+
+func (p *printer) arguments(args []Expression) {
+	p.write("(")
+	for idx, arg := range args {
+		if idx > 0 {
+			p.write(", ")
+		}
+		p.expression(arg, precAssignment)
+	}
+	p.write(")")
+}
+
+func (p *printer) arrow(e *ArrowFunctionExpression) {
+	if len(e.Params) == 1 {
+		if id, ok := e.Params[0].(*Identifier); ok {
+			p.write(id.Name)
+		} else {
+			p.params(e.Params)
+		}
+	} else {
+		p.params(e.Params)
+	}
+	p.write(" => ")
+	switch body := e.Body.(type) {
+	case *BlockStatement:
+		p.functionBody(e.Directives, body.Body)
+	case Expression:
+		if _, ok := leftmost(body).(*ObjectLiteral); ok {
+			p.write("(")
+			p.expression(body, precSequence)
+			p.write(")")
+		} else {
+			p.expression(body, precAssignment)
+		}
+	default:
+		p.node(body)
+	}
+}
+
+func (p *printer) objectLiteral(o *ObjectLiteral) {
+	if len(o.Properties) == 0 {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	for idx, prop := range o.Properties {
+		if idx > 0 {
+			p.write(",")
+		}
+		p.write(" ")
+		switch prop := prop.(type) {
+		case *SpreadElement:
+			p.expression(prop, precAssignment)
+		case *ObjectProperty:
+			fn, isFunction := prop.Value.(*FunctionExpression)
+			switch {
+			case isFunction && (prop.Method || prop.PropKind != PropertyInit):
+				p.method(prop.PropKind, prop.Key, prop.Computed, fn)
+			case prop.Shorthand:
+				p.propertyKey(prop.Key, false)
+			default:
+				p.propertyKey(prop.Key, prop.Computed)
+				p.write(": ")
+				p.expression(prop.Value, precAssignment)
+			}
+		default:
+			p.node(prop)
+		}
+	}
+	p.write(" }")
+}
+
+func (p *printer) template(t *TemplateLiteral) {
+	p.write("`")
+	for idx, quasi := range t.Quasis {
+		p.write(quasi.Raw)
+		if idx < len(t.Expressions) {
+			p.write("${")
+			p.expression(t.Expressions[idx], precSequence)
+			p.write("}")
+		}
+	}
+	p.write("`")
+}
+
+// Patterns -------------------------------------------------------------------
+
+func (p *printer) pattern(n Pattern) {
+	switch n := n.(type) {
+	case *Identifier:
+		p.write(n.Name)
+	case *AssignmentPattern:
+		p.pattern(n.Left)
+		p.write(" = ")
+		p.expression(n.Right, precAssignment)
+	case *RestElement:
+		p.write("...")
+		p.pattern(n.Argument)
+	case *ArrayPattern:
+		p.write("[")
+		for idx, elem := range n.Elements {
+			if idx > 0 {
+				p.write(", ")
+			}
+			if elem != nil {
+				p.pattern(elem)
+			}
+		}
+		if n.Rest != nil {
+			if len(n.Elements) > 0 {
+				p.write(", ")
+			}
+			p.pattern(n.Rest)
+		} else if len(n.Elements) > 0 && n.Elements[len(n.Elements)-1] == nil {
+			p.write(",")
+		}
+		p.write("]")
+	case *ObjectPattern:
+		if len(n.Properties) == 0 && n.Rest == nil {
+			p.write("{}")
+			return
+		}
+		p.write("{")
+		for idx, prop := range n.Properties {
+			if idx > 0 {
+				p.write(",")
+			}
+			p.write(" ")
+			if prop.Shorthand {
+				p.pattern(prop.Value)
+				continue
+			}
+			p.propertyKey(prop.Key, prop.Computed)
+			p.write(": ")
+			p.pattern(prop.Value)
+		}
+		if n.Rest != nil {
+			if len(n.Properties) > 0 {
+				p.write(",")
+			}
+			p.write(" ")
+			p.pattern(n.Rest)
+		}
+		p.write(" }")
+	case Expression:
+		// Assignment targets such as member expressions.
+		p.expression(n, precCall)
+	default:
+		p.node(n)
+	}
+}
+
+// node prints nodes that have no source form of their own.
+func (p *printer) node(n Node) {
+	p.write("/* ", string(n.Kind()), " */")
+}
+
+// leftmost returns the expression whose text starts the printed form of e.
+func leftmost(e Expression) Expression {
+	for {
+		switch x := e.(type) {
+		case *BinaryExpression:
+			e = x.Left
+		case *LogicalExpression:
+			e = x.Left
+		case *AssignmentExpression:
+			e = x.Left
+		case *ConditionalExpression:
+			e = x.Test
+		case *SequenceExpression:
+			if len(x.Expressions) == 0 {
+				return e
+			}
+			e = x.Expressions[0]
+		case *MemberExpression:
+			e = x.Object
+		case *CallExpression:
+			e = x.Callee
+		case *TaggedTemplateExpression:
+			e = x.Tag
+		case *ChainExpression:
+			e = x.Expression
+		case *UpdateExpression:
+			if x.Prefix {
+				return e
+			}
+			e = x.Argument
+		default:
+			return e
+		}
+	}
+}
+
+// containsCall reports whether a call appears along the member chain of a
+// new callee, where it would otherwise be taken as the new arguments.
+func containsCall(e Expression) bool {
+	for {
+		switch x := e.(type) {
+		case *CallExpression:
+			return true
+		case *MemberExpression:
+			e = x.Object
+		case *TaggedTemplateExpression:
+			e = x.Tag
+		case *ChainExpression:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// quote renders s as a double-quoted JavaScript string literal.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\u2028', '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\x%02x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
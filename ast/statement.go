@@ -40,6 +40,12 @@ type Program struct {
 	BaseNode
 	Body       []Statement
 	SourceType SourceType
+	// Directives holds the raw source text of the directive prologue,
+	// quotes included, so escaped spellings stay distinguishable.
+	Directives []string
+	// Strict reports whether the program is strict mode code, through a
+	// "use strict" directive or because it is a module.
+	Strict bool
 }
 
 func NewProgram(body []Statement, sourceType SourceType, loc Location) *Program {
@@ -371,7 +377,7 @@ func (t *TryStatement) String() string {
 // CatchClause represents the catch (binding) { } handler.
 type CatchClause struct {
 	BaseNode
-	Param Pattern
+	Param Pattern // nil when the binding is omitted
 	Body  *BlockStatement
 }
 
@@ -434,6 +440,12 @@ type FunctionDeclaration struct {
 	Params    []Pattern
 	Body      *BlockStatement
 	Generator bool
+	// Directives holds the raw source text of the directive prologue,
+	// quotes included, so escaped spellings stay distinguishable.
+	Directives []string
+	// Strict reports whether the function is strict mode code, either through a
+	// "use strict" directive or by appearing in strict code.
+	Strict bool
 }
 
 func NewFunctionDeclaration(id *Identifier, params []Pattern, body *BlockStatement, generator bool, loc Location) *FunctionDeclaration {
@@ -0,0 +1,181 @@
+package ast
+
+import "reflect"
+
+// Children returns the direct child nodes of n in source order. Absent
+// optional children, such as a missing else branch or array holes, are
+// omitted.
+func Children(n Node) []Node {
+	var c children
+	switch n := n.(type) {
+	case *Program:
+		addAll(&c, n.Body)
+	case *BlockStatement:
+		addAll(&c, n.Body)
+	case *ExpressionStatement:
+		c.add(n.Expression)
+	case *ReturnStatement:
+		c.add(n.Argument)
+	case *BreakStatement:
+		c.add(n.Label)
+	case *ContinueStatement:
+		c.add(n.Label)
+	case *ThrowStatement:
+		c.add(n.Argument)
+	case *IfStatement:
+		c.add(n.Test, n.Consequent, n.Alternate)
+	case *WhileStatement:
+		c.add(n.Test, n.Body)
+	case *DoWhileStatement:
+		c.add(n.Body, n.Test)
+	case *ForStatement:
+		c.add(n.Init, n.Test, n.Update, n.Body)
+	case *ForInStatement:
+		c.add(n.Left, n.Right, n.Body)
+	case *ForOfStatement:
+		c.add(n.Left, n.Right, n.Body)
+	case *SwitchStatement:
+		c.add(n.Discriminant)
+		addAll(&c, n.Cases)
+	case *SwitchCase:
+		c.add(n.Test)
+		addAll(&c, n.Consequent)
+	case *WithStatement:
+		c.add(n.Object, n.Body)
+	case *LabeledStatement:
+		c.add(n.Label, n.Body)
+	case *TryStatement:
+		c.add(n.Block, n.Handler, n.Finalizer)
+	case *CatchClause:
+		c.add(n.Param, n.Body)
+	case *VariableDeclaration:
+		addAll(&c, n.Declarations)
+	case *VariableDeclarator:
+		c.add(n.ID, n.Init)
+	case *FunctionDeclaration:
+		c.add(n.ID)
+		addAll(&c, n.Params)
+		c.add(n.Body)
+	case *ClassDeclaration:
+		c.add(n.ID, n.SuperClass, n.Body)
+	case *ClassExpression:
+		c.add(n.ID, n.SuperClass, n.Body)
+	case *ClassBody:
+		addAll(&c, n.Body)
+	case *MethodDefinition:
+		c.add(n.Key, n.Value)
+	case *StaticBlock:
+		addAll(&c, n.Body)
+	case *ImportDeclaration:
+		addAll(&c, n.Specifiers)
+		c.add(n.Source)
+	case *ImportSpecifier:
+		c.add(n.Imported, n.Local)
+	case *ImportDefaultSpecifier:
+		c.add(n.Local)
+	case *ImportNamespaceSpecifier:
+		c.add(n.Local)
+	case *ExportNamedDeclaration:
+		c.add(n.Declaration)
+		addAll(&c, n.Specifiers)
+		c.add(n.Source)
+	case *ExportSpecifier:
+		c.add(n.Local, n.Exported)
+	case *ExportDefaultDeclaration:
+		c.add(n.Declaration)
+	case *ExportAllDeclaration:
+		c.add(n.Exported, n.Source)
+	case *MetaProperty:
+		c.add(n.Meta, n.Property)
+	case *MemberExpression:
+		c.add(n.Object, n.Property)
+	case *CallExpression:
+		c.add(n.Callee)
+		addAll(&c, n.Arguments)
+	case *ChainExpression:
+		c.add(n.Expression)
+	case *NewExpression:
+		c.add(n.Callee)
+		addAll(&c, n.Arguments)
+	case *TaggedTemplateExpression:
+		c.add(n.Tag, n.Quasi)
+	case *BinaryExpression:
+		c.add(n.Left, n.Right)
+	case *LogicalExpression:
+		c.add(n.Left, n.Right)
+	case *AssignmentExpression:
+		c.add(n.Left, n.Right)
+	case *UnaryExpression:
+		c.add(n.Argument)
+	case *UpdateExpression:
+		c.add(n.Argument)
+	case *ConditionalExpression:
+		c.add(n.Test, n.Consequent, n.Alternate)
+	case *SequenceExpression:
+		addAll(&c, n.Expressions)
+	case *ArrowFunctionExpression:
+		addAll(&c, n.Params)
+		c.add(n.Body)
+	case *FunctionExpression:
+		c.add(n.ID)
+		addAll(&c, n.Params)
+		c.add(n.Body)
+	case *SpreadElement:
+		c.add(n.Argument)
+	case *YieldExpression:
+		c.add(n.Argument)
+	case *TemplateLiteral:
+		// Quasis and substitutions alternate in the source text.
+		for idx, quasi := range n.Quasis {
+			c.add(quasi)
+			if idx < len(n.Expressions) {
+				c.add(n.Expressions[idx])
+			}
+		}
+	case *ArrayLiteral:
+		addAll(&c, n.Elements)
+	case *ObjectLiteral:
+		addAll(&c, n.Properties)
+	case *ObjectProperty:
+		c.add(n.Key, n.Value)
+	case *ArrayPattern:
+		addAll(&c, n.Elements)
+		c.add(n.Rest)
+	case *ObjectPattern:
+		addAll(&c, n.Properties)
+		c.add(n.Rest)
+	case *ObjectPatternProperty:
+		c.add(n.Key, n.Value)
+	case *AssignmentPattern:
+		c.add(n.Left, n.Right)
+	case *RestElement:
+		c.add(n.Argument)
+	}
+	return c
+}
+
+type children []Node
+
+func (c *children) add(nodes ...Node) {
+	for _, n := range nodes {
+		if !isNilNode(n) {
+			*c = append(*c, n)
+		}
+	}
+}
+
+func addAll[T Node](c *children, nodes []T) {
+	for _, n := range nodes {
+		c.add(n)
+	}
+}
+
+// isNilNode reports whether n is nil, including typed nil pointers stored in
+// optional fields such as FunctionExpression.ID.
+func isNilNode(n Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
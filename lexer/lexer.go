@@ -15,6 +15,7 @@ type templateContext struct {
 // Lexer transforms ECMAScript source text into a stream of tokens.
 type Lexer struct {
 	src                  string
+	base                 int // offset of src[0] within the enclosing text
 	ch                   rune
 	chPos                Position
 	nextPos              Position
@@ -27,11 +28,24 @@ type Lexer struct {
 	err                  error
 }
 
-// New creates a new lexer with the provided ECMAScript source code.
+// New creates a new lexer with the provided ECMAScript source code. A
+// hashbang line such as "#!/usr/bin/env node" at the very start of src is
+// skipped like a single-line comment.
 func New(src string) *Lexer {
+	l := NewAt(src, Position{Line: 1, Column: 0, Offset: 0})
+	if l.ch == '#' && l.peekRune() == '!' {
+		l.consumeLineComment()
+	}
+	return l
+}
+
+// NewAt creates a lexer for src as if it began at start within a larger
+// document, so reported positions line up with the enclosing text.
+func NewAt(src string, start Position) *Lexer {
 	l := &Lexer{
 		src:           src,
-		nextPos:       Position{Line: 1, Column: 0, Offset: 0},
+		base:          start.Offset,
+		nextPos:       start,
 		canStartRegex: true,
 		lastTokenType: Illegal,
 	}
@@ -39,20 +53,38 @@ func New(src string) *Lexer {
 	return l
 }
 
+// Tokenize lexes all of src and returns its tokens, excluding the final EOF
+// token. Lexing stops at the first Illegal token, which is reported as an
+// error carrying its position; the tokens before it are still returned.
+func Tokenize(src string) ([]Token, error) {
+	l := New(src)
+	var tokens []Token
+	for {
+		tok := l.NextToken()
+		switch tok.Type {
+		case EOF:
+			return tokens, nil
+		case Illegal:
+			return tokens, fmt.Errorf("illegal token %q (line %d, column %d)", tok.Literal, tok.Start.Line, tok.Start.Column+1)
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
 // NextToken returns the next token from the input stream.
 func (l *Lexer) NextToken() Token {
 	for {
 		if len(l.buffer) > 0 {
 			tok := l.buffer[0]
 			l.buffer = l.buffer[1:]
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		}
 
 		if l.err != nil {
 			tok := Token{Type: Illegal, Literal: l.err.Error(), Start: l.chPos, End: l.chPos}
 			l.err = nil
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		}
 
@@ -75,7 +107,7 @@ func (l *Lexer) NextToken() Token {
 		switch l.ch {
 		case 0:
 			tok := Token{Type: EOF, Start: start, End: start}
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		case '`':
 			if err := l.lexTemplateChunk(true); err != nil {
@@ -85,42 +117,42 @@ func (l *Lexer) NextToken() Token {
 			continue
 		case '/':
 			if tok, ok := l.scanSlash(start); ok {
-				l.updateAfterToken(tok)
+				l.updateAfterToken(&tok)
 				return tok
 			}
 		case '\'', '"':
 			if tok, ok := l.scanString(start, l.ch); ok {
-				l.updateAfterToken(tok)
+				l.updateAfterToken(&tok)
 				return tok
 			}
 		case '.':
 			tok := l.scanDot(start)
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		case '+', '-', '*', '%', '&', '|', '^', '!', '=', '<', '>', '?', ':':
 			tok := l.scanOperator(start)
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		case '{', '}', '(', ')', '[', ']', ',', ';':
 			tok := l.scanPunctuation(start)
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		default:
-			if l.isIdentifierStart(l.ch) {
+			if l.isIdentifierStart(l.ch) || l.ch == '\\' {
 				tok := l.scanIdentifier(start)
-				l.updateAfterToken(tok)
+				l.updateAfterToken(&tok)
 				return tok
 			}
 			if unicode.IsDigit(l.ch) {
 				tok := l.scanNumber(start)
-				l.updateAfterToken(tok)
+				l.updateAfterToken(&tok)
 				return tok
 			}
 
 			literal := string(l.ch)
 			l.advance()
 			tok := Token{Type: Illegal, Literal: fmt.Sprintf("unexpected character %q", literal), Start: start, End: l.chPos}
-			l.updateAfterToken(tok)
+			l.updateAfterToken(&tok)
 			return tok
 		}
 	}
@@ -163,6 +195,8 @@ func (l *Lexer) scanSlash(start Position) (Token, bool) {
 	return Token{Type: tokType, Literal: literal, Start: start, End: l.chPos}, true
 }
 
+// scanString reads a string literal. Unlike '\n', U+2028 and U+2029 may
+// appear unescaped inside one, as they may in JSON.
 func (l *Lexer) scanString(start Position, quote rune) (Token, bool) {
 	l.advance()
 	for {
@@ -230,6 +264,14 @@ func (l *Lexer) scanOperator(start Position) Token {
 		return Token{Type: Minus, Literal: "-", Start: start, End: l.chPos}
 	case '*':
 		l.advance()
+		if l.ch == '*' {
+			l.advance()
+			if l.ch == '=' {
+				l.advance()
+				return Token{Type: ExponentAssign, Literal: "**=", Start: start, End: l.chPos}
+			}
+			return Token{Type: Exponent, Literal: "**", Start: start, End: l.chPos}
+		}
 		if l.ch == '=' {
 			l.advance()
 			return Token{Type: MultiplyAssign, Literal: "*=", Start: start, End: l.chPos}
@@ -246,6 +288,10 @@ func (l *Lexer) scanOperator(start Position) Token {
 		l.advance()
 		if l.ch == '&' {
 			l.advance()
+			if l.ch == '=' {
+				l.advance()
+				return Token{Type: LogicalAndAssign, Literal: "&&=", Start: start, End: l.chPos}
+			}
 			return Token{Type: LogicalAnd, Literal: "&&", Start: start, End: l.chPos}
 		}
 		if l.ch == '=' {
@@ -257,6 +303,10 @@ func (l *Lexer) scanOperator(start Position) Token {
 		l.advance()
 		if l.ch == '|' {
 			l.advance()
+			if l.ch == '=' {
+				l.advance()
+				return Token{Type: LogicalOrAssign, Literal: "||=", Start: start, End: l.chPos}
+			}
 			return Token{Type: LogicalOr, Literal: "||", Start: start, End: l.chPos}
 		}
 		if l.ch == '=' {
@@ -337,6 +387,19 @@ func (l *Lexer) scanOperator(start Position) Token {
 		return Token{Type: GreaterThan, Literal: ">", Start: start, End: l.chPos}
 	case '?':
 		l.advance()
+		if l.ch == '?' {
+			l.advance()
+			if l.ch == '=' {
+				l.advance()
+				return Token{Type: NullishAssign, Literal: "??=", Start: start, End: l.chPos}
+			}
+			return Token{Type: NullishCoalescing, Literal: "??", Start: start, End: l.chPos}
+		}
+		// In a ? .5 : b the dot starts a number, so ?. requires a non-digit after it.
+		if l.ch == '.' && !unicode.IsDigit(l.peekRune()) {
+			l.advance()
+			return Token{Type: OptionalChain, Literal: "?.", Start: start, End: l.chPos}
+		}
 		return Token{Type: Question, Literal: "?", Start: start, End: l.chPos}
 	case ':':
 		l.advance()
@@ -386,17 +449,83 @@ func (l *Lexer) scanPunctuation(start Position) Token {
 	return Token{Type: Illegal, Literal: l.slice(start, l.chPos), Start: start, End: l.chPos}
 }
 
+// scanIdentifier reads an identifier name, decoding any \u escapes so that the
+// literal holds the name as the parser should see it.
 func (l *Lexer) scanIdentifier(start Position) Token {
 	var b strings.Builder
-	for l.isIdentifierPart(l.ch) {
+	escaped := false
+	for {
+		if l.ch == '\\' {
+			r, err := l.readIdentifierEscape()
+			if err == nil {
+				valid := l.isIdentifierPart(r)
+				if b.Len() == 0 {
+					valid = l.isIdentifierStart(r)
+				}
+				if !valid {
+					err = fmt.Errorf("invalid identifier escape %U", r)
+				}
+			}
+			if err != nil {
+				l.err = err
+				return Token{Type: Illegal, Literal: l.slice(start, l.chPos), Start: start, End: l.chPos}
+			}
+			b.WriteRune(r)
+			escaped = true
+			continue
+		}
+		if !l.isIdentifierPart(l.ch) {
+			break
+		}
 		b.WriteRune(l.ch)
 		l.advance()
 	}
 	literal := b.String()
 	typ := LookupIdentifier(literal)
+	if escaped && typ != Identifier {
+		l.err = fmt.Errorf("keyword %q must not contain escaped characters", literal)
+		return Token{Type: Illegal, Literal: l.slice(start, l.chPos), Start: start, End: l.chPos}
+	}
 	return Token{Type: typ, Literal: literal, Start: start, End: l.chPos}
 }
 
+// readIdentifierEscape consumes a \uXXXX or \u{X...} escape and returns the
+// code point it denotes.
+func (l *Lexer) readIdentifierEscape() (rune, error) {
+	l.advance()
+	if l.ch != 'u' {
+		return 0, fmt.Errorf("invalid escape in identifier")
+	}
+	l.advance()
+
+	var value rune
+	if l.ch == '{' {
+		l.advance()
+		digits := 0
+		for isHexDigit(l.ch) {
+			value = value*16 + hexValue(l.ch)
+			if value > unicode.MaxRune {
+				return 0, fmt.Errorf("unicode escape out of range")
+			}
+			digits++
+			l.advance()
+		}
+		if digits == 0 || l.ch != '}' {
+			return 0, fmt.Errorf("invalid unicode escape in identifier")
+		}
+		l.advance()
+		return value, nil
+	}
+	for n := 0; n < 4; n++ {
+		if !isHexDigit(l.ch) {
+			return 0, fmt.Errorf("invalid unicode escape in identifier")
+		}
+		value = value*16 + hexValue(l.ch)
+		l.advance()
+	}
+	return value, nil
+}
+
 func (l *Lexer) scanNumber(start Position) Token {
 	literal, typ, err := l.readNumberLiteral()
 	if err != nil {
@@ -414,57 +543,120 @@ func (l *Lexer) readNumberLiteral() (string, TokenType, error) {
 		case 'x', 'X':
 			l.advance()
 			l.advance()
-			if !l.consumeDigits(func(r rune) bool { return unicode.Is(unicode.Hex_Digit, r) }) {
+			ok, err := l.consumeSeparatedDigits(isHexDigit)
+			if err != nil {
+				return l.slice(start, l.chPos), Illegal, err
+			}
+			if !ok {
 				return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid hexadecimal literal")
 			}
-			literal := l.slice(start, l.chPos)
-			return literal, Number, nil
+			return l.finishIntegerLiteral(start)
 		case 'o', 'O':
 			l.advance()
 			l.advance()
-			if !l.consumeDigits(isOctalDigit) {
+			ok, err := l.consumeSeparatedDigits(isOctalDigit)
+			if err != nil {
+				return l.slice(start, l.chPos), Illegal, err
+			}
+			if !ok {
 				return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid octal literal")
 			}
-			return l.slice(start, l.chPos), Number, nil
+			return l.finishIntegerLiteral(start)
 		case 'b', 'B':
 			l.advance()
 			l.advance()
-			if !l.consumeDigits(isBinaryDigit) {
+			ok, err := l.consumeSeparatedDigits(isBinaryDigit)
+			if err != nil {
+				return l.slice(start, l.chPos), Illegal, err
+			}
+			if !ok {
 				return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid binary literal")
 			}
-			return l.slice(start, l.chPos), Number, nil
+			return l.finishIntegerLiteral(start)
+		case '_':
+			l.advance()
+			return l.slice(start, l.chPos), Illegal, fmt.Errorf("numeric separators are not allowed after a leading 0")
 		}
 	}
 
-	l.consumeDigits(unicode.IsDigit)
+	leadingZero := l.ch == '0'
+	if _, err := l.consumeSeparatedDigits(unicode.IsDigit); err != nil {
+		return l.slice(start, l.chPos), Illegal, err
+	}
 
+	if l.ch == 'n' {
+		if leadingZero && l.chPos.Offset-start.Offset > 1 {
+			l.advance()
+			return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid BigInt literal")
+		}
+		return l.finishIntegerLiteral(start)
+	}
+
+	fractional := false
 	if l.ch == '.' {
+		fractional = true
 		l.advance()
-		if !l.consumeDigits(unicode.IsDigit) {
+		ok, err := l.consumeSeparatedDigits(unicode.IsDigit)
+		if err != nil {
+			return l.slice(start, l.chPos), Illegal, err
+		}
+		if !ok {
 			return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid floating-point literal")
 		}
 	}
 
 	if l.ch == 'e' || l.ch == 'E' {
+		fractional = true
 		l.advance()
 		if l.ch == '+' || l.ch == '-' {
 			l.advance()
 		}
-		if !l.consumeDigits(unicode.IsDigit) {
+		ok, err := l.consumeSeparatedDigits(unicode.IsDigit)
+		if err != nil {
+			return l.slice(start, l.chPos), Illegal, err
+		}
+		if !ok {
 			return l.slice(start, l.chPos), Illegal, fmt.Errorf("invalid exponent in numeric literal")
 		}
 	}
 
+	if fractional && l.ch == 'n' {
+		l.advance()
+		return l.slice(start, l.chPos), Illegal, fmt.Errorf("BigInt literals cannot have a fraction or exponent")
+	}
+
 	return l.slice(start, l.chPos), Number, nil
 }
 
-func (l *Lexer) consumeDigits(match func(rune) bool) bool {
-	count := 0
-	for match(l.ch) {
-		count++
+// finishIntegerLiteral consumes an optional BigInt suffix after the digits of
+// an integer literal.
+func (l *Lexer) finishIntegerLiteral(start Position) (string, TokenType, error) {
+	if l.ch == 'n' {
 		l.advance()
+		return l.slice(start, l.chPos), BigInt, nil
+	}
+	return l.slice(start, l.chPos), Number, nil
+}
+
+// consumeSeparatedDigits consumes digits accepted by match, allowing single
+// underscores between two digits. It reports whether any digit was consumed.
+func (l *Lexer) consumeSeparatedDigits(match func(rune) bool) (bool, error) {
+	count := 0
+	for {
+		switch {
+		case match(l.ch):
+			count++
+			l.advance()
+		case l.ch == '_':
+			if count == 0 || !match(l.peekRune()) {
+				l.advance()
+				return count > 0, fmt.Errorf("numeric separators are only allowed between digits")
+			}
+			l.advance()
+		default:
+			return count > 0, nil
+		}
 	}
-	return count > 0
 }
 
 func (l *Lexer) lexTemplateChunk(startWithBacktick bool) error {
@@ -530,7 +722,7 @@ func (l *Lexer) scanRegularExpression(start Position) (Token, error) {
 	inClass := false
 	for {
 		switch l.ch {
-		case 0, '\n':
+		case 0, '\n', '\u2028', '\u2029':
 			return Token{}, errors.New("unterminated regular expression literal")
 		case '/':
 			if !inClass {
@@ -567,14 +759,19 @@ func (l *Lexer) skipWhitespaceAndComments() {
 	for {
 		progressed := false
 		switch l.ch {
-		case ' ', '\t', '\f', '\v', '\u00a0':
+		case ' ', '\t', '\f', '\v', '\ufeff':
 			l.advance()
 			progressed = true
-		case '\n':
+		case '\n', '\u2028', '\u2029':
 			l.lineTerminatorBefore = true
 			l.advance()
 			progressed = true
 		default:
+			if unicode.Is(unicode.Zs, l.ch) {
+				l.advance()
+				progressed = true
+				continue
+			}
 			if l.ch == '/' {
 				next := l.peekRune()
 				if next == '/' {
@@ -603,7 +800,7 @@ func (l *Lexer) skipWhitespaceAndComments() {
 }
 
 func (l *Lexer) consumeLineComment() {
-	for l.ch != 0 && l.ch != '\n' {
+	for l.ch != 0 && !isLineTerminator(l.ch) {
 		l.advance()
 	}
 }
@@ -618,14 +815,17 @@ func (l *Lexer) consumeBlockComment() error {
 			l.advance()
 			return nil
 		}
-		if l.ch == '\n' {
+		if isLineTerminator(l.ch) {
 			l.lineTerminatorBefore = true
 		}
 		l.advance()
 	}
 }
 
-func (l *Lexer) updateAfterToken(tok Token) {
+// updateAfterToken records tok as the most recent token and updates the
+// lexing state that depends on it.
+func (l *Lexer) updateAfterToken(tok *Token) {
+	tok.NewlineBefore = l.lineTerminatorBefore
 	switch tok.Type {
 	case LBrace:
 		if len(l.contexts) > 0 {
@@ -637,7 +837,7 @@ func (l *Lexer) updateAfterToken(tok Token) {
 			l.contexts[len(l.contexts)-1].braceDepth--
 		}
 		l.canStartRegex = false
-	case Identifier, Number, String, TrueLiteral, FalseLiteral, NullLiteral, TemplateTail, RParen, RBracket:
+	case Identifier, Number, BigInt, String, TrueLiteral, FalseLiteral, NullLiteral, TemplateTail, RParen, RBracket:
 		l.canStartRegex = false
 	case Increment, Decrement:
 		l.canStartRegex = true
@@ -664,10 +864,11 @@ func (l *Lexer) isIdentifierPart(r rune) bool {
 }
 
 func (l *Lexer) peekRune() rune {
-	if l.nextPos.Offset >= len(l.src) {
+	offset := l.nextPos.Offset - l.base
+	if offset >= len(l.src) {
 		return 0
 	}
-	r, _ := utf8.DecodeRuneInString(l.src[l.nextPos.Offset:])
+	r, _ := utf8.DecodeRuneInString(l.src[offset:])
 	if r == '\r' {
 		return '\n'
 	}
@@ -675,7 +876,7 @@ func (l *Lexer) peekRune() rune {
 }
 
 func (l *Lexer) peekRuneN(n int) rune {
-	offset := l.nextPos.Offset
+	offset := l.nextPos.Offset - l.base
 	var r rune
 	for i := 0; i < n; i++ {
 		if offset >= len(l.src) {
@@ -703,32 +904,45 @@ func (l *Lexer) peekRuneN(n int) rune {
 
 func (l *Lexer) advance() {
 	pos := l.nextPos
-	if pos.Offset >= len(l.src) {
+	idx := pos.Offset - l.base
+	if idx >= len(l.src) {
 		l.ch = 0
 		l.chPos = pos
 		return
 	}
 
-	r, size := utf8.DecodeRuneInString(l.src[pos.Offset:])
-	offset := pos.Offset + size
+	r, size := utf8.DecodeRuneInString(l.src[idx:])
+	idx += size
 	if r == '\r' {
 		r = '\n'
-		if offset < len(l.src) && l.src[offset] == '\n' {
-			offset++
+		if idx < len(l.src) && l.src[idx] == '\n' {
+			idx++
 		}
 	}
+	offset := l.base + idx
 
 	l.ch = r
 	l.chPos = Position{Offset: pos.Offset, Line: pos.Line, Column: pos.Column}
-	if r == '\n' {
+	if isLineTerminator(r) {
 		l.nextPos = Position{Offset: offset, Line: pos.Line + 1, Column: 0}
 	} else {
-		l.nextPos = Position{Offset: offset, Line: pos.Line, Column: pos.Column + 1}
+		// Columns count UTF-16 code units, so astral code points take two.
+		width := 1
+		if r > 0xFFFF {
+			width = 2
+		}
+		l.nextPos = Position{Offset: offset, Line: pos.Line, Column: pos.Column + width}
 	}
 }
 
+// isLineTerminator reports whether r ends a line. Carriage returns have
+// already been folded into '\n' by advance.
+func isLineTerminator(r rune) bool {
+	return r == '\n' || r == '\u2028' || r == '\u2029'
+}
+
 func (l *Lexer) slice(start, end Position) string {
-	return l.src[start.Offset:end.Offset]
+	return l.src[start.Offset-l.base : end.Offset-l.base]
 }
 
 func isOctalDigit(r rune) bool {
@@ -738,3 +952,19 @@ func isOctalDigit(r rune) bool {
 func isBinaryDigit(r rune) bool {
 	return r == '0' || r == '1'
 }
+
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+// hexValue returns the numeric value of an ASCII hex digit.
+func hexValue(r rune) rune {
+	switch {
+	case r >= '0' && r <= '9':
+		return r - '0'
+	case r >= 'a' && r <= 'f':
+		return r - 'a' + 10
+	default:
+		return r - 'A' + 10
+	}
+}
@@ -16,6 +16,9 @@ type Token struct {
 	Literal string
 	Start   Position
 	End     Position
+	// NewlineBefore reports whether a line terminator, possibly inside a
+	// comment, separates the token from the previous one.
+	NewlineBefore bool
 }
 
 // Position tracks a byte offset and human readable coordinates within the source.
@@ -33,6 +36,7 @@ const (
 
 	Identifier TokenType = "IDENT"
 	Number     TokenType = "NUMBER"
+	BigInt     TokenType = "BIGINT"
 	String     TokenType = "STRING"
 	Regex      TokenType = "REGEXP"
 )
@@ -67,6 +71,8 @@ const (
 	Dot       TokenType = "DOT"
 	Question  TokenType = "QUESTION"
 	Backtick  TokenType = "BACKTICK"
+
+	OptionalChain TokenType = "OPTIONAL_CHAIN"
 )
 
 // Operator tokens covering arithmetic, comparison, logical, and assignment operators.
@@ -78,6 +84,7 @@ const (
 	Multiply   TokenType = "MULTIPLY"
 	Divide     TokenType = "DIVIDE"
 	Modulo     TokenType = "MODULO"
+	Exponent   TokenType = "EXPONENT"
 	Increment  TokenType = "INCREMENT"
 	Decrement  TokenType = "DECREMENT"
 	BitwiseNot TokenType = "BITWISE_NOT"
@@ -91,8 +98,9 @@ const (
 	BitwiseOr  TokenType = "BITWISE_OR"
 	BitwiseXor TokenType = "BITWISE_XOR"
 
-	LogicalAnd TokenType = "LOGICAL_AND"
-	LogicalOr  TokenType = "LOGICAL_OR"
+	LogicalAnd        TokenType = "LOGICAL_AND"
+	LogicalOr         TokenType = "LOGICAL_OR"
+	NullishCoalescing TokenType = "NULLISH_COALESCING"
 
 	Equal          TokenType = "EQUAL"
 	StrictEqual    TokenType = "STRICT_EQUAL"
@@ -108,12 +116,16 @@ const (
 	MultiplyAssign      TokenType = "MULTIPLY_ASSIGN"
 	DivideAssign        TokenType = "DIVIDE_ASSIGN"
 	ModuloAssign        TokenType = "MODULO_ASSIGN"
+	ExponentAssign      TokenType = "EXPONENT_ASSIGN"
 	ShiftLeftAssign     TokenType = "SHIFT_LEFT_ASSIGN"
 	ShiftRightAssign    TokenType = "SHIFT_RIGHT_ASSIGN"
 	UnsignedShiftAssign TokenType = "UNSIGNED_SHIFT_ASSIGN"
 	BitwiseAndAssign    TokenType = "BITWISE_AND_ASSIGN"
 	BitwiseOrAssign     TokenType = "BITWISE_OR_ASSIGN"
 	BitwiseXorAssign    TokenType = "BITWISE_XOR_ASSIGN"
+	LogicalAndAssign    TokenType = "LOGICAL_AND_ASSIGN"
+	LogicalOrAssign     TokenType = "LOGICAL_OR_ASSIGN"
+	NullishAssign       TokenType = "NULLISH_ASSIGN"
 
 	Arrow    TokenType = "ARROW"
 	Ellipsis TokenType = "ELLIPSIS"
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+
+	"es6-interpreter/lexer"
+	"es6-interpreter/parser"
+	"es6-interpreter/vm"
 )
 
 const version = "0.1.0-pre"
@@ -40,8 +47,86 @@ func main() {
 }
 
 func startREPL() error {
-	// TODO: Implement full REPL once lexer, parser, and VM are ready.
-	return errors.New("REPL is not implemented yet")
+	fmt.Println("es6-interpreter", version, "- press Ctrl-D to exit")
+	return runREPL(os.Stdin, os.Stdout)
+}
+
+// runREPL reads programs from in and prints each completion value to out.
+// Input spanning several lines is gathered until its brackets balance; a
+// blank line submits whatever has been gathered. All programs run against
+// one interpreter, so declarations persist between inputs. Parse and runtime
+// errors are reported without ending the session.
+func runREPL(in io.Reader, out io.Writer) error {
+	intr := vm.NewInterpreter()
+	defer intr.Close()
+	intr.SetOutput(out)
+	scanner := bufio.NewScanner(in)
+	var pending strings.Builder
+
+	prompt := func() {
+		if pending.Len() == 0 {
+			fmt.Fprint(out, "> ")
+		} else {
+			fmt.Fprint(out, "... ")
+		}
+	}
+
+	prompt()
+	for scanner.Scan() {
+		line := scanner.Text()
+		if pending.Len() == 0 && strings.TrimSpace(line) == "" {
+			prompt()
+			continue
+		}
+		pending.WriteString(line)
+		pending.WriteString("\n")
+		if strings.TrimSpace(line) != "" && unbalanced(pending.String()) {
+			prompt()
+			continue
+		}
+
+		evalREPLInput(intr, pending.String(), out)
+		pending.Reset()
+		prompt()
+	}
+	fmt.Fprintln(out)
+	return scanner.Err()
+}
+
+func evalREPLInput(intr *vm.Interpreter, src string, out io.Writer) {
+	program, err := parser.New(src).ParseProgram()
+	if err != nil {
+		fmt.Fprintf(out, "SyntaxError: %v\n", err)
+		return
+	}
+	result, err := intr.Execute(program)
+	if err != nil {
+		// Thrown values already read as uncaught; runtime errors do not.
+		var exc *vm.Exception
+		if errors.As(err, &exc) {
+			fmt.Fprintln(out, err)
+		} else {
+			fmt.Fprintf(out, "Uncaught %v\n", err)
+		}
+		return
+	}
+	fmt.Fprintln(out, result.Inspect())
+}
+
+// unbalanced reports whether src leaves a parenthesis, bracket or brace open,
+// meaning the input continues on the next line.
+func unbalanced(src string) bool {
+	depth := 0
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != lexer.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case lexer.LParen, lexer.LBracket, lexer.LBrace:
+			depth++
+		case lexer.RParen, lexer.RBracket, lexer.RBrace:
+			depth--
+		}
+	}
+	return depth > 0
 }
 
 func runFile(path string) error {
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestREPLKeepsEnvironmentBetweenInputs(t *testing.T) {
+	input := strings.Join([]string{
+		"let x = 1",
+		"x + 1",
+		"function double(n) {",
+		"  return n * 2;",
+		"}",
+		"",
+		"double(x + 1)",
+		"let = ;",
+		"missing",
+		`throw "boom"`,
+		"x",
+	}, "\n")
+
+	var out strings.Builder
+	if err := runREPL(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("repl: %v", err)
+	}
+
+	var results []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		line = strings.TrimLeft(line, ">. ")
+		if line != "" {
+			results = append(results, line)
+		}
+	}
+	want := []string{"undefined", "2", "undefined", "4", "SyntaxError:", "Uncaught ReferenceError: missing is not defined", "Uncaught boom", "1"}
+	if len(results) < len(want) {
+		t.Fatalf("expected at least %d results, got %q", len(want), results)
+	}
+	for idx, w := range want {
+		if !strings.HasPrefix(results[idx], w) {
+			t.Fatalf("result %d: expected prefix %q, got %q (all: %q)", idx, w, results[idx], results)
+		}
+	}
+}
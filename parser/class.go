@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"es6-interpreter/ast"
+	"es6-interpreter/lexer"
+)
+
+func (p *Parser) parseClassDeclaration() ast.Statement {
+	start := p.curToken.Start
+
+	if !p.expectPeek(lexer.Identifier) {
+		return nil
+	}
+	id := ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+	p.checkBindingIdentifier(id)
+	p.declareLexical(id)
+
+	superClass, body := p.parseClassTail()
+	if body == nil {
+		return nil
+	}
+	return ast.NewClassDeclaration(id, superClass, body, p.locFrom(start, p.curToken.End))
+}
+
+func (p *Parser) parseClassExpression() ast.Expression {
+	start := p.curToken.Start
+
+	var id *ast.Identifier
+	if p.peekTokenIs(lexer.Identifier) {
+		p.nextToken()
+		id = ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+		p.checkBindingIdentifier(id)
+	}
+
+	superClass, body := p.parseClassTail()
+	if body == nil {
+		return nil
+	}
+	return ast.NewClassExpression(id, superClass, body, p.locFrom(start, p.curToken.End))
+}
+
+// parseClassTail parses the optional extends clause and the class body that
+// follow the class keyword and name. All parts of a class are strict mode
+// code.
+func (p *Parser) parseClassTail() (ast.Expression, *ast.ClassBody) {
+	outerStrict := p.strict
+	p.strict = true
+	defer func() { p.strict = outerStrict }()
+
+	var superClass ast.Expression
+	if p.peekTokenIs(lexer.KeywordExtends) {
+		p.nextToken()
+		p.nextToken()
+		superClass = p.parseExpression(prefixPrec)
+		if superClass == nil {
+			return nil, nil
+		}
+	}
+
+	if !p.expectPeek(lexer.LBrace) {
+		return nil, nil
+	}
+	start := p.curToken.Start
+	p.nextToken()
+
+	var elements []ast.ClassElement
+	hasConstructor := false
+	for !p.curTokenIs(lexer.RBrace) && !p.curTokenIs(lexer.EOF) {
+		if p.curTokenIs(lexer.Semicolon) {
+			p.nextToken()
+			continue
+		}
+		if p.isContextualKeyword("static") && p.peekTokenIs(lexer.LBrace) {
+			block := p.parseStaticBlock()
+			if block == nil {
+				return nil, nil
+			}
+			elements = append(elements, block)
+			p.nextToken()
+			continue
+		}
+		method := p.parseMethodDefinition()
+		if method == nil {
+			return nil, nil
+		}
+		if method.MethodKind == ast.MethodConstructor {
+			if hasConstructor {
+				p.syntaxError("a class may only have one constructor")
+			}
+			hasConstructor = true
+		}
+		elements = append(elements, method)
+		p.nextToken()
+	}
+
+	if !p.curTokenIs(lexer.RBrace) {
+		p.unterminated("class body")
+		return nil, nil
+	}
+	return superClass, ast.NewClassBody(elements, p.locFrom(start, p.curToken.End))
+}
+
+// parseStaticBlock parses a static initialization block. Its body has a
+// var scope of its own, like a function body.
+func (p *Parser) parseStaticBlock() *ast.StaticBlock {
+	start := p.curToken.Start
+	p.nextToken()
+
+	p.pushScope(true)
+	body, ok := p.parseBlockBody(nil).(*ast.BlockStatement)
+	p.popScope()
+	if !ok {
+		return nil
+	}
+	return ast.NewStaticBlock(body.Body, p.locFrom(start, p.curToken.End))
+}
+
+func (p *Parser) parseMethodDefinition() *ast.MethodDefinition {
+	start := p.curToken.Start
+
+	// static, get and set are ordinary method names when a parameter list
+	// follows them directly.
+	static := false
+	if p.isContextualKeyword("static") && !p.peekTokenIs(lexer.LParen) {
+		static = true
+		p.nextToken()
+	}
+
+	kind := ast.MethodMethod
+	generator := false
+	switch {
+	case p.curTokenIs(lexer.Multiply):
+		generator = true
+		p.nextToken()
+	case (p.isContextualKeyword("get") || p.isContextualKeyword("set")) && !p.peekTokenIs(lexer.LParen):
+		kind = ast.MethodKind(p.curToken.Literal)
+		p.nextToken()
+	}
+
+	key, computed := p.parsePropertyName("class body")
+	if key == nil {
+		return nil
+	}
+
+	if !computed && !static && propertyNameIs(key, "constructor") {
+		if kind != ast.MethodMethod || generator {
+			p.syntaxError("class constructor may not be an accessor or a generator")
+		}
+		kind = ast.MethodConstructor
+	}
+	if !computed && static && propertyNameIs(key, "prototype") {
+		p.syntaxError("classes may not have a static member named 'prototype'")
+	}
+
+	if !p.peekTokenIs(lexer.LParen) && kind != ast.MethodGet && kind != ast.MethodSet {
+		p.syntaxError("class fields are not supported")
+		return nil
+	}
+
+	propKind := ast.PropertyMethod
+	switch kind {
+	case ast.MethodGet:
+		propKind = ast.PropertyGet
+	case ast.MethodSet:
+		propKind = ast.PropertySet
+	}
+	value := p.parseMethodFunction(propKind, generator)
+	if value == nil {
+		return nil
+	}
+
+	return ast.NewMethodDefinition(key, value, kind, static, computed, p.locFrom(start, p.curToken.End))
+}
+
+// isContextualKeyword reports whether the current token is the identifier
+// name, which acts as a keyword only in certain positions.
+func (p *Parser) isContextualKeyword(name string) bool {
+	return p.curTokenIs(lexer.Identifier) && p.curToken.Literal == name
+}
+
+// propertyNameIs reports whether a non-computed property key spells name.
+func propertyNameIs(key ast.Expression, name string) bool {
+	switch k := key.(type) {
+	case *ast.Identifier:
+		return k.Name == name
+	case *ast.StringLiteral:
+		return k.Value == name
+	default:
+		return false
+	}
+}
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"errors"
-	"strconv"
 	"strings"
 
 	"es6-interpreter/ast"
@@ -12,6 +10,7 @@ import (
 func (p *Parser) registerPrefixFns() {
 	p.registerPrefix(lexer.Identifier, p.parseIdentifier)
 	p.registerPrefix(lexer.Number, p.parseNumberLiteral)
+	p.registerPrefix(lexer.BigInt, p.parseBigIntLiteral)
 	p.registerPrefix(lexer.String, p.parseStringLiteral)
 	p.registerPrefix(lexer.TrueLiteral, p.parseBooleanLiteral)
 	p.registerPrefix(lexer.FalseLiteral, p.parseBooleanLiteral)
@@ -32,6 +31,9 @@ func (p *Parser) registerPrefixFns() {
 	p.registerPrefix(lexer.KeywordVoid, p.parsePrefixExpression)
 	p.registerPrefix(lexer.KeywordDelete, p.parsePrefixExpression)
 	p.registerPrefix(lexer.KeywordNew, p.parseNewExpression)
+	p.registerPrefix(lexer.KeywordClass, p.parseClassExpression)
+	p.registerPrefix(lexer.KeywordFunction, p.parseFunctionExpression)
+	p.registerPrefix(lexer.KeywordYield, p.parseYieldExpression)
 	p.registerPrefix(lexer.Ellipsis, p.parseSpreadElement)
 	p.registerPrefix(lexer.TemplateHead, p.parseTemplateLiteral)
 	p.registerPrefix(lexer.TemplateTail, p.parseTemplateLiteral)
@@ -42,25 +44,33 @@ func (p *Parser) registerInfixFns() {
 	p.registerInfix(lexer.Minus, p.parseInfixExpression)
 	p.registerInfix(lexer.Multiply, p.parseInfixExpression)
 	p.registerInfix(lexer.Divide, p.parseInfixExpression)
+	p.registerInfix(lexer.Modulo, p.parseInfixExpression)
+	p.registerInfix(lexer.Exponent, p.parseExponentExpression)
 	p.registerInfix(lexer.Assign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.PlusAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.MinusAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.MultiplyAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.DivideAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.ModuloAssign, p.parseAssignmentExpression)
+	p.registerInfix(lexer.ExponentAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.ShiftLeftAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.ShiftRightAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.UnsignedShiftAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.BitwiseAndAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.BitwiseOrAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.BitwiseXorAssign, p.parseAssignmentExpression)
+	p.registerInfix(lexer.LogicalAndAssign, p.parseAssignmentExpression)
+	p.registerInfix(lexer.LogicalOrAssign, p.parseAssignmentExpression)
+	p.registerInfix(lexer.NullishAssign, p.parseAssignmentExpression)
 	p.registerInfix(lexer.LParen, p.parseCallExpression)
 	p.registerInfix(lexer.Dot, p.parseMemberExpression)
+	p.registerInfix(lexer.OptionalChain, p.parseOptionalChain)
 	p.registerInfix(lexer.LBracket, p.parseComputedMemberExpression)
 	p.registerInfix(lexer.Increment, p.parsePostfixExpression)
 	p.registerInfix(lexer.Decrement, p.parsePostfixExpression)
 	p.registerInfix(lexer.LogicalAnd, p.parseLogicalExpression)
 	p.registerInfix(lexer.LogicalOr, p.parseLogicalExpression)
+	p.registerInfix(lexer.NullishCoalescing, p.parseLogicalExpression)
 	p.registerInfix(lexer.Equal, p.parseInfixExpression)
 	p.registerInfix(lexer.NotEqual, p.parseInfixExpression)
 	p.registerInfix(lexer.StrictEqual, p.parseInfixExpression)
@@ -93,6 +103,10 @@ func (p *Parser) registerInfix(tt lexer.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) parseExpression(pre precedence) ast.Expression {
+	if p.curTokenIs(lexer.EOF) {
+		p.unexpectedEOF(p.curToken.Start)
+		return nil
+	}
 	prefix := p.prefixFns[p.curToken.Type]
 	if prefix == nil {
 		p.noPrefixParseFnError(p.curToken.Type)
@@ -101,7 +115,9 @@ func (p *Parser) parseExpression(pre precedence) ast.Expression {
 
 	leftExp := prefix()
 
-	for !p.peekTokenIs(lexer.Semicolon) && pre < p.peekPrecedence() {
+	// A nil operand means an error was reported; operators after it are
+	// left for the caller's recovery to skip.
+	for leftExp != nil && !p.peekTokenIs(lexer.Semicolon) && pre < p.peekPrecedence() {
 		infix := p.infixFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -116,22 +132,30 @@ func (p *Parser) parseExpression(pre precedence) ast.Expression {
 
 func (p *Parser) parseIdentifier() ast.Expression {
 	tok := p.curToken
-	return ast.NewIdentifier(tok.Literal, p.tokenLocation(tok))
+	id := ast.NewIdentifier(tok.Literal, p.tokenLocation(tok))
+	p.checkIdentifier(id)
+	return id
 }
 
 func (p *Parser) parseNumberLiteral() ast.Expression {
 	tok := p.curToken
+	// Legacy octal literals such as 0755, and decimals with a leading zero
+	// such as 08, are sloppy mode only.
+	if p.strict && len(tok.Literal) > 1 && tok.Literal[0] == '0' && tok.Literal[1] >= '0' && tok.Literal[1] <= '9' {
+		p.syntaxError("octal literals are not allowed in strict mode")
+	}
 	return ast.NewNumberLiteral(tok.Literal, p.tokenLocation(tok))
 }
 
+func (p *Parser) parseBigIntLiteral() ast.Expression {
+	tok := p.curToken
+	digits := strings.ReplaceAll(strings.TrimSuffix(tok.Literal, "n"), "_", "")
+	return ast.NewBigIntLiteral(digits, p.tokenLocation(tok))
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	tok := p.curToken
-	val, err := strconv.Unquote(tok.Literal)
-	if err != nil {
-		p.errors = append(p.errors, err)
-		val = tok.Literal
-	}
-	return ast.NewStringLiteral(val, p.tokenLocation(tok))
+	return ast.NewStringLiteral(p.stringValue(tok), p.tokenLocation(tok))
 }
 
 func (p *Parser) parseBooleanLiteral() ast.Expression {
@@ -163,11 +187,14 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 			loc := p.locFrom(start, p.curToken.End)
 			return ast.NewSequenceExpression(nil, loc)
 		}
-		p.errors = append(p.errors, errors.New("empty grouping expression"))
+		p.syntaxError("empty grouping expression")
 		return nil
 	}
 
+	outerNoIn := p.noIn
+	p.noIn = false
 	exp := p.parseExpression(lowest)
+	p.noIn = outerNoIn
 	if exp == nil {
 		return nil
 	}
@@ -176,6 +203,7 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	}
 	loc := ast.Location{Start: convertPosition(start), End: convertPosition(p.curToken.End)}
 	p.setNodeLocation(exp, loc)
+	p.parenthesized[exp] = true
 	return exp
 }
 
@@ -193,10 +221,17 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	switch tok.Type {
 	case lexer.Increment, lexer.Decrement:
 		if !isAssignable(right) {
-			p.errors = append(p.errors, errors.New("invalid update target"))
+			p.syntaxError("invalid update target")
 			return nil
 		}
+		p.checkAssignmentTarget(right)
 		return ast.NewUpdateExpression(operator, right, true, loc)
+	case lexer.KeywordDelete:
+		if _, ok := right.(*ast.Identifier); ok && p.strict {
+			p.syntaxError("delete of an unqualified identifier in strict mode")
+			return nil
+		}
+		return ast.NewUnaryExpression(operator, right, true, loc)
 	default:
 		return ast.NewUnaryExpression(operator, right, true, loc)
 	}
@@ -205,9 +240,10 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
 	operator := p.curToken.Literal
 	if !isAssignable(left) {
-		p.errors = append(p.errors, errors.New("invalid update target"))
+		p.syntaxError("invalid update target")
 		return nil
 	}
+	p.checkAssignmentTarget(left)
 	loc := ast.Location{Start: left.Loc().Start, End: convertPosition(p.curToken.End)}
 	return ast.NewUpdateExpression(operator, left, false, loc)
 }
@@ -226,6 +262,25 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return ast.NewBinaryExpression(operator, left, right, loc)
 }
 
+// parseExponentExpression parses the right-associative ** operator. A unary
+// operand on the left is ambiguous, as in -2 ** 2, and must be parenthesized.
+func (p *Parser) parseExponentExpression(left ast.Expression) ast.Expression {
+	if _, ok := left.(*ast.UnaryExpression); ok && !p.parenthesized[left] {
+		p.syntaxError("unary operator used immediately before exponentiation expression; parentheses are required")
+		return nil
+	}
+	operator := p.curToken.Literal
+
+	p.nextToken()
+	right := p.parseExpression(exponentPrec - 1)
+	if right == nil {
+		return nil
+	}
+
+	loc := ast.Location{Start: left.Loc().Start, End: right.Loc().End}
+	return ast.NewBinaryExpression(operator, left, right, loc)
+}
+
 func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
 	operator := p.curToken.Literal
 	precedence := p.curPrecedence()
@@ -236,15 +291,31 @@ func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
 		return nil
 	}
 
+	if p.mixesNullish(operator, left) || p.mixesNullish(operator, right) {
+		p.syntaxError("cannot mix ?? with && or || without parentheses")
+		return nil
+	}
+
 	loc := ast.Location{Start: left.Loc().Start, End: right.Loc().End}
 	return ast.NewLogicalExpression(operator, left, right, loc)
 }
 
+// mixesNullish reports whether operand is an unparenthesized logical expression
+// whose operator may not be combined with operator.
+func (p *Parser) mixesNullish(operator string, operand ast.Expression) bool {
+	inner, ok := operand.(*ast.LogicalExpression)
+	if !ok || p.parenthesized[operand] {
+		return false
+	}
+	return (operator == "??") != (inner.Operator == "??")
+}
+
 func (p *Parser) parseAssignmentExpression(left ast.Expression) ast.Expression {
 	if !isAssignable(left) {
-		p.errors = append(p.errors, errors.New("invalid assignment target"))
+		p.syntaxError("invalid assignment target")
 		return nil
 	}
+	p.checkAssignmentTarget(left)
 
 	operator := p.curToken.Literal
 	precedence := p.curPrecedence()
@@ -273,7 +344,7 @@ func (p *Parser) parseNewExpression() ast.Expression {
 		}
 		identTok := p.curToken
 		if identTok.Literal != "target" {
-			p.errors = append(p.errors, errors.New("expected target after new"))
+			p.syntaxError("expected target after new")
 			return nil
 		}
 		meta := ast.NewIdentifier("new", p.locFrom(newTok.Start, newTok.End))
@@ -282,20 +353,66 @@ func (p *Parser) parseNewExpression() ast.Expression {
 		return ast.NewMetaProperty(meta, property, loc)
 	}
 
-	expr := p.parseExpression(postfixPrec)
-	if expr == nil {
+	// The callee is a member expression: a primary expression, a nested new
+	// expression or a parenthesized expression, followed by any number of
+	// property accesses. The first argument list belongs to new; anything
+	// after it applies to the constructed object.
+	var callee ast.Expression
+	if p.curTokenIs(lexer.KeywordNew) {
+		callee = p.parseNewExpression()
+	} else if prefix := p.prefixFns[p.curToken.Type]; prefix != nil {
+		callee = prefix()
+	} else if p.curTokenIs(lexer.EOF) {
+		p.unexpectedEOF(p.curToken.Start)
+	} else {
+		p.noPrefixParseFnError(p.curToken.Type)
+	}
+	for callee != nil {
+		switch p.peekToken.Type {
+		case lexer.Dot:
+			p.nextToken()
+			callee = p.parseMemberExpression(callee)
+			continue
+		case lexer.LBracket:
+			p.nextToken()
+			callee = p.parseComputedMemberExpression(callee)
+			continue
+		case lexer.TemplateHead, lexer.TemplateTail:
+			p.nextToken()
+			callee = p.parseTaggedTemplateExpression(callee)
+			continue
+		case lexer.OptionalChain:
+			p.syntaxErrorAt(convertPosition(p.peekToken.Start), "invalid optional chain from new expression")
+			return nil
+		}
+		break
+	}
+	if callee == nil {
 		return nil
 	}
 
-	return p.wrapNewExpression(expr, start)
+	var args []ast.Expression
+	if p.peekTokenIs(lexer.LParen) {
+		p.nextToken()
+		var ok bool
+		if args, ok = p.parseArguments(); !ok {
+			return nil
+		}
+	}
+	return ast.NewNewExpression(callee, args, p.locFrom(start, p.curToken.End))
 }
 
 func (p *Parser) parseConditionalExpression(test ast.Expression) ast.Expression {
 	start := test.Loc().Start
 
-	// parse consequent expression after '?'
+	// Both branches are assignment expressions, which makes ?: right
+	// associative: a ? b : c ? d : e nests in the alternate. The in operator
+	// is allowed in the consequent even in the head of a for statement.
 	p.nextToken()
-	consequent := p.parseExpression(lowest)
+	noIn := p.noIn
+	p.noIn = false
+	consequent := p.parseExpression(sequencePrec)
+	p.noIn = noIn
 	if consequent == nil {
 		return nil
 	}
@@ -305,7 +422,7 @@ func (p *Parser) parseConditionalExpression(test ast.Expression) ast.Expression
 	}
 
 	p.nextToken()
-	alternate := p.parseExpression(conditionalPrec - 1)
+	alternate := p.parseExpression(sequencePrec)
 	if alternate == nil {
 		return nil
 	}
@@ -315,44 +432,68 @@ func (p *Parser) parseConditionalExpression(test ast.Expression) ast.Expression
 }
 
 func (p *Parser) parseCallExpression(callee ast.Expression) ast.Expression {
+	callee, chained := p.chainBase(callee)
 	start := callee.Loc().Start
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	end := convertPosition(p.curToken.End)
+	loc := ast.Location{Start: start, End: end}
+	return wrapChain(ast.NewCallExpression(callee, args, loc), chained)
+}
+
+// parseArguments parses an argument list from the opening parenthesis, which
+// is the current token, through the closing one. Arguments may be spread with
+// ... and the list may end in a trailing comma.
+func (p *Parser) parseArguments() ([]ast.Expression, bool) {
 	p.nextToken()
 	var args []ast.Expression
-	if !p.curTokenIs(lexer.RParen) {
-		for {
-			arg := p.parseExpression(sequencePrec)
-			if arg == nil {
-				return nil
+	for !p.curTokenIs(lexer.RParen) {
+		var arg ast.Expression
+		if p.curTokenIs(lexer.Ellipsis) {
+			spreadStart := p.curToken.Start
+			p.nextToken()
+			argument := p.parseExpression(sequencePrec)
+			if argument == nil {
+				return nil, false
 			}
-			args = append(args, arg)
+			arg = ast.NewSpreadElement(argument, p.locFrom(spreadStart, p.curToken.End))
+		} else if arg = p.parseExpression(sequencePrec); arg == nil {
+			return nil, false
+		}
+		args = append(args, arg)
 
-			if !p.peekTokenIs(lexer.Comma) {
-				break
-			}
+		if p.peekTokenIs(lexer.Comma) {
 			p.nextToken() // move to comma
-			p.nextToken() // move to next argument
+			p.nextToken() // move to next argument or )
+			continue
 		}
 		if !p.expectPeek(lexer.RParen) {
-			p.errors = append(p.errors, errors.New("unterminated call expression"))
-			return nil
+			p.unterminated("call expression")
+			return nil, false
 		}
 	}
-	end := convertPosition(p.curToken.End)
-	loc := ast.Location{Start: start, End: end}
-	return ast.NewCallExpression(callee, args, loc)
+	return args, true
 }
 
 func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	object, chained := p.chainBase(object)
 	start := object.Loc().Start
-	if !p.expectPeek(lexer.Identifier) {
+	// Any identifier name may follow the dot, reserved words included, as
+	// in gen.return().
+	if isReservedWord(p.peekToken) {
+		p.nextToken()
+	} else if !p.expectPeek(lexer.Identifier) {
 		return nil
 	}
 	property := ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
 	loc := ast.Location{Start: start, End: property.Loc().End}
-	return ast.NewMemberExpression(object, property, false, loc)
+	return wrapChain(ast.NewMemberExpression(object, property, false, loc), chained)
 }
 
 func (p *Parser) parseComputedMemberExpression(object ast.Expression) ast.Expression {
+	object, chained := p.chainBase(object)
 	start := object.Loc().Start
 	p.nextToken()
 	property := p.parseExpression(lowest)
@@ -360,11 +501,58 @@ func (p *Parser) parseComputedMemberExpression(object ast.Expression) ast.Expres
 		return nil
 	}
 	if !p.expectPeek(lexer.RBracket) {
-		p.errors = append(p.errors, errors.New("unterminated computed member expression"))
+		p.unterminated("computed member expression")
 		return nil
 	}
 	loc := ast.Location{Start: start, End: convertPosition(p.curToken.End)}
-	return ast.NewMemberExpression(object, property, true, loc)
+	return wrapChain(ast.NewMemberExpression(object, property, true, loc), chained)
+}
+
+// parseOptionalChain parses the link following ?. and wraps the result in a
+// ChainExpression that later member accesses and calls extend.
+func (p *Parser) parseOptionalChain(left ast.Expression) ast.Expression {
+	var link ast.Expression
+	switch {
+	case p.peekTokenIs(lexer.LParen):
+		p.nextToken()
+		link = p.parseCallExpression(left)
+	case p.peekTokenIs(lexer.LBracket):
+		p.nextToken()
+		link = p.parseComputedMemberExpression(left)
+	default:
+		link = p.parseMemberExpression(left)
+	}
+	if link == nil {
+		return nil
+	}
+
+	if chain, ok := link.(*ast.ChainExpression); ok {
+		link = chain.Expression
+	}
+	switch n := link.(type) {
+	case *ast.CallExpression:
+		n.Optional = true
+	case *ast.MemberExpression:
+		n.Optional = true
+	}
+	return ast.NewChainExpression(link, link.Loc())
+}
+
+// chainBase unwraps an optional chain so that a following member access or
+// call extends it. Parenthesized chains are left intact, since parentheses
+// end the short-circuit.
+func (p *Parser) chainBase(expr ast.Expression) (ast.Expression, bool) {
+	if chain, ok := expr.(*ast.ChainExpression); ok && !p.parenthesized[expr] {
+		return chain.Expression, true
+	}
+	return expr, false
+}
+
+func wrapChain(expr ast.Expression, chained bool) ast.Expression {
+	if !chained {
+		return expr
+	}
+	return ast.NewChainExpression(expr, expr.Loc())
 }
 
 func (p *Parser) parseSequenceExpression(left ast.Expression) ast.Expression {
@@ -436,42 +624,49 @@ func (p *Parser) parseArrowFunctionExpression(left ast.Expression) ast.Expressio
 	var (
 		bodyNode       ast.Node
 		expressionBody = true
+		pro            = prologue{strict: p.strict}
 	)
 
 	if p.curTokenIs(lexer.LBrace) {
-		bodyStmt := p.parseBlockStatement()
+		var bodyStmt ast.Statement
+		bodyStmt, pro = p.parseFunctionBody(params, false, true)
 		if bodyStmt == nil {
 			return nil
 		}
 		block, ok := bodyStmt.(*ast.BlockStatement)
 		if !ok {
-			p.errors = append(p.errors, errors.New("arrow function body must be block statement"))
+			p.syntaxError("arrow function body must be block statement")
 			return nil
 		}
 		bodyNode = block
 		expressionBody = false
 	} else {
+		// Arrow functions are never generators, even inside one.
+		outerGenerator := p.generator
+		p.generator = false
 		bodyExpr := p.parseExpression(assignmentPrec - 1)
+		p.generator = outerGenerator
 		if bodyExpr == nil {
 			return nil
 		}
 		bodyNode = bodyExpr
+		p.checkParams(params, true, false)
 	}
 
 	loc := ast.Location{Start: left.Loc().Start, End: bodyNode.Loc().End}
-	return ast.NewArrowFunctionExpression(params, bodyNode, expressionBody, loc)
+	arrow := ast.NewArrowFunctionExpression(params, bodyNode, expressionBody, loc)
+	arrow.Directives, arrow.Strict = pro.directives, pro.strict
+	return arrow
 }
 
 func (p *Parser) convertArrowParams(node ast.Expression) ([]ast.Pattern, bool) {
 	switch n := node.(type) {
 	case *ast.SequenceExpression:
 		return p.sequenceExpressionsToPatterns(n)
-	case *ast.Identifier:
-		return []ast.Pattern{n}, true
 	default:
 		pat, ok := p.expressionToPattern(n)
 		if !ok {
-			p.errors = append(p.errors, errors.New("invalid arrow function parameters"))
+			p.syntaxError("invalid arrow function parameters")
 			return nil, false
 		}
 		return []ast.Pattern{pat}, true
@@ -486,7 +681,7 @@ func (p *Parser) sequenceExpressionsToPatterns(seq *ast.SequenceExpression) ([]a
 	for i, expr := range seq.Expressions {
 		if spread, ok := expr.(*ast.SpreadElement); ok {
 			if i != len(seq.Expressions)-1 {
-				p.errors = append(p.errors, errors.New("rest parameter must be last"))
+				p.syntaxError("rest parameter must be last")
 				return nil, false
 			}
 			pat, ok := p.expressionToPattern(spread.Argument)
@@ -509,6 +704,9 @@ func (p *Parser) sequenceExpressionsToPatterns(seq *ast.SequenceExpression) ([]a
 func (p *Parser) expressionToPattern(expr ast.Expression) (ast.Pattern, bool) {
 	switch e := expr.(type) {
 	case *ast.Identifier:
+		// The name was parsed as a reference, so reserved words have been
+		// checked but eval and arguments have not.
+		p.checkStrictBinding(e)
 		return e, true
 	case *ast.ArrayLiteral:
 		return p.arrayLiteralToPattern(e)
@@ -516,7 +714,7 @@ func (p *Parser) expressionToPattern(expr ast.Expression) (ast.Pattern, bool) {
 		return p.objectLiteralToPattern(e)
 	case *ast.AssignmentExpression:
 		if e.Operator != "=" {
-			p.errors = append(p.errors, errors.New("invalid assignment in parameter"))
+			p.syntaxError("invalid assignment in parameter")
 			return nil, false
 		}
 		left, ok := p.expressionToPattern(e.Left)
@@ -526,7 +724,7 @@ func (p *Parser) expressionToPattern(expr ast.Expression) (ast.Pattern, bool) {
 		loc := e.Loc()
 		return ast.NewAssignmentPattern(left, e.Right, loc), true
 	default:
-		p.errors = append(p.errors, errors.New("invalid parameter pattern"))
+		p.syntaxError("invalid parameter pattern")
 		return nil, false
 	}
 }
@@ -544,7 +742,7 @@ func (p *Parser) arrayLiteralToPattern(arr *ast.ArrayLiteral) (ast.Pattern, bool
 		}
 		if spread, ok := elem.(*ast.SpreadElement); ok {
 			if rest != nil || i != len(arr.Elements)-1 {
-				p.errors = append(p.errors, errors.New("rest element must be last in array pattern"))
+				p.syntaxError("rest element must be last in array pattern")
 				return nil, false
 			}
 			arg, ok := p.expressionToPattern(spread.Argument)
@@ -574,7 +772,7 @@ func (p *Parser) objectLiteralToPattern(obj *ast.ObjectLiteral) (ast.Pattern, bo
 		switch pr := prop.(type) {
 		case *ast.ObjectProperty:
 			if pr.PropKind != ast.PropertyInit || pr.Method {
-				p.errors = append(p.errors, errors.New("invalid object pattern property"))
+				p.syntaxError("invalid object pattern property")
 				return nil, false
 			}
 			value, ok := p.expressionToPattern(pr.Value)
@@ -584,7 +782,7 @@ func (p *Parser) objectLiteralToPattern(obj *ast.ObjectLiteral) (ast.Pattern, bo
 			props = append(props, ast.NewObjectPatternProperty(pr.Key, value, pr.Computed, pr.Shorthand, pr.Loc()))
 		case *ast.SpreadElement:
 			if rest != nil || i != len(obj.Properties)-1 {
-				p.errors = append(p.errors, errors.New("rest element must be last in object pattern"))
+				p.syntaxError("rest element must be last in object pattern")
 				return nil, false
 			}
 			arg, ok := p.expressionToPattern(pr.Argument)
@@ -593,7 +791,7 @@ func (p *Parser) objectLiteralToPattern(obj *ast.ObjectLiteral) (ast.Pattern, bo
 			}
 			rest = ast.NewRestElement(arg, pr.Loc())
 		default:
-			p.errors = append(p.errors, errors.New("unsupported object literal property in pattern"))
+			p.syntaxError("unsupported object literal property in pattern")
 			return nil, false
 		}
 	}
@@ -631,7 +829,7 @@ func (p *Parser) readTemplateLiteral(start lexer.Position) (*ast.TemplateLiteral
 		}
 
 		if !(p.peekTokenIs(lexer.TemplateMiddle) || p.peekTokenIs(lexer.TemplateTail)) {
-			p.errors = append(p.errors, errors.New("expected template continuation"))
+			p.syntaxError("expected template continuation")
 			return nil, false
 		}
 
@@ -676,12 +874,9 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 			elements = append(elements, element)
 
 			if p.peekTokenIs(lexer.Comma) {
+				// A single trailing comma before ] ends the list without
+				// adding a hole.
 				p.nextToken()
-				if p.peekTokenIs(lexer.RBracket) {
-					elements = append(elements, nil)
-					p.nextToken()
-					break
-				}
 				p.nextToken()
 				continue
 			}
@@ -691,7 +886,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	}
 
 	if !p.curTokenIs(lexer.RBracket) {
-		p.errors = append(p.errors, errors.New("unterminated array literal"))
+		p.unterminated("array literal")
 		return nil
 	}
 
@@ -739,7 +934,7 @@ func (p *Parser) parseObjectLiteral() ast.Expression {
 	}
 
 	if !p.curTokenIs(lexer.RBrace) {
-		p.errors = append(p.errors, errors.New("unterminated object literal"))
+		p.unterminated("object literal")
 		return nil
 	}
 
@@ -753,47 +948,50 @@ func (p *Parser) parseObjectProperty() ast.Property {
 	if p.curTokenIs(lexer.Ellipsis) {
 		spreadStart := p.curToken.Start
 		p.nextToken()
-		arg := p.parseExpression(lowest)
+		arg := p.parseExpression(sequencePrec)
 		if arg == nil {
 			return nil
 		}
 		return ast.NewSpreadElement(arg, p.locFrom(spreadStart, p.curToken.End))
 	}
 
-	computed := false
-	var key ast.Expression
-
-	switch p.curToken.Type {
-	case lexer.Identifier:
-		key = ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
-	case lexer.String:
-		val, err := strconv.Unquote(p.curToken.Literal)
-		if err != nil {
-			p.errors = append(p.errors, err)
-			val = p.curToken.Literal
-		}
-		key = ast.NewStringLiteral(val, p.tokenLocation(p.curToken))
-	case lexer.Number:
-		key = ast.NewNumberLiteral(p.curToken.Literal, p.tokenLocation(p.curToken))
-	case lexer.LBracket:
-		computed = true
+	kind := ast.PropertyInit
+	generator := false
+	if p.curTokenIs(lexer.Multiply) {
+		generator = true
 		p.nextToken()
-		expr := p.parseExpression(lowest)
-		if expr == nil {
-			return nil
+	} else if p.curTokenIs(lexer.Identifier) && (p.curToken.Literal == "get" || p.curToken.Literal == "set") {
+		// get and set only introduce an accessor when a property name follows.
+		if !p.peekTokenIs(lexer.Colon) && !p.peekTokenIs(lexer.Comma) && !p.peekTokenIs(lexer.RBrace) && !p.peekTokenIs(lexer.LParen) {
+			kind = ast.PropertyGet
+			if p.curToken.Literal == "set" {
+				kind = ast.PropertySet
+			}
+			p.nextToken()
 		}
-		key = expr
-		if !p.expectPeek(lexer.RBracket) {
+	}
+
+	keyTok := p.curToken
+	key, computed := p.parsePropertyName("object literal property")
+	if key == nil {
+		return nil
+	}
+
+	if kind != ast.PropertyInit || p.peekTokenIs(lexer.LParen) || generator {
+		method := kind == ast.PropertyInit
+		if method {
+			kind = ast.PropertyMethod
+		}
+		value := p.parseMethodFunction(kind, generator)
+		if value == nil {
 			return nil
 		}
-	default:
-		msg := "unexpected token " + string(p.curToken.Type) + " in object literal property"
-		p.errors = append(p.errors, errors.New(msg))
-		return nil
+		loc := p.locFrom(start, p.curToken.End)
+		return ast.NewObjectProperty(key, value, kind, computed, false, method, loc)
 	}
 
 	// shorthand property for identifiers only
-	if !computed {
+	if keyTok.Type == lexer.Identifier {
 		if ident, ok := key.(*ast.Identifier); ok {
 			if p.peekTokenIs(lexer.Comma) || p.peekTokenIs(lexer.RBrace) {
 				loc := p.locFrom(start, p.curToken.End)
@@ -816,69 +1014,155 @@ func (p *Parser) parseObjectProperty() ast.Property {
 	return ast.NewObjectProperty(key, value, ast.PropertyInit, computed, false, false, loc)
 }
 
-func (p *Parser) wrapNewExpression(expr ast.Expression, start lexer.Position) ast.Expression {
-	newStart := convertPosition(start)
-	switch e := expr.(type) {
-	case *ast.CallExpression:
-		switch callee := e.Callee.(type) {
-		case *ast.CallExpression:
-			wrapped := p.wrapNewExpression(callee, start)
-			if wrapped != e.Callee {
-				e.Callee = wrapped
-				p.extendNodeStart(e, newStart)
-				return e
-			}
-		case *ast.MemberExpression:
-			if containsCallExpression(callee.Object) {
-				original := callee.Object
-				p.wrapNewExpression(callee, start)
-				if original != callee.Object {
-					p.extendNodeStart(callee, newStart)
-					p.extendNodeStart(e, newStart)
-					return e
-				}
-			}
+// parsePropertyName parses the name of an object literal property or class
+// member: an identifier name (reserved words included), a string or number
+// literal, or a bracketed computed key. context names the construct in
+// error messages.
+func (p *Parser) parsePropertyName(context string) (ast.Expression, bool) {
+	tok := p.curToken
+	switch {
+	case tok.Type == lexer.String:
+		return ast.NewStringLiteral(p.stringValue(tok), p.tokenLocation(tok)), false
+	case tok.Type == lexer.Number:
+		return ast.NewNumberLiteral(tok.Literal, p.tokenLocation(tok)), false
+	case tok.Type == lexer.LBracket:
+		p.nextToken()
+		outerNoIn := p.noIn
+		p.noIn = false
+		expr := p.parseExpression(sequencePrec)
+		p.noIn = outerNoIn
+		if expr == nil || !p.expectPeek(lexer.RBracket) {
+			return nil, true
 		}
-		loc := ast.Location{Start: newStart, End: e.Loc().End}
-		return ast.NewNewExpression(e.Callee, e.Arguments, loc)
-	case *ast.MemberExpression:
-		wrapped := p.wrapNewExpression(e.Object, start)
-		if wrapped != e.Object {
-			e.Object = wrapped
-			p.extendNodeStart(e, newStart)
-			return e
-		}
-		loc := ast.Location{Start: newStart, End: e.Loc().End}
-		return ast.NewNewExpression(e, nil, loc)
-	case *ast.NewExpression:
-		p.extendNodeStart(e, newStart)
-		return e
+		return expr, true
+	case tok.Type == lexer.Identifier || lexer.LookupIdentifier(tok.Literal) == tok.Type:
+		return ast.NewIdentifier(tok.Literal, p.tokenLocation(tok)), false
 	default:
-		loc := ast.Location{Start: newStart, End: expr.Loc().End}
-		return ast.NewNewExpression(expr, nil, loc)
+		msg := "unexpected token " + string(tok.Type) + " in " + context
+		p.syntaxError(msg)
+		return nil, false
 	}
 }
 
-func containsCallExpression(expr ast.Expression) bool {
-	switch e := expr.(type) {
-	case *ast.CallExpression:
-		return true
-	case *ast.MemberExpression:
-		return containsCallExpression(e.Object)
-	default:
+// parseFunctionExpression parses a function in expression position. The
+// optional name is visible only inside the function itself, so it is not
+// declared in the enclosing scope.
+func (p *Parser) parseFunctionExpression() ast.Expression {
+	start := p.curToken.Start
+
+	isGenerator := false
+	if p.peekTokenIs(lexer.Multiply) {
+		p.nextToken()
+		isGenerator = true
+	}
+
+	var id *ast.Identifier
+	if p.peekTokenIs(lexer.Identifier) {
+		p.nextToken()
+		id = ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+		p.checkBindingIdentifier(id)
+	}
+
+	if !p.expectPeek(lexer.LParen) {
+		return nil
+	}
+
+	params, ok := p.parseFunctionParams()
+	if !ok {
+		return nil
+	}
+
+	if !p.expectPeek(lexer.LBrace) {
+		return nil
+	}
+
+	bodyStmt, pro := p.parseFunctionBody(params, isGenerator, false)
+	body, ok := bodyStmt.(*ast.BlockStatement)
+	if !ok {
+		return nil
+	}
+
+	fn := ast.NewFunctionExpression(id, params, body, isGenerator, p.locFrom(start, p.curToken.End))
+	fn.Directives, fn.Strict = pro.directives, pro.strict
+	return fn
+}
+
+// isReservedWord reports whether tok is a keyword or literal token spelled
+// like an identifier, such as return or null.
+func isReservedWord(tok lexer.Token) bool {
+	return tok.Type != lexer.Identifier && lexer.LookupIdentifier(tok.Literal) == tok.Type
+}
+
+// parseYieldExpression parses yield, yield expr and yield* expr. The operand
+// is optional, so yield followed by a line break or a token that cannot
+// start an expression stands alone.
+func (p *Parser) parseYieldExpression() ast.Expression {
+	start := p.curToken.Start
+	if !p.generator {
+		p.syntaxError("yield is only valid in generator functions")
+		return nil
+	}
+
+	delegate := false
+	if p.peekTokenIs(lexer.Multiply) && !p.peekToken.NewlineBefore {
+		p.nextToken()
+		delegate = true
+	} else if p.peekToken.NewlineBefore || !p.yieldOperandFollows() {
+		return ast.NewYieldExpression(nil, false, p.locFrom(start, p.curToken.End))
+	}
+
+	p.nextToken()
+	arg := p.parseExpression(sequencePrec)
+	if arg == nil {
+		return nil
+	}
+	return ast.NewYieldExpression(arg, delegate, p.locFrom(start, p.curToken.End))
+}
+
+// yieldOperandFollows reports whether the token after yield can begin its
+// operand rather than ending the yield expression.
+func (p *Parser) yieldOperandFollows() bool {
+	switch p.peekToken.Type {
+	case lexer.RParen, lexer.RBracket, lexer.RBrace, lexer.Comma, lexer.Semicolon, lexer.Colon, lexer.EOF:
 		return false
 	}
+	return true
 }
 
-func (p *Parser) extendNodeStart(node ast.Node, start ast.Position) {
-	if start.Offset < 0 {
-		return
+// parseMethodFunction parses the parameter list and body of a method or
+// accessor, starting at the token before the opening parenthesis.
+func (p *Parser) parseMethodFunction(kind ast.PropertyKind, generator bool) *ast.FunctionExpression {
+	if !p.expectPeek(lexer.LParen) {
+		return nil
 	}
-	loc := node.Loc()
-	if loc.Start.Offset > start.Offset {
-		loc.Start = start
-		p.setNodeLocation(node, loc)
+	start := p.curToken.Start
+	params, ok := p.parseFunctionParams()
+	if !ok {
+		return nil
+	}
+	switch {
+	case kind == ast.PropertyGet && len(params) != 0:
+		p.syntaxError("getter must not have any formal parameters")
+	case kind == ast.PropertySet && (len(params) != 1 || isRestElement(params[0])):
+		p.syntaxError("setter must have exactly one formal parameter")
+	}
+
+	if !p.expectPeek(lexer.LBrace) {
+		return nil
 	}
+	bodyStmt, pro := p.parseFunctionBody(params, generator, true)
+	body, ok := bodyStmt.(*ast.BlockStatement)
+	if !ok {
+		return nil
+	}
+	fn := ast.NewFunctionExpression(nil, params, body, generator, p.locFrom(start, p.curToken.End))
+	fn.Directives, fn.Strict = pro.directives, pro.strict
+	return fn
+}
+
+func isRestElement(param ast.Pattern) bool {
+	_, ok := param.(*ast.RestElement)
+	return ok
 }
 
 func (p *Parser) parseRegExpLiteral() ast.Expression {
@@ -900,7 +1184,7 @@ func (p *Parser) parseRegExpLiteral() ast.Expression {
 
 func (p *Parser) noPrefixParseFnError(tt lexer.TokenType) {
 	msg := "no prefix parse function for " + string(tt)
-	p.errors = append(p.errors, errors.New(msg))
+	p.syntaxError(msg)
 }
 
 func (p *Parser) setNodeLocation(node ast.Node, loc ast.Location) {
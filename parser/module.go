@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"fmt"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/lexer"
+)
+
+// parseModuleItem parses an import or export declaration at the top level of
+// a module.
+func (p *Parser) parseModuleItem() ast.Statement {
+	if p.curTokenIs(lexer.KeywordImport) {
+		return p.parseImportDeclaration()
+	}
+	return p.parseExportDeclaration()
+}
+
+// skipModuleItem parses and discards an import or export declaration found
+// in script code, so that its braces and from clause do not produce further
+// errors. The names it binds or exports go to a scope and export tables of
+// their own, leaving those of the script untouched.
+func (p *Parser) skipModuleItem() {
+	exports, localExports := p.exports, p.localExports
+	p.exports, p.localExports = nil, nil
+	p.pushScope(true)
+	p.parseModuleItem()
+	p.popScope()
+	p.exports, p.localExports = exports, localExports
+}
+
+func (p *Parser) parseImportDeclaration() ast.Statement {
+	start := p.curToken.Start
+
+	// import "m" evaluates the module without binding anything.
+	if p.peekTokenIs(lexer.String) {
+		p.nextToken()
+		source := p.parseStringLiteral().(*ast.StringLiteral)
+		return p.finishModuleDeclaration(start, func(end lexer.Position) ast.Statement {
+			return ast.NewImportDeclaration(nil, source, p.locFrom(start, end))
+		})
+	}
+
+	var specifiers []ast.ImportClause
+	p.nextToken()
+	more := true
+	if p.curTokenIs(lexer.Identifier) {
+		local := p.importBinding()
+		specifiers = append(specifiers, ast.NewImportDefaultSpecifier(local, local.Loc()))
+		more = p.peekTokenIs(lexer.Comma)
+		if more {
+			p.nextToken()
+			p.nextToken()
+		} else if !p.expectFrom() {
+			return nil
+		}
+	}
+	if more {
+		switch p.curToken.Type {
+		case lexer.Multiply:
+			nsStart := p.curToken.Start
+			if !p.expectPeek(lexer.Identifier) || p.curToken.Literal != "as" {
+				p.syntaxError("expected 'as' after * in import declaration")
+				return nil
+			}
+			if !p.expectPeek(lexer.Identifier) {
+				return nil
+			}
+			local := p.importBinding()
+			specifiers = append(specifiers, ast.NewImportNamespaceSpecifier(local, p.locFrom(nsStart, p.curToken.End)))
+		case lexer.LBrace:
+			named, ok := p.parseImportSpecifiers()
+			if !ok {
+				return nil
+			}
+			specifiers = append(specifiers, named...)
+		default:
+			p.syntaxError(fmt.Sprintf("unexpected token %q in import declaration", p.curToken.Literal))
+			return nil
+		}
+		if !p.expectFrom() {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(lexer.String) {
+		return nil
+	}
+	source := p.parseStringLiteral().(*ast.StringLiteral)
+	return p.finishModuleDeclaration(start, func(end lexer.Position) ast.Statement {
+		return ast.NewImportDeclaration(specifiers, source, p.locFrom(start, end))
+	})
+}
+
+// parseImportSpecifiers parses { a, b as c }, starting at the opening brace.
+func (p *Parser) parseImportSpecifiers() ([]ast.ImportClause, bool) {
+	var specifiers []ast.ImportClause
+	for {
+		p.nextToken()
+		if p.curTokenIs(lexer.RBrace) {
+			return specifiers, true
+		}
+		imported, ok := p.moduleExportName()
+		if !ok {
+			return nil, false
+		}
+		local := imported
+		if p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "as" {
+			p.nextToken()
+			if !p.expectPeek(lexer.Identifier) {
+				return nil, false
+			}
+			local = p.importBinding()
+		} else if !p.curTokenIs(lexer.Identifier) {
+			p.syntaxError(fmt.Sprintf("'%s' must be renamed with as to be imported", imported.Name))
+			return nil, false
+		} else {
+			p.declareLexical(local)
+		}
+		specifiers = append(specifiers, ast.NewImportSpecifier(imported, local, ast.Location{Start: imported.Loc().Start, End: local.Loc().End}))
+		if p.peekTokenIs(lexer.Comma) {
+			p.nextToken()
+			continue
+		}
+		if !p.expectPeek(lexer.RBrace) {
+			return nil, false
+		}
+		return specifiers, true
+	}
+}
+
+// importBinding creates the local binding of an import specifier from the
+// current identifier token. Imports are immutable, like const bindings.
+func (p *Parser) importBinding() *ast.Identifier {
+	local := ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+	p.checkBindingIdentifier(local)
+	p.declareLexical(local)
+	return local
+}
+
+func (p *Parser) parseExportDeclaration() ast.Statement {
+	start := p.curToken.Start
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case lexer.KeywordDefault:
+		return p.parseExportDefault(start)
+	case lexer.Multiply:
+		var exported *ast.Identifier
+		if p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "as" {
+			p.nextToken()
+			p.nextToken()
+			name, ok := p.moduleExportName()
+			if !ok {
+				return nil
+			}
+			exported = name
+			p.declareExport(exported)
+		}
+		if !p.expectFrom() || !p.expectPeek(lexer.String) {
+			return nil
+		}
+		source := p.parseStringLiteral().(*ast.StringLiteral)
+		return p.finishModuleDeclaration(start, func(end lexer.Position) ast.Statement {
+			return ast.NewExportAllDeclaration(exported, source, p.locFrom(start, end))
+		})
+	case lexer.LBrace:
+		specifiers, ok := p.parseExportSpecifiers()
+		if !ok {
+			return nil
+		}
+		var source *ast.StringLiteral
+		if p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "from" {
+			p.nextToken()
+			if !p.expectPeek(lexer.String) {
+				return nil
+			}
+			source = p.parseStringLiteral().(*ast.StringLiteral)
+		}
+		for _, spec := range specifiers {
+			p.declareExport(spec.Exported)
+			if source == nil {
+				p.localExports = append(p.localExports, spec.Local)
+			}
+		}
+		return p.finishModuleDeclaration(start, func(end lexer.Position) ast.Statement {
+			return ast.NewExportNamedDeclaration(nil, specifiers, source, p.locFrom(start, end))
+		})
+	case lexer.KeywordVar, lexer.KeywordLet, lexer.KeywordConst, lexer.KeywordFunction, lexer.KeywordClass:
+		stmt := p.parseStatement()
+		decl, ok := stmt.(ast.Declaration)
+		if !ok {
+			return nil
+		}
+		for _, id := range declaredIdentifiers(decl) {
+			p.declareExport(id)
+		}
+		return ast.NewExportNamedDeclaration(decl, nil, nil, ast.Location{Start: convertPosition(start), End: decl.Loc().End})
+	default:
+		p.syntaxError(fmt.Sprintf("unexpected token %q after export", p.curToken.Literal))
+		return nil
+	}
+}
+
+// parseExportDefault parses the rest of export default, starting at the
+// default keyword.
+func (p *Parser) parseExportDefault(start lexer.Position) ast.Statement {
+	p.declareExport(ast.NewIdentifier("default", p.tokenLocation(p.curToken)))
+	p.nextToken()
+
+	// Named function and class declarations bind their name as well as
+	// providing the default export; anonymous ones are expressions.
+	named := false
+	switch {
+	case p.curTokenIs(lexer.KeywordFunction):
+		named = p.peekTokenIs(lexer.Identifier) || p.peekTokenIs(lexer.Multiply)
+	case p.curTokenIs(lexer.KeywordClass):
+		named = p.peekTokenIs(lexer.Identifier)
+	}
+	if named {
+		stmt := p.parseStatement()
+		if stmt == nil {
+			return nil
+		}
+		return ast.NewExportDefaultDeclaration(stmt, ast.Location{Start: convertPosition(start), End: stmt.Loc().End})
+	}
+
+	declarationLike := p.curTokenIs(lexer.KeywordFunction) || p.curTokenIs(lexer.KeywordClass)
+	expr := p.parseExpression(sequencePrec)
+	if expr == nil {
+		return nil
+	}
+	if declarationLike {
+		// Like a declaration, an anonymous function or class needs no
+		// terminating semicolon.
+		end := p.curToken.End
+		if p.peekTokenIs(lexer.Semicolon) {
+			p.nextToken()
+			end = p.curToken.End
+		}
+		return ast.NewExportDefaultDeclaration(expr, p.locFrom(start, end))
+	}
+	return p.finishModuleDeclaration(start, func(end lexer.Position) ast.Statement {
+		return ast.NewExportDefaultDeclaration(expr, p.locFrom(start, end))
+	})
+}
+
+// parseExportSpecifiers parses { a, b as c }, starting at the opening brace.
+func (p *Parser) parseExportSpecifiers() ([]*ast.ExportSpecifier, bool) {
+	var specifiers []*ast.ExportSpecifier
+	for {
+		p.nextToken()
+		if p.curTokenIs(lexer.RBrace) {
+			return specifiers, true
+		}
+		local, ok := p.moduleExportName()
+		if !ok {
+			return nil, false
+		}
+		exported := local
+		if p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "as" {
+			p.nextToken()
+			p.nextToken()
+			if exported, ok = p.moduleExportName(); !ok {
+				return nil, false
+			}
+		}
+		specifiers = append(specifiers, ast.NewExportSpecifier(local, exported, ast.Location{Start: local.Loc().Start, End: exported.Loc().End}))
+		if p.peekTokenIs(lexer.Comma) {
+			p.nextToken()
+			continue
+		}
+		if !p.expectPeek(lexer.RBrace) {
+			return nil, false
+		}
+		return specifiers, true
+	}
+}
+
+// moduleExportName reads the current token as the name of an export, which
+// may be any identifier name including reserved words such as default.
+func (p *Parser) moduleExportName() (*ast.Identifier, bool) {
+	tok := p.curToken
+	if tok.Type != lexer.Identifier && !isReservedWord(tok) {
+		p.syntaxError(fmt.Sprintf("unexpected token %q, expected an export name", tok.Literal))
+		return nil, false
+	}
+	return ast.NewIdentifier(tok.Literal, p.tokenLocation(tok)), true
+}
+
+// expectFrom advances to the contextual keyword from.
+func (p *Parser) expectFrom() bool {
+	if p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "from" {
+		p.nextToken()
+		return true
+	}
+	p.syntaxErrorAt(convertPosition(p.peekToken.Start), fmt.Sprintf("expected 'from', got %q", p.peekToken.Literal))
+	return false
+}
+
+// finishModuleDeclaration ends an import or export declaration at its
+// semicolon and builds the node spanning it.
+func (p *Parser) finishModuleDeclaration(start lexer.Position, build func(end lexer.Position) ast.Statement) ast.Statement {
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
+	}
+	return build(end)
+}
+
+// declareExport records an exported name, which must be unique within the
+// module.
+func (p *Parser) declareExport(name *ast.Identifier) {
+	if p.exports == nil {
+		p.exports = make(map[string]bool)
+	}
+	if p.exports[name.Name] {
+		p.syntaxErrorAt(name.Loc().Start, fmt.Sprintf("duplicate export '%s'", name.Name))
+		return
+	}
+	p.exports[name.Name] = true
+}
+
+// resolveLocalExports checks that every local name exported by an export
+// clause is declared at the top level of the module.
+func (p *Parser) resolveLocalExports() {
+	s := p.scope
+	for _, id := range p.localExports {
+		if !s.lexical[id.Name] && !s.vars[id.Name] && !s.functions[id.Name] {
+			p.syntaxErrorAt(id.Loc().Start, fmt.Sprintf("export '%s' is not defined in module", id.Name))
+		}
+	}
+}
+
+// declaredIdentifiers lists the bindings introduced by a declaration.
+func declaredIdentifiers(decl ast.Declaration) []*ast.Identifier {
+	switch d := decl.(type) {
+	case *ast.VariableDeclaration:
+		var ids []*ast.Identifier
+		for _, declarator := range d.Declarations {
+			ids = append(ids, boundIdentifiers(declarator.ID)...)
+		}
+		return ids
+	case *ast.FunctionDeclaration:
+		return []*ast.Identifier{d.ID}
+	case *ast.ClassDeclaration:
+		return []*ast.Identifier{d.ID}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	"es6-interpreter/ast"
 	"es6-interpreter/lexer"
@@ -20,6 +21,31 @@ type Parser struct {
 
 	errors []error
 
+	// strict reports whether the code currently being parsed is strict mode code.
+	strict bool
+	// scope holds the declarations of the innermost block or function body.
+	scope *scope
+	// parenthesized records expressions that were wrapped in parentheses.
+	parenthesized map[ast.Expression]bool
+	// noIn stops the in operator from being parsed as a binary operator while
+	// reading the head of a for statement, where it introduces a for-in loop.
+	noIn bool
+	// generator reports whether the innermost function being parsed is a
+	// generator, where yield begins an expression.
+	generator bool
+	// module reports whether the input is module code, where await is
+	// reserved.
+	module bool
+	// exports holds the names exported so far by the module being parsed.
+	exports map[string]bool
+	// localExports lists the local bindings named by export { ... } clauses
+	// without a from clause. They are resolved once the whole module has
+	// been parsed, since they may be declared after the export.
+	localExports []*ast.Identifier
+	// reachedEOF records that input ended where more was expected. Only the
+	// first such error is reported; the rest of the parse unwinds quietly.
+	reachedEOF bool
+
 	prefixFns map[lexer.TokenType]prefixParseFn
 	infixFns  map[lexer.TokenType]infixParseFn
 }
@@ -29,12 +55,19 @@ func New(src string) *Parser {
 	return NewFromLexer(lexer.New(src))
 }
 
+// NewAt returns a parser for src whose first character sits at start, so that
+// token and error positions refer to the enclosing document.
+func NewAt(src string, start lexer.Position) *Parser {
+	return NewFromLexer(lexer.NewAt(src, start))
+}
+
 // NewFromLexer returns a parser that pulls tokens directly from the supplied lexer.
 func NewFromLexer(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		lex:       l,
-		prefixFns: make(map[lexer.TokenType]prefixParseFn),
-		infixFns:  make(map[lexer.TokenType]infixParseFn),
+		lex:           l,
+		parenthesized: make(map[ast.Expression]bool),
+		prefixFns:     make(map[lexer.TokenType]prefixParseFn),
+		infixFns:      make(map[lexer.TokenType]infixParseFn),
 	}
 
 	// prime tokens
@@ -47,22 +80,56 @@ func NewFromLexer(l *lexer.Lexer) *Parser {
 	return p
 }
 
-// Errors returns the list of all parsing errors encountered.
+// Errors returns the list of all parsing errors encountered. Each is a
+// *SyntaxError.
 func (p *Parser) Errors() []error {
 	return p.errors
 }
 
 // ParseProgram parses the entire input into a Program node.
 func (p *Parser) ParseProgram() (*ast.Program, error) {
-	program := ast.NewProgram(nil, ast.SourceTypeScript, ast.Location{})
+	return p.parseProgram(ast.SourceTypeScript)
+}
 
+// ParseModule parses the entire input as module code, which is always strict
+// mode code.
+func (p *Parser) ParseModule() (*ast.Program, error) {
+	p.strict = true
+	p.module = true
+	return p.parseProgram(ast.SourceTypeModule)
+}
+
+func (p *Parser) parseProgram(sourceType ast.SourceType) (*ast.Program, error) {
+	program := ast.NewProgram(nil, sourceType, ast.Location{})
+	p.pushScope(true)
+	defer p.popScope()
+
+	prologue := true
+	var directives []lexer.Token
 	for !p.curTokenIs(lexer.EOF) {
-		stmt := p.parseStatement()
+		if prologue {
+			prologue = p.checkDirective(&directives)
+		}
+		errCount := len(p.errors)
+		var stmt ast.Statement
+		if sourceType == ast.SourceTypeModule && (p.curTokenIs(lexer.KeywordImport) || p.curTokenIs(lexer.KeywordExport)) {
+			stmt = p.parseModuleItem()
+		} else {
+			stmt = p.parseStatement()
+		}
 		if stmt != nil {
 			program.Body = append(program.Body, stmt)
 		}
+		if len(p.errors) > errCount {
+			p.synchronize()
+		}
 		p.nextToken()
 	}
+	if sourceType == ast.SourceTypeModule {
+		p.resolveLocalExports()
+	}
+
+	program.Directives, program.Strict = rawDirectives(directives), p.strict
 
 	if len(program.Body) > 0 {
 		first := program.Body[0].Loc()
@@ -77,9 +144,45 @@ func (p *Parser) ParseProgram() (*ast.Program, error) {
 	return program, nil
 }
 
+// synchronize skips the rest of a statement that failed to parse so that
+// parsing can resume with the next one and report its errors independently.
+// It stops on the semicolon or closing brace that ends the statement, or just
+// before the brace closing the enclosing block; the caller's nextToken then
+// steps past it.
+func (p *Parser) synchronize() {
+	depth := 0
+	for !p.curTokenIs(lexer.EOF) {
+		switch p.curToken.Type {
+		case lexer.LBrace:
+			depth++
+		case lexer.RBrace:
+			depth--
+			if depth <= 0 {
+				return
+			}
+		case lexer.Semicolon:
+			if depth == 0 {
+				return
+			}
+		}
+		if depth == 0 && p.peekTokenIs(lexer.RBrace) {
+			return
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.lex.NextToken()
+	// The lexer reserves words such as interface that only strict mode code
+	// reserves; elsewhere they are identifiers. checkIdentifier rejects them
+	// in strict mode code.
+	switch p.peekToken.Type {
+	case lexer.KeywordImplements, lexer.KeywordInterface, lexer.KeywordPackage,
+		lexer.KeywordPrivate, lexer.KeywordProtected, lexer.KeywordPublic:
+		p.peekToken.Type = lexer.Identifier
+	}
 }
 
 func (p *Parser) curTokenIs(tt lexer.TokenType) bool {
@@ -100,8 +203,53 @@ func (p *Parser) expectPeek(tt lexer.TokenType) bool {
 }
 
 func (p *Parser) peekError(tt lexer.TokenType) {
+	if p.peekTokenIs(lexer.EOF) {
+		p.unexpectedEOF(p.peekToken.Start)
+		return
+	}
 	msg := "expected next token to be " + string(tt) + ", got " + string(p.peekToken.Type)
-	p.errors = append(p.errors, errors.New(msg))
+	p.syntaxErrorAt(convertPosition(p.peekToken.Start), msg)
+}
+
+// SyntaxError is a parse error located in the source text. Position columns
+// are zero-based; Error reports them one-based.
+type SyntaxError struct {
+	Message  string
+	Position ast.Position
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (line %d, column %d)", e.Message, e.Position.Line, e.Position.Column+1)
+}
+
+// syntaxError records an error at the start of the current token.
+func (p *Parser) syntaxError(msg string) {
+	p.syntaxErrorAt(convertPosition(p.curToken.Start), msg)
+}
+
+func (p *Parser) syntaxErrorAt(pos ast.Position, msg string) {
+	p.errors = append(p.errors, &SyntaxError{Message: msg, Position: pos})
+}
+
+// unexpectedEOF reports that the input ended at pos in the middle of a
+// construct. It reports only once, however many constructs were left open.
+func (p *Parser) unexpectedEOF(pos lexer.Position) {
+	if p.reachedEOF {
+		return
+	}
+	p.reachedEOF = true
+	p.syntaxErrorAt(convertPosition(pos), "unexpected end of input")
+}
+
+// unterminated reports a construct that is missing its closing token. When
+// the input simply ran out, that is reported as the end of input instead.
+func (p *Parser) unterminated(construct string) {
+	switch {
+	case p.curTokenIs(lexer.EOF):
+		p.unexpectedEOF(p.curToken.Start)
+	case !p.reachedEOF:
+		p.syntaxError("unterminated " + construct)
+	}
 }
 
 func (p *Parser) curLoc() ast.Location {
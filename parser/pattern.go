@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"errors"
 	"fmt"
 
 	"es6-interpreter/ast"
@@ -33,14 +32,19 @@ func (p *Parser) parseBindingElement(allowDefault bool) ast.Pattern {
 func (p *Parser) parseBindingPrimary() ast.Pattern {
 	switch p.curToken.Type {
 	case lexer.Identifier:
-		return ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+		id := ast.NewIdentifier(p.curToken.Literal, p.tokenLocation(p.curToken))
+		p.checkBindingIdentifier(id)
+		return id
 	case lexer.LBracket:
 		return p.parseArrayPattern()
 	case lexer.LBrace:
 		return p.parseObjectPattern()
+	case lexer.EOF:
+		p.unexpectedEOF(p.curToken.Start)
+		return nil
 	default:
 		msg := fmt.Sprintf("unsupported binding pattern starting with %s", p.curToken.Type)
-		p.errors = append(p.errors, errors.New(msg))
+		p.syntaxError(msg)
 		return nil
 	}
 }
@@ -63,7 +67,7 @@ func (p *Parser) parseArrayPattern() ast.Pattern {
 
 			if p.curTokenIs(lexer.Ellipsis) {
 				if rest != nil {
-					p.errors = append(p.errors, errors.New("duplicate rest element in array pattern"))
+					p.syntaxError("duplicate rest element in array pattern")
 					return nil
 				}
 				restStart := p.curToken.Start
@@ -74,7 +78,7 @@ func (p *Parser) parseArrayPattern() ast.Pattern {
 				}
 				rest = ast.NewRestElement(arg, p.locFrom(restStart, p.curToken.End))
 				if !p.peekTokenIs(lexer.RBracket) {
-					p.errors = append(p.errors, errors.New("rest element must be last in array pattern"))
+					p.syntaxError("rest element must be last in array pattern")
 					return nil
 				}
 				p.nextToken() // move to closing bracket
@@ -89,12 +93,7 @@ func (p *Parser) parseArrayPattern() ast.Pattern {
 
 			if p.peekTokenIs(lexer.Comma) {
 				p.nextToken() // move to comma
-				if p.peekTokenIs(lexer.RBracket) {
-					elements = append(elements, nil)
-					p.nextToken() // move to closing bracket
-					break
-				}
-				p.nextToken() // move to next element
+				p.nextToken() // move to next element or closing bracket
 			} else {
 				p.nextToken()
 			}
@@ -102,7 +101,7 @@ func (p *Parser) parseArrayPattern() ast.Pattern {
 	}
 
 	if !p.curTokenIs(lexer.RBracket) {
-		p.errors = append(p.errors, errors.New("unterminated array pattern"))
+		p.unterminated("array pattern")
 		return nil
 	}
 
@@ -122,7 +121,7 @@ func (p *Parser) parseObjectPattern() ast.Pattern {
 		for !p.curTokenIs(lexer.RBrace) && !p.curTokenIs(lexer.EOF) {
 			if p.curTokenIs(lexer.Ellipsis) {
 				if rest != nil {
-					p.errors = append(p.errors, errors.New("duplicate rest element in object pattern"))
+					p.syntaxError("duplicate rest element in object pattern")
 					return nil
 				}
 				restStart := p.curToken.Start
@@ -133,7 +132,7 @@ func (p *Parser) parseObjectPattern() ast.Pattern {
 				}
 				rest = ast.NewRestElement(arg, p.locFrom(restStart, p.curToken.End))
 				if !p.peekTokenIs(lexer.RBrace) {
-					p.errors = append(p.errors, errors.New("rest element must be last in object pattern"))
+					p.syntaxError("rest element must be last in object pattern")
 					return nil
 				}
 				p.nextToken()
@@ -160,7 +159,7 @@ func (p *Parser) parseObjectPattern() ast.Pattern {
 	}
 
 	if !p.curTokenIs(lexer.RBrace) {
-		p.errors = append(p.errors, errors.New("unterminated object pattern"))
+		p.unterminated("object pattern")
 		return nil
 	}
 
@@ -176,6 +175,7 @@ func (p *Parser) parseObjectPatternProperty() *ast.ObjectPatternProperty {
 		keyTok := p.curToken
 		key := ast.NewIdentifier(keyTok.Literal, p.tokenLocation(keyTok))
 		basePattern := ast.NewIdentifier(keyTok.Literal, p.tokenLocation(keyTok))
+		p.checkBindingIdentifier(basePattern)
 		value := ast.Pattern(basePattern)
 		shorthand := true
 
@@ -199,9 +199,22 @@ func (p *Parser) parseObjectPatternProperty() *ast.ObjectPatternProperty {
 
 		loc := p.locFrom(start, p.curToken.End)
 		return ast.NewObjectPatternProperty(key, value, false, shorthand, loc)
+	case lexer.String, lexer.Number, lexer.LBracket:
+		// Literal and computed keys always need an explicit binding target.
+		key, computed := p.parsePropertyName("object pattern")
+		if key == nil || !p.expectPeek(lexer.Colon) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parseBindingElement(true)
+		if value == nil {
+			return nil
+		}
+		loc := p.locFrom(start, p.curToken.End)
+		return ast.NewObjectPatternProperty(key, value, computed, false, loc)
 	default:
 		msg := fmt.Sprintf("unsupported object pattern property starting with %s", p.curToken.Type)
-		p.errors = append(p.errors, errors.New(msg))
+		p.syntaxError(msg)
 		return nil
 	}
 }
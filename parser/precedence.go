@@ -9,6 +9,7 @@ const (
 	sequencePrec
 	assignmentPrec
 	conditionalPrec
+	nullishPrec
 	logicalOrPrec
 	logicalAndPrec
 	bitwiseOrPrec
@@ -19,6 +20,7 @@ const (
 	shiftPrec
 	additivePrec
 	multiplicativePrec
+	exponentPrec
 	prefixPrec
 	postfixPrec
 	callPrec
@@ -32,14 +34,19 @@ var precedences = map[lexer.TokenType]precedence{
 	lexer.MultiplyAssign:      assignmentPrec,
 	lexer.DivideAssign:        assignmentPrec,
 	lexer.ModuloAssign:        assignmentPrec,
+	lexer.ExponentAssign:      assignmentPrec,
 	lexer.ShiftLeftAssign:     assignmentPrec,
 	lexer.ShiftRightAssign:    assignmentPrec,
 	lexer.UnsignedShiftAssign: assignmentPrec,
 	lexer.BitwiseAndAssign:    assignmentPrec,
 	lexer.BitwiseOrAssign:     assignmentPrec,
 	lexer.BitwiseXorAssign:    assignmentPrec,
+	lexer.LogicalAndAssign:    assignmentPrec,
+	lexer.LogicalOrAssign:     assignmentPrec,
+	lexer.NullishAssign:       assignmentPrec,
 	lexer.Question:            conditionalPrec,
 	lexer.Arrow:               assignmentPrec,
+	lexer.NullishCoalescing:   nullishPrec,
 	lexer.LogicalOr:           logicalOrPrec,
 	lexer.LogicalAnd:          logicalAndPrec,
 	lexer.BitwiseOr:           bitwiseOrPrec,
@@ -63,16 +70,26 @@ var precedences = map[lexer.TokenType]precedence{
 	lexer.Multiply:            multiplicativePrec,
 	lexer.Divide:              multiplicativePrec,
 	lexer.Modulo:              multiplicativePrec,
+	lexer.Exponent:            exponentPrec,
 	lexer.Increment:           postfixPrec,
 	lexer.Decrement:           postfixPrec,
 	lexer.LParen:              callPrec,
 	lexer.LBracket:            callPrec,
 	lexer.Dot:                 callPrec,
+	lexer.OptionalChain:       callPrec,
 	lexer.TemplateHead:        callPrec,
 	lexer.TemplateTail:        callPrec,
 }
 
 func (p *Parser) peekPrecedence() precedence {
+	if p.noIn && p.peekTokenIs(lexer.KeywordIn) {
+		return lowest
+	}
+	// A postfix ++ or -- may not follow a line break; the operator then
+	// starts the next statement as a prefix update.
+	if (p.peekTokenIs(lexer.Increment) || p.peekTokenIs(lexer.Decrement)) && p.peekToken.NewlineBefore {
+		return lowest
+	}
 	if prec, ok := precedences[p.peekToken.Type]; ok {
 		return prec
 	}
@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"fmt"
+
+	"es6-interpreter/ast"
+)
+
+// scope tracks the names declared in one block or function body so duplicate
+// declarations can be reported as early errors.
+type scope struct {
+	parent   *scope
+	function bool // function bodies and the script top level
+
+	lexical   map[string]bool // let, const and class bindings
+	vars      map[string]bool // var bindings hoisted through this scope, and parameters
+	functions map[string]bool // function declarations directly inside a block
+}
+
+func (p *Parser) pushScope(function bool) {
+	p.scope = &scope{
+		parent:    p.scope,
+		function:  function,
+		lexical:   make(map[string]bool),
+		vars:      make(map[string]bool),
+		functions: make(map[string]bool),
+	}
+}
+
+func (p *Parser) popScope() {
+	p.scope = p.scope.parent
+}
+
+func (p *Parser) redeclarationError(ident *ast.Identifier) {
+	p.syntaxErrorAt(ident.Loc().Start, fmt.Sprintf("identifier '%s' has already been declared", ident.Name))
+}
+
+// declareLexical records a let, const or class binding in the current scope.
+func (p *Parser) declareLexical(ident *ast.Identifier) {
+	s := p.scope
+	if s.lexical[ident.Name] || s.vars[ident.Name] || s.functions[ident.Name] {
+		p.redeclarationError(ident)
+		return
+	}
+	s.lexical[ident.Name] = true
+}
+
+// declareVar records a var binding in every scope up to the enclosing function,
+// since it conflicts with lexical bindings in any of them.
+func (p *Parser) declareVar(ident *ast.Identifier) {
+	for s := p.scope; s != nil; s = s.parent {
+		if s.lexical[ident.Name] || s.functions[ident.Name] {
+			p.redeclarationError(ident)
+			return
+		}
+		s.vars[ident.Name] = true
+		if s.function {
+			return
+		}
+	}
+}
+
+// declareFunction records a function declaration. At the top of a function or
+// script it behaves like a var; inside a block it is lexically scoped, although
+// sloppy mode code may repeat it.
+func (p *Parser) declareFunction(ident *ast.Identifier) {
+	s := p.scope
+	if s.function {
+		if s.lexical[ident.Name] {
+			p.redeclarationError(ident)
+			return
+		}
+		s.vars[ident.Name] = true
+		return
+	}
+	if s.lexical[ident.Name] || s.vars[ident.Name] || (p.strict && s.functions[ident.Name]) {
+		p.redeclarationError(ident)
+		return
+	}
+	s.functions[ident.Name] = true
+}
+
+// declareParams records parameter names in the current function scope.
+// Duplicate parameters are not checked here.
+func (p *Parser) declareParams(params []ast.Pattern) {
+	for _, param := range params {
+		for _, ident := range boundIdentifiers(param) {
+			p.scope.vars[ident.Name] = true
+		}
+	}
+}
+
+func (p *Parser) declareVariables(decl *ast.VariableDeclaration) {
+	for _, d := range decl.Declarations {
+		for _, ident := range boundIdentifiers(d.ID) {
+			if decl.DeclareKind == ast.VarKind {
+				p.declareVar(ident)
+			} else {
+				p.declareLexical(ident)
+			}
+		}
+	}
+}
+
+// boundIdentifiers lists the identifiers bound by a binding pattern.
+func boundIdentifiers(pattern ast.Pattern) []*ast.Identifier {
+	switch pt := pattern.(type) {
+	case *ast.Identifier:
+		return []*ast.Identifier{pt}
+	case *ast.AssignmentPattern:
+		return boundIdentifiers(pt.Left)
+	case *ast.RestElement:
+		return boundIdentifiers(pt.Argument)
+	case *ast.ArrayPattern:
+		var idents []*ast.Identifier
+		for _, elem := range pt.Elements {
+			if elem != nil {
+				idents = append(idents, boundIdentifiers(elem)...)
+			}
+		}
+		if pt.Rest != nil {
+			idents = append(idents, boundIdentifiers(pt.Rest)...)
+		}
+		return idents
+	case *ast.ObjectPattern:
+		var idents []*ast.Identifier
+		for _, prop := range pt.Properties {
+			idents = append(idents, boundIdentifiers(prop.Value)...)
+		}
+		if pt.Rest != nil {
+			idents = append(idents, boundIdentifiers(pt.Rest)...)
+		}
+		return idents
+	default:
+		return nil
+	}
+}
+
+// strictReservedWords are identifiers in sloppy mode code but reserved in
+// strict mode code.
+var strictReservedWords = map[string]bool{
+	"implements": true,
+	"interface":  true,
+	"let":        true,
+	"package":    true,
+	"private":    true,
+	"protected":  true,
+	"public":     true,
+	"static":     true,
+	"yield":      true,
+}
+
+// checkIdentifier reports id when the code being parsed reserves its name.
+// Module code reserves await, and strict mode code the strictReservedWords.
+func (p *Parser) checkIdentifier(id *ast.Identifier) {
+	switch {
+	case p.module && id.Name == "await":
+		p.syntaxErrorAt(id.Loc().Start, "unexpected reserved word 'await' in module code")
+	case p.strict && strictReservedWords[id.Name]:
+		p.syntaxErrorAt(id.Loc().Start, fmt.Sprintf("unexpected strict mode reserved word '%s'", id.Name))
+	}
+}
+
+// checkBindingIdentifier reports id when it may not be declared as a binding.
+// Besides reserved words, strict mode code may not bind eval or arguments.
+func (p *Parser) checkBindingIdentifier(id *ast.Identifier) {
+	p.checkIdentifier(id)
+	p.checkStrictBinding(id)
+}
+
+// checkStrictBinding reports a binding of eval or arguments in strict mode
+// code.
+func (p *Parser) checkStrictBinding(id *ast.Identifier) {
+	if p.strict && isEvalOrArguments(id) {
+		p.syntaxErrorAt(id.Loc().Start, fmt.Sprintf("cannot declare '%s' in strict mode", id.Name))
+	}
+}
+
+// checkAssignmentTarget reports an assignment or update of eval or arguments
+// in strict mode code.
+func (p *Parser) checkAssignmentTarget(target ast.Expression) {
+	if id, ok := target.(*ast.Identifier); ok && p.strict && isEvalOrArguments(id) {
+		p.syntaxErrorAt(id.Loc().Start, fmt.Sprintf("cannot assign to '%s' in strict mode", id.Name))
+	}
+}
+
+func isEvalOrArguments(id *ast.Identifier) bool {
+	return id.Name == "eval" || id.Name == "arguments"
+}
+
+// checkParams reports duplicate parameter names where the function forbids
+// them: in strict mode code, with non-simple parameters, or when unique is
+// set. When a "use strict" directive made the function strict only after its
+// parameters were parsed, becameStrict asks for eval and arguments to be
+// reported as well.
+func (p *Parser) checkParams(params []ast.Pattern, unique, becameStrict bool) {
+	unique = unique || p.strict || !isSimpleParameterList(params)
+	seen := make(map[string]bool)
+	for _, param := range params {
+		for _, id := range boundIdentifiers(param) {
+			if becameStrict {
+				p.checkStrictBinding(id)
+			}
+			if unique && seen[id.Name] {
+				p.syntaxErrorAt(id.Loc().Start, fmt.Sprintf("duplicate parameter name '%s' not allowed in this context", id.Name))
+			}
+			seen[id.Name] = true
+		}
+	}
+}
+
+// isSimpleParameterList reports whether params are plain identifiers, without
+// defaults, destructuring or a rest parameter.
+func isSimpleParameterList(params []ast.Pattern) bool {
+	for _, param := range params {
+		if _, ok := param.(*ast.Identifier); !ok {
+			return false
+		}
+	}
+	return true
+}
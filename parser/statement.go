@@ -1,7 +1,8 @@
 package parser
 
 import (
-	"errors"
+	"fmt"
+	"slices"
 
 	"es6-interpreter/ast"
 	"es6-interpreter/lexer"
@@ -41,11 +42,21 @@ func (p *Parser) parseStatement() ast.Statement {
 		if p.peekTokenIs(lexer.Colon) {
 			return p.parseLabeledStatement()
 		}
+		if p.isContextualKeyword("static") && p.peekTokenIs(lexer.LBrace) && p.peekToken.Start.Line == p.curToken.End.Line {
+			p.syntaxError("static blocks are only allowed in class bodies")
+			return nil
+		}
 		return p.parseExpressionStatement()
 	case lexer.KeywordTry:
 		return p.parseTryStatement()
 	case lexer.KeywordFunction:
 		return p.parseFunctionDeclaration()
+	case lexer.KeywordClass:
+		return p.parseClassDeclaration()
+	case lexer.KeywordImport, lexer.KeywordExport:
+		p.syntaxError(fmt.Sprintf("%s may only appear at the top level of a module", p.curToken.Literal))
+		p.skipModuleItem()
+		return nil
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -57,22 +68,63 @@ func (p *Parser) parseEmptyStatement() ast.Statement {
 }
 
 func (p *Parser) parseBlockStatement() ast.Statement {
+	p.pushScope(false)
+	defer p.popScope()
+	return p.parseBlockBody(nil)
+}
+
+// parseFunctionBody parses the block body of a function, honouring a leading
+// directive prologue. Strictness enabled by the body does not leak outward.
+// The body shares a scope with the parameters. generator reports whether the
+// function is a generator, whose body may contain yield expressions, and
+// uniqueParams whether its parameter names must be distinct even in sloppy
+// mode code, as for arrow functions and methods. The returned prologue
+// records the body's directives and strictness.
+func (p *Parser) parseFunctionBody(params []ast.Pattern, generator, uniqueParams bool) (ast.Statement, prologue) {
+	outerStrict, outerGenerator := p.strict, p.generator
+	p.generator = generator
+	p.pushScope(true)
+	p.declareParams(params)
+	var directives []lexer.Token
+	body := p.parseBlockBody(&directives)
+	p.popScope()
+	if slices.ContainsFunc(directives, isUseStrict) && !isSimpleParameterList(params) {
+		p.syntaxErrorAt(params[0].Loc().Start, "\"use strict\" not allowed in function with non-simple parameters")
+	}
+	p.checkParams(params, uniqueParams, p.strict && !outerStrict)
+	pro := prologue{directives: rawDirectives(directives), strict: p.strict}
+	p.strict, p.generator = outerStrict, outerGenerator
+	return body, pro
+}
+
+// parseBlockBody parses the statements of a block up to its closing brace.
+// For a function body, directives is non-nil and collects the directive
+// prologue.
+func (p *Parser) parseBlockBody(directives *[]lexer.Token) ast.Statement {
 	start := p.curToken.Start
 
 	// Move inside the block body.
 	p.nextToken()
 
 	var body []ast.Statement
+	inPrologue := directives != nil
 	for !p.curTokenIs(lexer.RBrace) && !p.curTokenIs(lexer.EOF) {
+		if inPrologue {
+			inPrologue = p.checkDirective(directives)
+		}
+		errCount := len(p.errors)
 		stmt := p.parseStatement()
 		if stmt != nil {
 			body = append(body, stmt)
 		}
+		if len(p.errors) > errCount {
+			p.synchronize()
+		}
 		p.nextToken()
 	}
 
 	if !p.curTokenIs(lexer.RBrace) {
-		p.errors = append(p.errors, errors.New("unterminated block statement"))
+		p.unterminated("block statement")
 		return nil
 	}
 
@@ -80,18 +132,68 @@ func (p *Parser) parseBlockStatement() ast.Statement {
 	return ast.NewBlockStatement(body, loc)
 }
 
+// checkDirective inspects the current token while inside a directive prologue.
+// It switches the parser into strict mode on a "use strict" directive and
+// reports whether the prologue continues with the current statement.
+// directives collects the prologue's earlier directives, which a later "use
+// strict" makes strict code too.
+func (p *Parser) checkDirective(directives *[]lexer.Token) bool {
+	tok := p.curToken
+	if tok.Type != lexer.String {
+		return false
+	}
+	terminated := p.peekTokenIs(lexer.Semicolon) || p.peekTokenIs(lexer.RBrace) || p.peekTokenIs(lexer.EOF) ||
+		p.peekToken.Start.Line > tok.End.Line
+	if !terminated {
+		return false
+	}
+	if isUseStrict(tok) && !p.strict {
+		p.strict = true
+		// Earlier directives were decoded as sloppy code, so legacy octal
+		// escapes in them have not been reported yet.
+		for _, prev := range *directives {
+			if _, err := decodeStringLiteral(prev.Literal, true); err != nil {
+				p.syntaxErrorAt(convertPosition(prev.Start), err.Error())
+			}
+		}
+	}
+	*directives = append(*directives, tok)
+	return true
+}
+
+// isUseStrict reports whether the directive tok is "use strict". Directives
+// compare the raw source text, so escaped spellings do not count.
+func isUseStrict(tok lexer.Token) bool {
+	raw := tok.Literal
+	return len(raw) >= 2 && raw[1:len(raw)-1] == "use strict"
+}
+
+// prologue describes the directive prologue of a function body.
+type prologue struct {
+	directives []string
+	strict     bool // whether the function is strict mode code
+}
+
+// rawDirectives returns the source text of directive tokens.
+func rawDirectives(tokens []lexer.Token) []string {
+	var raw []string
+	for _, tok := range tokens {
+		raw = append(raw, tok.Literal)
+	}
+	return raw
+}
+
 func (p *Parser) parseReturnStatement() ast.Statement {
 	start := p.curToken.Start
 
-	// No argument if the next token is a semicolon, closing brace, or EOF.
-	switch {
-	case p.peekTokenIs(lexer.Semicolon):
-		p.nextToken()
-		loc := p.locFrom(start, p.curToken.End)
-		return ast.NewReturnStatement(nil, loc)
-	case p.peekTokenIs(lexer.RBrace) || p.peekTokenIs(lexer.EOF):
-		loc := p.locFrom(start, p.curToken.End)
-		return ast.NewReturnStatement(nil, loc)
+	// No argument if the statement ends right away. A line break after
+	// return always ends it, so the next line is not returned.
+	if p.peekTokenIs(lexer.Semicolon) || p.peekTokenIs(lexer.RBrace) || p.peekTokenIs(lexer.EOF) || p.peekToken.NewlineBefore {
+		end, ok := p.consumeSemicolon()
+		if !ok {
+			return nil
+		}
+		return ast.NewReturnStatement(nil, p.locFrom(start, end))
 	}
 
 	// Parse return argument expression.
@@ -101,14 +203,11 @@ func (p *Parser) parseReturnStatement() ast.Statement {
 		return nil
 	}
 
-	end := argument.Loc().End
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = convertPosition(p.curToken.End)
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
 	}
-
-	loc := ast.Location{Start: convertPosition(start), End: end}
-	return ast.NewReturnStatement(argument, loc)
+	return ast.NewReturnStatement(argument, p.locFrom(start, end))
 }
 
 func (p *Parser) parseIfStatement() ast.Statement {
@@ -217,51 +316,43 @@ func (p *Parser) parseDoWhileStatement() ast.Statement {
 
 func (p *Parser) parseBreakStatement() ast.Statement {
 	start := p.curToken.Start
-	end := p.curToken.End
 
 	var label *ast.Identifier
-	if p.peekTokenIs(lexer.Identifier) && p.peekToken.Start.Line == p.curToken.End.Line {
+	if p.peekTokenIs(lexer.Identifier) && !p.peekToken.NewlineBefore {
 		p.nextToken()
 		tok := p.curToken
 		label = ast.NewIdentifier(tok.Literal, p.tokenLocation(tok))
-		end = tok.End
 	}
 
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = p.curToken.End
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
 	}
-
-	loc := p.locFrom(start, end)
-	return ast.NewBreakStatement(label, loc)
+	return ast.NewBreakStatement(label, p.locFrom(start, end))
 }
 
 func (p *Parser) parseContinueStatement() ast.Statement {
 	start := p.curToken.Start
-	end := p.curToken.End
 
 	var label *ast.Identifier
-	if p.peekTokenIs(lexer.Identifier) && p.peekToken.Start.Line == p.curToken.End.Line {
+	if p.peekTokenIs(lexer.Identifier) && !p.peekToken.NewlineBefore {
 		p.nextToken()
 		tok := p.curToken
 		label = ast.NewIdentifier(tok.Literal, p.tokenLocation(tok))
-		end = tok.End
 	}
 
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = p.curToken.End
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
 	}
-
-	loc := p.locFrom(start, end)
-	return ast.NewContinueStatement(label, loc)
+	return ast.NewContinueStatement(label, p.locFrom(start, end))
 }
 
 func (p *Parser) parseThrowStatement() ast.Statement {
 	start := p.curToken.Start
 
-	if p.peekToken.Start.Line != p.curToken.End.Line {
-		p.errors = append(p.errors, errors.New("illegal newline after throw"))
+	if p.peekToken.NewlineBefore {
+		p.syntaxError("illegal newline after throw")
 		return nil
 	}
 
@@ -271,27 +362,21 @@ func (p *Parser) parseThrowStatement() ast.Statement {
 		return nil
 	}
 
-	end := argument.Loc().End
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = convertPosition(p.curToken.End)
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
 	}
-
-	loc := ast.Location{Start: convertPosition(start), End: end}
-	return ast.NewThrowStatement(argument, loc)
+	return ast.NewThrowStatement(argument, p.locFrom(start, end))
 }
 
 func (p *Parser) parseDebuggerStatement() ast.Statement {
 	start := p.curToken.Start
-	end := p.curToken.End
 
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = p.curToken.End
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
 	}
-
-	loc := p.locFrom(start, end)
-	return ast.NewDebuggerStatement(loc)
+	return ast.NewDebuggerStatement(p.locFrom(start, end))
 }
 
 func (p *Parser) parseSwitchStatement() ast.Statement {
@@ -318,6 +403,10 @@ func (p *Parser) parseSwitchStatement() ast.Statement {
 	// move inside switch body
 	p.nextToken()
 
+	// All clauses share one block scope.
+	p.pushScope(false)
+	defer p.popScope()
+
 	var cases []*ast.SwitchCase
 	seenDefault := false
 
@@ -337,7 +426,7 @@ func (p *Parser) parseSwitchStatement() ast.Statement {
 			}
 		case lexer.KeywordDefault:
 			if seenDefault {
-				p.errors = append(p.errors, errors.New("multiple default clauses in switch"))
+				p.syntaxError("multiple default clauses in switch")
 				return nil
 			}
 			seenDefault = true
@@ -345,7 +434,7 @@ func (p *Parser) parseSwitchStatement() ast.Statement {
 				return nil
 			}
 		default:
-			p.errors = append(p.errors, errors.New("expected case or default clause"))
+			p.syntaxError("expected case or default clause")
 			return nil
 		}
 
@@ -371,7 +460,7 @@ func (p *Parser) parseSwitchStatement() ast.Statement {
 	}
 
 	if !p.curTokenIs(lexer.RBrace) {
-		p.errors = append(p.errors, errors.New("unterminated switch statement"))
+		p.unterminated("switch statement")
 		return nil
 	}
 
@@ -381,6 +470,9 @@ func (p *Parser) parseSwitchStatement() ast.Statement {
 
 func (p *Parser) parseWithStatement() ast.Statement {
 	start := p.curToken.Start
+	if p.strict {
+		p.syntaxErrorAt(convertPosition(start), "with statements are not allowed in strict mode")
+	}
 
 	if !p.expectPeek(lexer.LParen) {
 		return nil
@@ -410,6 +502,7 @@ func (p *Parser) parseLabeledStatement() ast.Statement {
 	start := p.curToken.Start
 	labelTok := p.curToken
 	label := ast.NewIdentifier(labelTok.Literal, p.tokenLocation(labelTok))
+	p.checkIdentifier(label)
 
 	if !p.expectPeek(lexer.Colon) {
 		return nil
@@ -439,7 +532,7 @@ func (p *Parser) parseTryStatement() ast.Statement {
 
 	tryBlock, ok := blockStmt.(*ast.BlockStatement)
 	if !ok {
-		p.errors = append(p.errors, errors.New("try block did not produce BlockStatement"))
+		p.syntaxError("try block did not produce BlockStatement")
 		return nil
 	}
 
@@ -469,14 +562,14 @@ func (p *Parser) parseTryStatement() ast.Statement {
 		var ok bool
 		finalizer, ok = finalizerStmt.(*ast.BlockStatement)
 		if !ok {
-			p.errors = append(p.errors, errors.New("finally block did not produce BlockStatement"))
+			p.syntaxError("finally block did not produce BlockStatement")
 			return nil
 		}
 		end = p.curToken.End
 	}
 
 	if handler == nil && finalizer == nil {
-		p.errors = append(p.errors, errors.New("try statement requires catch or finally"))
+		p.syntaxError("try statement requires catch or finally")
 		return nil
 	}
 
@@ -487,32 +580,43 @@ func (p *Parser) parseTryStatement() ast.Statement {
 func (p *Parser) parseCatchClause() *ast.CatchClause {
 	start := p.curToken.Start
 
-	if !p.expectPeek(lexer.LParen) {
-		return nil
-	}
-
-	p.nextToken()
-	param := p.parseBindingElement(false)
-	if param == nil {
-		return nil
-	}
+	// The binding is optional: catch { } discards the thrown value.
+	var param ast.Pattern
+	if p.peekTokenIs(lexer.LParen) {
+		p.nextToken()
+		p.nextToken()
+		param = p.parseBindingElement(false)
+		if param == nil {
+			return nil
+		}
 
-	if !p.expectPeek(lexer.RParen) {
-		return nil
+		if !p.expectPeek(lexer.RParen) {
+			return nil
+		}
 	}
 
 	if !p.expectPeek(lexer.LBrace) {
 		return nil
 	}
 
-	bodyStmt := p.parseBlockStatement()
+	// The parameter is declared in the body's scope, so that let, const,
+	// class and function declarations in the body cannot redeclare it. A
+	// var of the same name stays legal.
+	p.pushScope(false)
+	defer p.popScope()
+	if param != nil {
+		for _, ident := range boundIdentifiers(param) {
+			p.scope.vars[ident.Name] = true
+		}
+	}
+	bodyStmt := p.parseBlockBody(nil)
 	if bodyStmt == nil {
 		return nil
 	}
 
 	body, ok := bodyStmt.(*ast.BlockStatement)
 	if !ok {
-		p.errors = append(p.errors, errors.New("catch body did not produce BlockStatement"))
+		p.syntaxError("catch body did not produce BlockStatement")
 		return nil
 	}
 
@@ -535,6 +639,8 @@ func (p *Parser) parseFunctionDeclaration() ast.Statement {
 
 	nameTok := p.curToken
 	id := ast.NewIdentifier(nameTok.Literal, p.tokenLocation(nameTok))
+	p.checkBindingIdentifier(id)
+	p.declareFunction(id)
 
 	if !p.expectPeek(lexer.LParen) {
 		return nil
@@ -549,19 +655,21 @@ func (p *Parser) parseFunctionDeclaration() ast.Statement {
 		return nil
 	}
 
-	bodyStmt := p.parseBlockStatement()
+	bodyStmt, pro := p.parseFunctionBody(params, isGenerator, false)
 	if bodyStmt == nil {
 		return nil
 	}
 
 	body, ok2 := bodyStmt.(*ast.BlockStatement)
 	if !ok2 {
-		p.errors = append(p.errors, errors.New("function body did not produce BlockStatement"))
+		p.syntaxError("function body did not produce BlockStatement")
 		return nil
 	}
 
 	loc := p.locFrom(start, p.curToken.End)
-	return ast.NewFunctionDeclaration(id, params, body, isGenerator, loc)
+	decl := ast.NewFunctionDeclaration(id, params, body, isGenerator, loc)
+	decl.Directives, decl.Strict = pro.directives, pro.strict
+	return decl
 }
 
 func (p *Parser) parseFunctionParams() ([]ast.Pattern, bool) {
@@ -578,7 +686,7 @@ func (p *Parser) parseFunctionParams() ([]ast.Pattern, bool) {
 	restSeen := false
 	for !p.curTokenIs(lexer.RParen) && !p.curTokenIs(lexer.EOF) {
 		if restSeen {
-			p.errors = append(p.errors, errors.New("parameters not allowed after rest element"))
+			p.syntaxError("parameters not allowed after rest element")
 			return nil, false
 		}
 
@@ -607,7 +715,7 @@ func (p *Parser) parseFunctionParams() ([]ast.Pattern, bool) {
 		if p.peekTokenIs(lexer.Comma) {
 			p.nextToken()
 			if p.peekTokenIs(lexer.RParen) {
-				p.errors = append(p.errors, errors.New("trailing comma without parameter"))
+				p.syntaxError("trailing comma without parameter")
 				return nil, false
 			}
 			p.nextToken()
@@ -619,7 +727,7 @@ func (p *Parser) parseFunctionParams() ([]ast.Pattern, bool) {
 			break
 		}
 
-		p.errors = append(p.errors, errors.New("unexpected token in parameter list"))
+		p.syntaxError("unexpected token in parameter list")
 		return nil, false
 	}
 
@@ -629,6 +737,10 @@ func (p *Parser) parseFunctionParams() ([]ast.Pattern, bool) {
 func (p *Parser) parseForStatement() ast.Statement {
 	start := p.curToken.Start
 
+	// Bindings declared in the head are scoped to the loop.
+	p.pushScope(false)
+	defer p.popScope()
+
 	if !p.expectPeek(lexer.LParen) {
 		return nil
 	}
@@ -637,21 +749,33 @@ func (p *Parser) parseForStatement() ast.Statement {
 
 	var init ast.Node
 	if !p.curTokenIs(lexer.Semicolon) {
+		p.noIn = true
 		switch p.curToken.Type {
 		case lexer.KeywordVar, lexer.KeywordLet, lexer.KeywordConst:
-			decl := p.parseVariableStatement()
+			decl := p.parseVariableDeclarationList()
+			p.noIn = false
 			if decl == nil {
 				return nil
 			}
 			init = decl
+			if p.peekTokenIs(lexer.KeywordIn) || p.peekIsOf() {
+				return p.parseForInOfRest(start, decl)
+			}
+			if !p.checkInitializers(decl) {
+				return nil
+			}
 		default:
 			expr := p.parseExpression(lowest)
+			p.noIn = false
 			if expr == nil {
 				return nil
 			}
 			init = expr
+			if p.peekTokenIs(lexer.KeywordIn) || p.peekIsOf() {
+				return p.parseForInOfRest(start, expr)
+			}
 			if !p.peekTokenIs(lexer.Semicolon) {
-				p.errors = append(p.errors, errors.New("expected semicolon after for-loop initializer"))
+				p.syntaxError("expected semicolon after for-loop initializer")
 				return nil
 			}
 		}
@@ -695,7 +819,7 @@ func (p *Parser) parseForStatement() ast.Statement {
 	}
 
 	if !p.curTokenIs(lexer.RParen) {
-		p.errors = append(p.errors, errors.New("unterminated for-loop clause"))
+		p.unterminated("for-loop clause")
 		return nil
 	}
 
@@ -709,22 +833,139 @@ func (p *Parser) parseForStatement() ast.Statement {
 	return ast.NewForStatement(init, test, update, body, loc)
 }
 
+func (p *Parser) peekIsOf() bool {
+	return p.peekTokenIs(lexer.Identifier) && p.peekToken.Literal == "of"
+}
+
+// parseForInOfRest finishes a for-in or for-of statement once its left-hand
+// side has been read and the in or of keyword is the peek token.
+func (p *Parser) parseForInOfRest(start lexer.Position, left ast.Node) ast.Statement {
+	isOf := p.peekIsOf()
+	keyword := "for-in"
+	if isOf {
+		keyword = "for-of"
+	}
+
+	switch l := left.(type) {
+	case *ast.VariableDeclaration:
+		if len(l.Declarations) != 1 {
+			p.syntaxError("only a single binding is allowed in a " + keyword + " loop head")
+			return nil
+		}
+		if l.Declarations[0].Init != nil {
+			p.syntaxError(keyword + " loop variable declaration may not have an initializer")
+			return nil
+		}
+	case *ast.Identifier, *ast.MemberExpression:
+	default:
+		p.syntaxError("invalid left-hand side in " + keyword + " loop")
+		return nil
+	}
+
+	p.nextToken() // in / of
+	p.nextToken()
+	// for-of takes an assignment expression, for-in a full expression.
+	prec := lowest
+	if isOf {
+		prec = sequencePrec
+	}
+	right := p.parseExpression(prec)
+	if right == nil {
+		return nil
+	}
+	if !p.expectPeek(lexer.RParen) {
+		return nil
+	}
+
+	p.nextToken()
+	body := p.parseStatement()
+	if body == nil {
+		return nil
+	}
+
+	loc := ast.Location{Start: convertPosition(start), End: body.Loc().End}
+	if isOf {
+		return ast.NewForOfStatement(left, right, body, false, loc)
+	}
+	return ast.NewForInStatement(left, right, body, loc)
+}
+
 func (p *Parser) parseExpressionStatement() ast.Statement {
 	expr := p.parseExpression(lowest)
 	if expr == nil {
 		return nil
 	}
 
-	stmt := ast.NewExpressionStatement(expr, expr.Loc())
+	if _, ok := p.consumeSemicolon(); !ok {
+		return nil
+	}
+	return ast.NewExpressionStatement(expr, expr.Loc())
+}
 
+func (p *Parser) parseVariableStatement() ast.Statement {
+	decl := p.parseVariableDeclarationList()
+	if decl == nil {
+		return nil
+	}
+	if !p.checkInitializers(decl) {
+		return nil
+	}
+	end, ok := p.consumeSemicolon()
+	if !ok {
+		return nil
+	}
+	loc := decl.Loc()
+	loc.End = convertPosition(end)
+	p.setNodeLocation(decl, loc)
+	return decl
+}
+
+// consumeSemicolon ends a statement that the grammar terminates with a
+// semicolon, returning the statement's end. An explicit semicolon is
+// consumed. Otherwise one is inserted automatically before a closing brace,
+// the end of input or a token on a new line, and any other token is a
+// syntax error.
+func (p *Parser) consumeSemicolon() (lexer.Position, bool) {
 	if p.peekTokenIs(lexer.Semicolon) {
 		p.nextToken()
+		return p.curToken.End, true
+	}
+	if p.peekTokenIs(lexer.RBrace) || p.peekTokenIs(lexer.EOF) || p.peekToken.NewlineBefore {
+		return p.curToken.End, true
 	}
+	p.syntaxErrorAt(convertPosition(p.peekToken.Start), fmt.Sprintf("missing semicolon before %q", p.peekToken.Literal))
+	return lexer.Position{}, false
+}
 
-	return stmt
+// checkInitializers reports declarators that need an initializer but lack
+// one: every const binding and every destructuring pattern. The head of a
+// for-in or for-of loop supplies the value instead and is not checked.
+func (p *Parser) checkInitializers(decl *ast.VariableDeclaration) bool {
+	ok := true
+	for _, d := range decl.Declarations {
+		if d.Init != nil {
+			continue
+		}
+		switch {
+		case decl.DeclareKind == ast.ConstKind:
+			p.syntaxErrorAt(d.Loc().Start, "missing initializer in const declaration")
+			ok = false
+		case !isIdentifierPattern(d.ID):
+			p.syntaxErrorAt(d.Loc().Start, "missing initializer in destructuring declaration")
+			ok = false
+		}
+	}
+	return ok
 }
 
-func (p *Parser) parseVariableStatement() ast.Statement {
+func isIdentifierPattern(pattern ast.Pattern) bool {
+	_, ok := pattern.(*ast.Identifier)
+	return ok
+}
+
+// parseVariableDeclarationList parses a var, let or const keyword followed by
+// its comma-separated declarators.
+func (p *Parser) parseVariableDeclarationList() *ast.VariableDeclaration {
 	kind := ast.VarKind
 	switch p.curToken.Type {
 	case lexer.KeywordConst:
@@ -741,7 +982,7 @@ func (p *Parser) parseVariableStatement() ast.Statement {
 	var declarators []*ast.VariableDeclarator
 	for {
 		if p.curToken.Type == lexer.Semicolon {
-			p.errors = append(p.errors, errors.New("missing binding in variable declaration"))
+			p.syntaxError("missing binding in variable declaration")
 			return nil
 		}
 
@@ -759,14 +1000,9 @@ func (p *Parser) parseVariableStatement() ast.Statement {
 		p.nextToken() // advance to next binding token
 	}
 
-	end := p.curToken.End
-
-	if p.peekTokenIs(lexer.Semicolon) {
-		p.nextToken()
-		end = p.curToken.End
-	}
-
-	return ast.NewVariableDeclaration(kind, declarators, p.locFrom(start, end))
+	decl := ast.NewVariableDeclaration(kind, declarators, p.locFrom(start, p.curToken.End))
+	p.declareVariables(decl)
+	return decl
 }
 
 func (p *Parser) parseVariableDeclarator() *ast.VariableDeclarator {
@@ -781,7 +1017,8 @@ func (p *Parser) parseVariableDeclarator() *ast.VariableDeclarator {
 	if p.peekTokenIs(lexer.Assign) {
 		p.nextToken() // move to '='
 		p.nextToken() // advance to initializer expression
-		init = p.parseExpression(lowest)
+		// A comma ends the initializer and starts the next declarator.
+		init = p.parseExpression(sequencePrec)
 		if init == nil {
 			return nil
 		}
@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"es6-interpreter/lexer"
+)
+
+// stringValue returns the cooked value of a string literal token, recording
+// an error and falling back to the raw text when it cannot be decoded.
+func (p *Parser) stringValue(tok lexer.Token) string {
+	val, err := decodeStringLiteral(tok.Literal, p.strict)
+	if err != nil {
+		p.syntaxErrorAt(convertPosition(tok.Start), err.Error())
+		return tok.Literal
+	}
+	return val
+}
+
+// decodeStringLiteral converts the raw text of a quoted string literal into
+// its value, processing every ECMAScript escape form. Legacy octal escapes
+// and \8 and \9 are only accepted outside strict mode code. Surrogate pairs
+// written as two \u escapes are combined; lone surrogates cannot be held in
+// a Go string and decode to U+FFFD.
+func decodeStringLiteral(raw string, strict bool) (string, error) {
+	if len(raw) < 2 || (raw[0] != '"' && raw[0] != '\'') || raw[len(raw)-1] != raw[0] {
+		return "", fmt.Errorf("malformed string literal %s", raw)
+	}
+	body := raw[1 : len(raw)-1]
+	if !strings.ContainsRune(body, '\\') {
+		return body, nil
+	}
+
+	var b strings.Builder
+	pendingHigh := rune(-1) // high surrogate awaiting its low half
+	flush := func() {
+		if pendingHigh >= 0 {
+			b.WriteRune(utf8.RuneError)
+			pendingHigh = -1
+		}
+	}
+	writeUnit := func(r rune) {
+		switch {
+		case utf16.IsSurrogate(r) && r < 0xDC00:
+			flush()
+			pendingHigh = r
+		case utf16.IsSurrogate(r) && pendingHigh >= 0:
+			b.WriteRune(utf16.DecodeRune(pendingHigh, r))
+			pendingHigh = -1
+		default:
+			flush()
+			b.WriteRune(r)
+		}
+	}
+
+	for idx := 0; idx < len(body); {
+		c := body[idx]
+		if c != '\\' {
+			flush()
+			r, size := utf8.DecodeRuneInString(body[idx:])
+			b.WriteRune(r)
+			idx += size
+			continue
+		}
+		idx++
+		if idx >= len(body) {
+			return "", errors.New("unterminated escape sequence")
+		}
+
+		r, size := utf8.DecodeRuneInString(body[idx:])
+		idx += size
+		switch r {
+		case 'b':
+			writeUnit('\b')
+		case 'f':
+			writeUnit('\f')
+		case 'n':
+			writeUnit('\n')
+		case 'r':
+			writeUnit('\r')
+		case 't':
+			writeUnit('\t')
+		case 'v':
+			writeUnit('\v')
+		case '\r':
+			// Line continuation; \<CR><LF> counts as a single terminator.
+			if idx < len(body) && body[idx] == '\n' {
+				idx++
+			}
+		case '\n', '\u2028', '\u2029':
+			// Line continuation.
+		case 'x':
+			if idx+2 > len(body) || !isHex(body[idx]) || !isHex(body[idx+1]) {
+				return "", errors.New("invalid hexadecimal escape sequence")
+			}
+			writeUnit(hexRune(body[idx : idx+2]))
+			idx += 2
+		case 'u':
+			cp, n, err := decodeUnicodeEscape(body[idx:])
+			if err != nil {
+				return "", err
+			}
+			writeUnit(cp)
+			idx += n
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			if r == '0' && (idx >= len(body) || !isDecimal(body[idx])) {
+				writeUnit(0)
+				break
+			}
+			if strict {
+				return "", errors.New("octal escape sequences are not allowed in strict mode")
+			}
+			// Legacy octal: up to three digits when the first is 0-3,
+			// otherwise up to two, never exceeding \377.
+			value := r - '0'
+			limit := 1
+			if r <= '3' {
+				limit = 2
+			}
+			for n := 0; n < limit && idx < len(body) && body[idx] >= '0' && body[idx] <= '7'; n++ {
+				value = value*8 + rune(body[idx]-'0')
+				idx++
+			}
+			writeUnit(value)
+		case '8', '9':
+			if strict {
+				return "", fmt.Errorf("\\%c escapes are not allowed in strict mode", r)
+			}
+			writeUnit(r)
+		default:
+			writeUnit(r)
+		}
+	}
+	flush()
+	return b.String(), nil
+}
+
+// decodeUnicodeEscape decodes the part of a \u escape following the u,
+// either four hex digits or a braced code point. It returns the code point
+// and the number of bytes consumed.
+func decodeUnicodeEscape(s string) (rune, int, error) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 {
+			return 0, 0, errors.New("invalid unicode escape sequence")
+		}
+		var cp rune
+		for idx := 1; idx < end; idx++ {
+			if !isHex(s[idx]) {
+				return 0, 0, errors.New("invalid unicode escape sequence")
+			}
+			cp = cp*16 + hexRune(s[idx:idx+1])
+			if cp > utf8.MaxRune {
+				return 0, 0, errors.New("undefined unicode code point")
+			}
+		}
+		return cp, end + 1, nil
+	}
+	if len(s) < 4 {
+		return 0, 0, errors.New("invalid unicode escape sequence")
+	}
+	for idx := 0; idx < 4; idx++ {
+		if !isHex(s[idx]) {
+			return 0, 0, errors.New("invalid unicode escape sequence")
+		}
+	}
+	return hexRune(s[:4]), 4, nil
+}
+
+func isHex(c byte) bool {
+	return isDecimal(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isDecimal(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// hexRune converts a run of validated hex digits to its value.
+func hexRune(digits string) rune {
+	var v rune
+	for idx := 0; idx < len(digits); idx++ {
+		c := digits[idx]
+		switch {
+		case c >= 'a':
+			v = v*16 + rune(c-'a'+10)
+		case c >= 'A':
+			v = v*16 + rune(c-'A'+10)
+		default:
+			v = v*16 + rune(c-'0')
+		}
+	}
+	return v
+}
@@ -1,28 +1,52 @@
 package test262
 
-import "strings"
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
 
-var asyncKeywords = []string{"async", "await", "async-functions"}
+// asyncFeatures are the Test262 features that need async functions or
+// promises jobs to run.
+var asyncFeatures = []string{"async-functions", "async-iteration", "top-level-await", "Symbol.asyncIterator"}
+
+// asyncWords mark a path segment as async related when they appear as one of
+// its hyphen-separated words, as in async-generator or for-await-of.
+var asyncWords = []string{"async", "await"}
 
 // IsAsyncRelated returns true when a test case should be excluded because it
-// targets async/await semantics that are intentionally unsupported.
+// targets async/await semantics that are intentionally unsupported. Cases
+// are matched by their features and the async flag; the path is consulted
+// only when the case carries neither features nor flags, as for paths read
+// from a list.
 func IsAsyncRelated(tc TestCase) bool {
-	lowerPath := strings.ToLower(tc.Path)
-	for _, keyword := range asyncKeywords {
-		if strings.Contains(lowerPath, keyword) {
+	for _, feature := range tc.Features {
+		if slices.Contains(asyncFeatures, feature) {
 			return true
 		}
 	}
+	if tc.HasFlag("async") {
+		return true
+	}
+	if len(tc.Features) > 0 || len(tc.Flags) > 0 {
+		return false
+	}
 
-	for _, flag := range tc.Flags {
-		if strings.Contains(strings.ToLower(flag), "async") {
-			return true
+	for _, segment := range strings.Split(filepath.ToSlash(tc.Path), "/") {
+		segment = strings.TrimSuffix(segment, ".js")
+		for _, word := range strings.FieldsFunc(strings.ToLower(segment), isWordSeparator) {
+			if slices.Contains(asyncWords, word) {
+				return true
+			}
 		}
 	}
-
 	return false
 }
 
+func isWordSeparator(r rune) bool {
+	return r == '-' || r == '_' || r == '.'
+}
+
 // FilterAsync removes async-related Test262 cases from the provided slice and
 // returns the filtered list.
 func FilterAsync(cases []TestCase) []TestCase {
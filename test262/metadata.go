@@ -0,0 +1,117 @@
+package test262
+
+import "strings"
+
+// Negative describes the error a negative test expects: the phase it occurs
+// in (parse, resolution or runtime) and the name of the error constructor.
+type Negative struct {
+	Phase string
+	Type  string
+}
+
+// parseMetadata fills in tc from the YAML frontmatter between /*--- and
+// ---*/ in src. Only the subset of YAML used by Test262 is understood:
+// scalars, block scalars, flow and block sequences, and the negative map.
+func parseMetadata(tc *TestCase, src string) {
+	start := strings.Index(src, "/*---")
+	if start < 0 {
+		return
+	}
+	body := src[start+len("/*---"):]
+	end := strings.Index(body, "---*/")
+	if end < 0 {
+		return
+	}
+	lines := strings.Split(body[:end], "\n")
+
+	for idx := 0; idx < len(lines); idx++ {
+		line := strings.TrimRight(lines[idx], " \t\r")
+		if line == "" || indentOf(line) > 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		// Collect the indented lines belonging to this key.
+		var nested []string
+		for idx+1 < len(lines) {
+			next := strings.TrimRight(lines[idx+1], " \t\r")
+			if next != "" && indentOf(next) == 0 {
+				break
+			}
+			nested = append(nested, next)
+			idx++
+		}
+
+		switch key {
+		case "description":
+			tc.Description = scalarValue(value, nested)
+		case "flags":
+			tc.Flags = listValue(value, nested)
+		case "features":
+			tc.Features = listValue(value, nested)
+		case "includes":
+			tc.Includes = listValue(value, nested)
+		case "negative":
+			neg := &Negative{}
+			for _, entry := range nested {
+				k, v, ok := strings.Cut(strings.TrimSpace(entry), ":")
+				if !ok {
+					continue
+				}
+				switch k {
+				case "phase":
+					neg.Phase = strings.TrimSpace(v)
+				case "type":
+					neg.Type = strings.TrimSpace(v)
+				}
+			}
+			tc.Negative = neg
+		}
+	}
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// scalarValue returns a plain scalar, or the text of a | or > block scalar
+// whose lines follow in nested.
+func scalarValue(value string, nested []string) string {
+	if !strings.HasPrefix(value, "|") && !strings.HasPrefix(value, ">") {
+		return strings.Trim(value, `"'`)
+	}
+	var parts []string
+	for _, line := range nested {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	if value[0] == '|' {
+		return strings.Join(parts, "\n")
+	}
+	return strings.Join(parts, " ")
+}
+
+// listValue returns the items of a flow sequence such as [a, b] or of a
+// block sequence whose "- item" lines follow in nested.
+func listValue(value string, nested []string) []string {
+	var items []string
+	if strings.HasPrefix(value, "[") {
+		inner := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+		for _, item := range strings.Split(inner, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	for _, line := range nested {
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "-"); ok {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	return items
+}
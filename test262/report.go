@@ -0,0 +1,106 @@
+package test262
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a single test case.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result records how a single test case fared.
+type Result struct {
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	// Error explains a failure; it is empty for other statuses.
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report aggregates the outcome of a single test run.
+type Report struct {
+	Total   int      `json:"total"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+	Results []Result `json:"results"`
+}
+
+func (rep *Report) add(result Result) {
+	rep.Total++
+	switch result.Status {
+	case StatusPass:
+		rep.Passed++
+	case StatusFail:
+		rep.Failed++
+	case StatusSkip:
+		rep.Skipped++
+	}
+	rep.Results = append(rep.Results, result)
+}
+
+// WriteReport writes rep to w in format, which is "json" or "tap". JSON
+// output holds the totals and every result; TAP output has one line per
+// case, followed for failures by the error as a diagnostic.
+func (rep *Report) WriteReport(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	case "tap":
+		return rep.writeTAP(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+func (rep *Report) writeTAP(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(rep.Results))
+	for idx, result := range rep.Results {
+		switch result.Status {
+		case StatusPass:
+			fmt.Fprintf(&b, "ok %d - %s\n", idx+1, result.Path)
+		case StatusSkip:
+			fmt.Fprintf(&b, "ok %d - %s # SKIP\n", idx+1, result.Path)
+		default:
+			fmt.Fprintf(&b, "not ok %d - %s\n", idx+1, result.Path)
+			for _, line := range strings.Split(result.Error, "\n") {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteReport writes rep in format to a report file in OutDir, named
+// report.json or report.tap, and returns the file's path.
+func (r *Runner) WriteReport(rep *Report, format string) (string, error) {
+	path := filepath.Join(r.OutDir, "report."+format)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("create report: %w", err)
+	}
+	if err := rep.WriteReport(f, format); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("write report: %w", err)
+	}
+	return path, nil
+}
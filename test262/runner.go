@@ -1,10 +1,18 @@
 package test262
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"es6-interpreter/parser"
+	"es6-interpreter/vm"
 )
 
 // Runner coordinates discovery and execution of Test262 compliance tests.
@@ -15,23 +23,43 @@ type Runner struct {
 	OutDir string
 	// SkipAsync controls whether async/await tests are excluded.
 	SkipAsync bool
+	// Concurrency is the number of cases run at once. Values below one run
+	// the cases one after another.
+	Concurrency int
+	// Timeout bounds the running time of each case, so that a case that
+	// never finishes fails instead of stalling its worker. Zero means
+	// DefaultTimeout and a negative value disables the limit.
+	Timeout time.Duration
 }
 
+// DefaultTimeout is the per-case time limit used when Runner.Timeout is zero.
+const DefaultTimeout = 10 * time.Second
+
+// errTimedOut reports a case stopped by the per-case time limit.
+var errTimedOut = errors.New("timed out")
+
 // TestCase describes a single Test262 test file.
 type TestCase struct {
 	Path        string
 	Description string
 	Flags       []string
+	// Features lists the language features the test exercises.
+	Features []string
+	// Includes names the harness files the test needs beyond the defaults.
+	Includes []string
+	// Negative is set when the test passes by raising an error.
+	Negative *Negative
 }
 
-// Report aggregates the outcome of a single test run.
-type Report struct {
-	Total   int
-	Passed  int
-	Failed  int
-	Skipped int
+// HasFlag reports whether the test's metadata lists flag.
+func (tc TestCase) HasFlag(flag string) bool {
+	return slices.Contains(tc.Flags, flag)
 }
 
+// defaultIncludes are the harness files prepended to every test that is not
+// marked raw.
+var defaultIncludes = []string{"assert.js", "sta.js"}
+
 // NewRunner validates the file system layout and returns a configured Runner.
 func NewRunner(rootDir, outDir string) (*Runner, error) {
 	if rootDir == "" {
@@ -61,10 +89,259 @@ func (r *Runner) Discover() ([]TestCase, error) {
 	return nil, errors.New("test discovery not implemented yet")
 }
 
-// Run executes the provided test cases and returns a summarized report.
+// Run executes the provided test cases and returns a summarized report. Case
+// paths are relative to RootDir. Each case is read from disk and its
+// metadata parsed, then it runs once in each mode its flags allow, with the
+// harness prepended. Async tests are skipped when SkipAsync is set, and a
+// case that cannot be read or runs past Timeout counts as failed. The error
+// reports a missing harness file.
+//
+// Cases are spread over Concurrency workers, each running one case at a time
+// in fresh interpreters. Results are reported sorted by path. Once a worker
+// hits an error, no further cases are started and those running are stopped.
 func (r *Runner) Run(cases []TestCase) (*Report, error) {
-	if len(cases) == 0 {
-		return &Report{}, nil
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := make([]Result, len(cases))
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for range max(r.Concurrency, 1) {
+		wg.Go(func() {
+			// Each worker caches harness files of its own.
+			harness := make(map[string]string)
+			for idx := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				result, err := r.runOne(ctx, cases[idx], harness)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					continue
+				}
+				results[idx] = result
+			}
+		})
+	}
+feed:
+	for idx := range cases {
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	slices.SortStableFunc(results, func(a, b Result) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	report := &Report{}
+	for _, result := range results {
+		report.add(result)
+	}
+	return report, nil
+}
+
+// runOne reads, runs and times a single case. A case still running when
+// the time limit passes fails, and one still running when ctx is done stops.
+func (r *Runner) runOne(ctx context.Context, tc TestCase, harness map[string]string) (result Result, err error) {
+	started := time.Now()
+	defer func() { result.Duration = time.Since(started) }()
+	result = Result{Path: tc.Path, Status: StatusPass}
+	src, err := os.ReadFile(r.path(tc.Path))
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+		return result, nil
+	}
+	parseMetadata(&tc, string(src))
+	if r.SkipAsync && IsAsyncRelated(tc) {
+		result.Status = StatusSkip
+		return result, nil
+	}
+
+	prelude, err := r.prelude(tc, harness)
+	if err != nil {
+		return Result{}, err
+	}
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := runCase(ctx, tc, prelude, string(src), filepath.Dir(r.path(tc.Path))); err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+		if errors.Is(err, errTimedOut) {
+			result.Error = fmt.Sprintf("timed out after %s", timeout)
+		}
+	}
+	return result, nil
+}
+
+func (r *Runner) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(r.RootDir, name)
+}
+
+// prelude returns the harness source to prepend to tc, reading harness files
+// through cache.
+func (r *Runner) prelude(tc TestCase, cache map[string]string) (string, error) {
+	if tc.HasFlag("raw") {
+		return "", nil
+	}
+	var b strings.Builder
+	for _, name := range slices.Concat(defaultIncludes, tc.Includes) {
+		src, ok := cache[name]
+		if !ok {
+			data, err := os.ReadFile(filepath.Join(r.RootDir, "harness", name))
+			if err != nil {
+				return "", fmt.Errorf("read harness file: %w", err)
+			}
+			src = string(data)
+			cache[name] = src
+		}
+		b.WriteString(src)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// runCase runs tc in sloppy mode, strict mode or both, as its flags direct,
+// and returns the first way in which it failed. Module imports resolve
+// against dir. Once ctx is done the case stops with errTimedOut.
+func runCase(ctx context.Context, tc TestCase, prelude, src, dir string) error {
+	module := tc.HasFlag("module")
+	var modes []bool // whether to run in strict mode
+	switch {
+	case module || tc.HasFlag("raw") || tc.HasFlag("noStrict"):
+		modes = []bool{false}
+	case tc.HasFlag("onlyStrict"):
+		modes = []bool{true}
+	default:
+		modes = []bool{false, true}
+	}
+
+	for _, strict := range modes {
+		source := prelude + src
+		if strict {
+			source = "\"use strict\";\n" + source
+		}
+		phase, err := execute(ctx, source, module, dir)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errTimedOut
+		}
+		if err := classify(tc.Negative, phase, err); err != nil {
+			if strict {
+				return fmt.Errorf("strict mode: %w", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// execute parses and runs source in a fresh sandboxed interpreter. It reports
+// the phase, parse or runtime, in which an error occurred. Modules import
+// their dependencies from files in dir. Running stops once ctx is done.
+func execute(ctx context.Context, source string, module bool, dir string) (string, error) {
+	p := parser.New(source)
+	intr := vm.NewSandboxInterpreter()
+	defer intr.Close()
+	if module {
+		program, err := p.ParseModule()
+		if err != nil {
+			return "parse", err
+		}
+		intr.SetModuleLoader(func(specifier string) (string, error) {
+			data, err := os.ReadFile(filepath.Join(dir, specifier))
+			return string(data), err
+		})
+		_, err = intr.ExecuteModuleContext(ctx, program)
+		return "runtime", err
+	}
+	program, err := p.ParseProgram()
+	if err != nil {
+		return "parse", err
+	}
+	_, err = intr.ExecuteContext(ctx, program)
+	return "runtime", err
+}
+
+// classify checks the outcome of one run against the expectation of a test,
+// returning nil when the test passed.
+func classify(negative *Negative, phase string, err error) error {
+	if negative == nil {
+		if err != nil {
+			return fmt.Errorf("%s error: %s", phase, describeError(phase, err))
+		}
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("expected %s %s, but no error occurred", negative.Phase, negative.Type)
+	}
+	want := negative.Phase
+	if want != "parse" {
+		// Resolution errors surface while running the module graph.
+		want = "runtime"
+	}
+	if phase != want {
+		return fmt.Errorf("expected %s %s, got %s error: %s", negative.Phase, negative.Type, phase, describeError(phase, err))
+	}
+	if name := errorName(phase, err); name != negative.Type {
+		return fmt.Errorf("expected %s, got %s", negative.Type, describeError(phase, err))
+	}
+	return nil
+}
+
+// errorName returns the name of the error constructor behind err. Parse
+// errors are SyntaxErrors. Thrown objects are named by their constructor, and
+// runtime errors and thrown strings by their "Name:" prefix.
+func errorName(phase string, err error) string {
+	if phase == "parse" {
+		return "SyntaxError"
+	}
+	msg := err.Error()
+	var exc *vm.Exception
+	if errors.As(err, &exc) {
+		v := exc.Value
+		if v.Kind() == vm.ObjectKind {
+			if ctor := v.Object().Get("constructor"); ctor.Kind() == vm.ObjectKind {
+				return vm.ToString(ctor.Object().Get("name")).StringValue()
+			}
+			return ""
+		}
+		msg = vm.ToString(v).StringValue()
+	}
+	name, _, _ := strings.Cut(msg, ":")
+	return name
+}
+
+// describeError renders err for a report. Thrown objects, which would
+// otherwise read as [object Object], are shown by constructor name and
+// message.
+func describeError(phase string, err error) string {
+	var exc *vm.Exception
+	if !errors.As(err, &exc) || exc.Value.Kind() != vm.ObjectKind {
+		return err.Error()
 	}
-	return nil, errors.New("test execution not implemented yet")
+	msg := vm.ToString(exc.Value.Object().Get("message")).StringValue()
+	return "Uncaught " + errorName(phase, err) + ": " + msg
 }
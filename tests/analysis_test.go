@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"es6-interpreter/analysis"
+	"es6-interpreter/ast"
+)
+
+func TestFreeVariablesClosingOverOuterVariable(t *testing.T) {
+	prog := parseProgram(t, `
+let counter = 0;
+function increment(step) {
+  let next = counter + step;
+  counter = next;
+  return next;
+}
+`)
+	fn, ok := prog.Body[1].(*ast.FunctionDeclaration)
+	if !ok {
+		t.Fatalf("expected FunctionDeclaration, got %T", prog.Body[1])
+	}
+	got := analysis.FreeVariables(fn)
+	if want := []string{"counter"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("free variables mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestFreeVariablesNoneForSelfContainedFunction(t *testing.T) {
+	prog := parseProgram(t, `
+function sum(a, b = a) {
+  var total = a + b;
+  if (total > 10) {
+    const capped = 10;
+    return capped;
+  }
+  return total;
+}
+`)
+	fn := prog.Body[0].(*ast.FunctionDeclaration)
+	if got := analysis.FreeVariables(fn); len(got) != 0 {
+		t.Fatalf("expected no free variables, got %v", got)
+	}
+}
+
+func TestFreeVariablesUnionWithNestedFunctions(t *testing.T) {
+	prog := parseProgram(t, `
+const outer = (x) => {
+  function helper(y) {
+    return x + y + scale;
+  }
+  const inner = (z) => helper(z) + offset.value + x;
+  return inner(x);
+};
+`)
+	decl := prog.Body[0].(*ast.VariableDeclaration)
+	arrow, ok := decl.Declarations[0].Init.(*ast.ArrowFunctionExpression)
+	if !ok {
+		t.Fatalf("expected ArrowFunctionExpression, got %T", decl.Declarations[0].Init)
+	}
+	got := analysis.FreeVariables(arrow)
+	if want := []string{"scale", "offset"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("free variables mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestFlatScopeListsLocalsOfClosureFreeFunction(t *testing.T) {
+	prog := parseProgram(t, `
+function sum(a, b = 1, ...rest) {
+  let total = a + b;
+  for (var i = 0; i < rest.length; i = i + 1) {
+    const item = rest[i];
+    total = total + item;
+  }
+  var total2 = total, a;
+  return total2;
+}
+`)
+	fn := prog.Body[0].(*ast.FunctionDeclaration)
+	got, ok := analysis.FlatScope(fn)
+	if !ok {
+		t.Fatalf("expected closure-free function to be flat")
+	}
+	if want := []string{"a", "b", "rest", "total", "i", "total2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("locals mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestFlatScopeRejectsFunctionsCreatingClosures(t *testing.T) {
+	prog := parseProgram(t, `
+function nested() { function inner() {} }
+function arrow() { return [1].map((x) => x); }
+function expr() { const f = function() {}; }
+function klass() { class K {} }
+`)
+	for _, stmt := range prog.Body {
+		fn := stmt.(*ast.FunctionDeclaration)
+		if _, ok := analysis.FlatScope(fn); ok {
+			t.Fatalf("%s: expected function creating closures not to be flat", fn.ID.Name)
+		}
+	}
+}
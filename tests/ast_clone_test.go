@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"testing"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+func TestCloneCopiesTheWholeTree(t *testing.T) {
+	original, err := parser.New("let total = 0;\nfor (const n of [1, 2]) { total = total + n; }").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	before := ast.Print(original)
+
+	clone, ok := ast.Clone(original).(*ast.Program)
+	if !ok {
+		t.Fatalf("expected a *ast.Program clone")
+	}
+	if clone == original {
+		t.Fatalf("expected a distinct program")
+	}
+	if !ast.Equal(original, clone) {
+		t.Fatalf("expected the clone to equal the original")
+	}
+	if clone.Body[1].Loc() != original.Body[1].Loc() {
+		t.Fatalf("expected locations to be copied, got %v and %v", clone.Body[1].Loc(), original.Body[1].Loc())
+	}
+
+	decl := clone.Body[0].(*ast.VariableDeclaration)
+	decl.Declarations[0].ID.(*ast.Identifier).Name = "sum"
+
+	if ast.Equal(original, clone) {
+		t.Fatalf("expected Equal to distinguish the modified clone")
+	}
+	if after := ast.Print(original); after != before {
+		t.Fatalf("modifying the clone changed the original:\n%s", after)
+	}
+}
+
+func TestCloneNil(t *testing.T) {
+	if ast.Clone(nil) != nil {
+		t.Fatalf("expected nil clone of nil")
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+func TestMarshalJSONMatchesESTreeFixture(t *testing.T) {
+	program, err := parser.New("const sum = (a, b = 2) => a + b;\nsum(1);").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	got, err := ast.MarshalJSON(program)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	fixture, err := os.ReadFile("testdata/arrow_sum.estree.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var want bytes.Buffer
+	if err := json.Compact(&want, fixture); err != nil {
+		t.Fatalf("compact fixture: %v", err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Fatalf("ESTree output differs from fixture:\ngot:  %s\nwant: %s", got, want.Bytes())
+	}
+}
+
+func TestMarshalJSONLiteralsAndPatterns(t *testing.T) {
+	program, err := parser.New("let [x, ...rest] = [0x10, null, /a+/g, `t${x}`, 10n];").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	got, err := ast.MarshalJSON(program)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	for _, want := range []string{
+		`"type":"ArrayPattern"`,
+		`"type":"RestElement"`,
+		`"value":16,"raw":"0x10"`,
+		`"value":null,"raw":"null"`,
+		`"raw":"/a+/g","regex":{"pattern":"a+","flags":"g"}`,
+		`"type":"TemplateElement"`,
+		`"raw":"10n","bigint":"10"`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Fatalf("expected %s in output, got %s", want, got)
+		}
+	}
+}
+
+func TestMarshalJSONDirectives(t *testing.T) {
+	program, err := parser.New(`'use\x20strict';
+function f() { "use strict"; 'a'; }
+"not a directive" + 1;`).ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	got, err := ast.MarshalJSON(program)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	for _, want := range []string{
+		`"directive":"use\\x20strict"`,
+		`"directive":"use strict"`,
+		`"directive":"a"`,
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Fatalf("expected %s in output, got %s", want, got)
+		}
+	}
+	if n := strings.Count(string(got), `"directive"`); n != 3 {
+		t.Fatalf("expected 3 directive fields, got %d in %s", n, got)
+	}
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"testing"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+func TestPrintRoundTrips(t *testing.T) {
+	sources := []string{
+		`"use strict";
+var a = 1, b = [1, , 2, ...rest], c = { x, y: 2, [k]: 3, "s": 4, 5: 6, ...o, get g() { return 1; }, set g(v) {}, m(a, b = 2) {} };
+let [p, , q = 1, ...r] = a;
+let { s, t: u = 2, [k]: w, ...z } = b;
+const f = function named(x) { return x; }, g = (x) => x * 2, h = () => ({ a: 1 }), i = (a, b) => { return a; };
+function decl(a, { b }, [c] = []) {
+  if (a) b(); else if (c) { d(); } else e();
+  if (a) { if (b) c(); } else d();
+  for (let i = 0; i < 10; i++) continue;
+  for (var k in o) break;
+  for (const v of list) {}
+  for (;;) {}
+  for (var x = ("a" in o); x; ) {}
+  while (a) a--;
+  do { a++; } while (a < 10);
+  outer: for (;;) { break outer; }
+  switch (a) { case 1: b(); break; default: c(); }
+  try { a(); } catch (e) { b(e); } finally { c(); }
+  try { a(); } catch { b(); }
+  throw new Error("x\n\"y\"");
+  debugger;
+  return;
+}
+class A extends B { constructor() { super(); } static s() {} get x() { return this.y; } set x(v) {} ["c" + 1]() {} static { init(); } }
+const C = class {};
+`,
+		`with (o) {}
+(a + b) * c;
+a - (b - c);
+a - b - c;
+-(-x);
+- -x;
++ +x;
+!(a && b);
+typeof (a + b);
+(a, b);
+x = y = z;
+(x = 1) + 2;
+a ? b : c ? d : e;
+(a ? b : c) ? d : e;
+a || b && c;
+(a || b) && c;
+a ?? (b || c);
+(a ?? b) || c;
+a?.b.c;
+(a?.b).c;
+a?.[0]?.(1);
+new (f())();
+new a.b.C(1, 2);
+new (a().b)();
+(1).toString();
+1.5.toFixed();
+(function () {})();
+(() => 1)();
+({}).x;
+f(...args, -1);
+tag` + "`a${b}c`" + `;
+` + "`x${y}`" + `;
+x instanceof Y in z;
+/re+/gi.test(s);
+10n + 1_000;
+new.target;
+++a.b;
+a[b, c];
+function* gen() { yield; yield a, b; const x = yield* (c, d); ({ *m() {} }); }
+`,
+		`'use\x20strict';
+with (o) {}
+function f() { 'use strict'; "x\ty"; return 1; }
+const g = () => { 'a'; }, o = { m() { 'use\x20strict'; } };
+`,
+	}
+	for _, src := range sources {
+		original, err := parser.New(src).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse source: %v", err)
+		}
+		printed := ast.Print(original)
+		reparsed, err := parser.New(printed).ParseProgram()
+		if err != nil {
+			t.Fatalf("reparse printed source: %v\n%s", err, printed)
+		}
+		if !ast.Equal(original, reparsed) {
+			t.Fatalf("printed program does not round-trip:\n%s", printed)
+		}
+		if again := ast.Print(reparsed); again != printed {
+			t.Fatalf("printing is not stable:\n%s\nthen:\n%s", printed, again)
+		}
+	}
+}
+
+func TestPrintModuleRoundTrips(t *testing.T) {
+	src := `import "side";
+import def, { a, b as c, default as d } from "m";
+import * as ns from "n";
+export var x = 1, y;
+export function f() {}
+export { x as z, y as w };
+export { a as default2 } from "m";
+export * from "n";
+export * as all from "n";
+export default (1, 2);
+`
+	original, err := parser.New(src).ParseModule()
+	if err != nil {
+		t.Fatalf("parse source: %v", err)
+	}
+	printed := ast.Print(original)
+	reparsed, err := parser.New(printed).ParseModule()
+	if err != nil {
+		t.Fatalf("reparse printed source: %v\n%s", err, printed)
+	}
+	if !ast.Equal(original, reparsed) {
+		t.Fatalf("printed module does not round-trip:\n%s", printed)
+	}
+}
+
+func TestPrintParenthesizesOnlyWherePrecedenceRequires(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"((a + b)) * c;", "(a + b) * c;\n"},
+		{"a + (b * c);", "a + b * c;\n"},
+		{"a - (b - c);", "a - (b - c);\n"},
+		{"(a - b) - c;", "a - b - c;\n"},
+		{"x = (y = z);", "x = y = z;\n"},
+		{"a ** (b ** c);", "a ** b ** c;\n"},
+		{"(a ** b) ** c;", "(a ** b) ** c;\n"},
+		{"(-a) ** b;", "(-a) ** b;\n"},
+		{"(a, b) ? c : d;", "(a, b) ? c : d;\n"},
+		{"(() => {}).call();", "(() => {}).call();\n"},
+		{"({ a: 1 }).a;", "({ a: 1 }.a);\n"},
+		{"if (a) { b(); }", "if (a) {\n  b();\n}\n"},
+	}
+	for _, tt := range tests {
+		program, err := parser.New(tt.src).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.src, err)
+		}
+		if got := ast.Print(program); got != tt.want {
+			t.Fatalf("Print(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEqualIgnoresLocations(t *testing.T) {
+	a, err := parser.New("f(x + 1);").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	b, err := parser.New("f( x+1 )\n;").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	c, err := parser.New("f(x + 2);").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if !ast.Equal(a, b) {
+		t.Fatalf("expected programs differing only in layout to be equal")
+	}
+	if ast.Equal(a, c) {
+		t.Fatalf("expected programs with different literals to differ")
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+type visitFunc func(ast.Node) error
+
+func (f visitFunc) Visit(n ast.Node) error { return f(n) }
+
+func TestWalkVisitsEveryNodeInSourceOrder(t *testing.T) {
+	program, err := parser.New("let x = a + 1;\nif (x) { f(x, `t${x}`); }").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	var kinds []string
+	err = ast.Walk(visitFunc(func(n ast.Node) error {
+		kinds = append(kinds, string(n.Kind()))
+		return nil
+	}), program)
+	if err != nil {
+		t.Fatalf("unexpected walk error: %v", err)
+	}
+
+	want := []string{
+		"Program",
+		"VariableDeclaration", "VariableDeclarator", "Identifier",
+		"BinaryExpression", "Identifier", "NumberLiteral",
+		"IfStatement", "Identifier", "BlockStatement", "ExpressionStatement",
+		"CallExpression", "Identifier", "Identifier",
+		"TemplateLiteral", "TemplateElement", "Identifier", "TemplateElement",
+	}
+	if strings.Join(kinds, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected traversal (%d nodes):\ngot:  %v\nwant: %v", len(kinds), kinds, want)
+	}
+}
+
+func TestWalkStopsOnVisitorError(t *testing.T) {
+	program, err := parser.New("a; b; c;").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	stop := errors.New("stop")
+	var names []string
+	err = ast.Walk(visitFunc(func(n ast.Node) error {
+		if id, ok := n.(*ast.Identifier); ok {
+			names = append(names, id.Name)
+			if id.Name == "b" {
+				return stop
+			}
+		}
+		return nil
+	}), program)
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the visitor error, got %v", err)
+	}
+	if strings.Join(names, ",") != "a,b" {
+		t.Fatalf("expected traversal to stop after b, got %v", names)
+	}
+}
+
+func TestDebugStringUsesChildren(t *testing.T) {
+	program, err := parser.New("f(1);").ParseProgram()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	got := ast.DebugString(program)
+	want := "Program 1:1-1:5\n" +
+		"  ExpressionStatement 1:1-1:5\n" +
+		"    CallExpression 1:1-1:5\n" +
+		"      Identifier 1:1-1:2\n" +
+		"      NumberLiteral 1:3-1:4\n"
+	if got != want {
+		t.Fatalf("unexpected debug string:\n%s", got)
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"strings"
 	"testing"
 
 	"es6-interpreter/lexer"
@@ -137,6 +138,90 @@ func TestLineTerminatorHandling(t *testing.T) {
 	assertTokens(t, got, want)
 }
 
+func TestLexerNewlineBefore(t *testing.T) {
+	l := lexer.New("a b\nc /* x\n */ d // e\nf\u2028g")
+	want := map[string]bool{"a": false, "b": false, "c": true, "d": true, "f": true, "g": true}
+	for tok := l.NextToken(); tok.Type != lexer.EOF; tok = l.NextToken() {
+		if tok.NewlineBefore != want[tok.Literal] {
+			t.Fatalf("token %q: expected NewlineBefore %v, got %v", tok.Literal, want[tok.Literal], tok.NewlineBefore)
+		}
+	}
+}
+
+func TestLexerBigIntWithSeparators(t *testing.T) {
+	source := "1_000n 1_000_000n 0x1Fn 42"
+	l := lexer.New(source)
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.BigInt, "1_000n"},
+		{lexer.BigInt, "1_000_000n"},
+		{lexer.BigInt, "0x1Fn"},
+		{lexer.Number, "42"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
+
+func TestLexerMalformedBigIntProducesIllegal(t *testing.T) {
+	for _, source := range []string{"1_n", "1._000n", "1__0n", "1.5n", "1e3n", "012n"} {
+		tokens := collectTokens(t, lexer.New(source))
+		last := tokens[len(tokens)-1]
+		if last.Type != lexer.Illegal {
+			t.Fatalf("%s: expected ILLEGAL token, got %s", source, last.Type)
+		}
+	}
+}
+
+func TestLexerNumericSeparators(t *testing.T) {
+	source := "1_000_000 1_000.000_1 1e1_0 0xFF_FF 0o7_7 0b1010_0101 _1"
+	l := lexer.New(source)
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.Number, "1_000_000"},
+		{lexer.Number, "1_000.000_1"},
+		{lexer.Number, "1e1_0"},
+		{lexer.Number, "0xFF_FF"},
+		{lexer.Number, "0o7_7"},
+		{lexer.Number, "0b1010_0101"},
+		{lexer.Identifier, "_1"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
+
+func TestLexerMalformedSeparatorsProduceIllegal(t *testing.T) {
+	for _, source := range []string{"1_", "1__2", "1_.5", "1._5", "1e_5", "0x_FF", "0xFF_", "0o7__7", "0b_1", "0_1"} {
+		tokens := collectTokens(t, lexer.New(source))
+		last := tokens[len(tokens)-1]
+		if last.Type != lexer.Illegal {
+			t.Fatalf("%s: expected ILLEGAL token, got %s", source, last.Type)
+		}
+	}
+}
+
+func TestLexerIdentifierEscapes(t *testing.T) {
+	source := "\\u0061 \\u0061bc a\\u{62}c \\u{24}x"
+	l := lexer.New(source)
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.Identifier, "a"},
+		{lexer.Identifier, "abc"},
+		{lexer.Identifier, "abc"},
+		{lexer.Identifier, "$x"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
+
+func TestLexerInvalidIdentifierEscapesProduceIllegal(t *testing.T) {
+	for _, source := range []string{"\\u0020", "a\\u0020", "\\u0031a", "\\u00", "\\u{}", "\\x61", "\\u0069f"} {
+		tokens := collectTokens(t, lexer.New(source))
+		if tokens[0].Type != lexer.Illegal {
+			t.Fatalf("%q: expected ILLEGAL token, got %s", source, tokens[0].Type)
+		}
+	}
+}
+
 func TestUnterminatedStringProducesIllegal(t *testing.T) {
 	source := "\"unterminated"
 	l := lexer.New(source)
@@ -147,6 +232,36 @@ func TestUnterminatedStringProducesIllegal(t *testing.T) {
 	}
 }
 
+func TestTokenize(t *testing.T) {
+	tokens, err := lexer.Tokenize("let total = add(1, 2);\n// done\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertTokens(t, tokens, []tokenExpectation{
+		{lexer.KeywordLet, "let"},
+		{lexer.Identifier, "total"},
+		{lexer.Assign, "="},
+		{lexer.Identifier, "add"},
+		{lexer.LParen, "("},
+		{lexer.Number, "1"},
+		{lexer.Comma, ","},
+		{lexer.Number, "2"},
+		{lexer.RParen, ")"},
+		{lexer.Semicolon, ";"},
+	})
+
+	tokens, err = lexer.Tokenize("x = \"unterminated")
+	if err == nil {
+		t.Fatalf("expected error for unterminated string")
+	}
+	if !strings.Contains(err.Error(), "unterminated string literal") || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("expected the 2 tokens before the error, got %d", len(tokens))
+	}
+}
+
 func TestUnterminatedCommentProducesIllegal(t *testing.T) {
 	source := "/* comment"
 	l := lexer.New(source)
@@ -189,3 +304,130 @@ func TestIllegalCharacter(t *testing.T) {
 		t.Fatalf("expected ILLEGAL token for #, got %s", last.Type)
 	}
 }
+
+func TestLexerNullishCoalescing(t *testing.T) {
+	source := "a ?? b ? c : d"
+	l := lexer.New(source)
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.Identifier, "a"},
+		{lexer.NullishCoalescing, "??"},
+		{lexer.Identifier, "b"},
+		{lexer.Question, "?"},
+		{lexer.Identifier, "c"},
+		{lexer.Colon, ":"},
+		{lexer.Identifier, "d"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
+
+func TestLexerOptionalChain(t *testing.T) {
+	source := "a?.b?.[c] ok?.5:1"
+	l := lexer.New(source)
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.Identifier, "a"},
+		{lexer.OptionalChain, "?."},
+		{lexer.Identifier, "b"},
+		{lexer.OptionalChain, "?."},
+		{lexer.LBracket, "["},
+		{lexer.Identifier, "c"},
+		{lexer.RBracket, "]"},
+		{lexer.Identifier, "ok"},
+		{lexer.Question, "?"},
+		{lexer.Number, ".5"},
+		{lexer.Colon, ":"},
+		{lexer.Number, "1"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
+
+func TestLexerColumnsCountUTF16Units(t *testing.T) {
+	source := "'\U0001F600' + x"
+	l := lexer.New(source)
+	tokens := collectTokens(t, l)
+	want := []struct {
+		literal string
+		column  int
+		offset  int
+	}{
+		{"'\U0001F600'", 0, 0},
+		{"+", 5, 7},
+		{"x", 7, 9},
+	}
+	for idx, w := range want {
+		tok := tokens[idx]
+		if tok.Literal != w.literal || tok.Start.Column != w.column || tok.Start.Offset != w.offset {
+			t.Fatalf("token %d: expected %q at column %d offset %d, got %q at column %d offset %d",
+				idx, w.literal, w.column, w.offset, tok.Literal, tok.Start.Column, tok.Start.Offset)
+		}
+	}
+	if end := tokens[0].End.Column; end != 4 {
+		t.Fatalf("expected string literal to end at column 4, got %d", end)
+	}
+}
+
+func TestLexerUnicodeLineSeparators(t *testing.T) {
+	l := lexer.New("a\u2028b\u2029c // note\u2028d '\u2028'")
+	want := []struct {
+		literal string
+		line    int
+	}{
+		{"a", 1}, {"b", 2}, {"c", 3}, {"d", 4}, {"'\u2028'", 4},
+	}
+	for _, w := range want {
+		tok := l.NextToken()
+		if tok.Literal != w.literal || tok.Start.Line != w.line {
+			t.Fatalf("expected %q on line %d, got %q on line %d", w.literal, w.line, tok.Literal, tok.Start.Line)
+		}
+	}
+	if tok := l.NextToken(); tok.Type != lexer.EOF || tok.Start.Line != 5 {
+		t.Fatalf("expected EOF on line 5, got %s on line %d", tok.Type, tok.Start.Line)
+	}
+}
+
+func TestLexerUnicodeWhitespace(t *testing.T) {
+	l := lexer.New("\uFEFFlet\u2003x =\u30001;")
+	got := collectTokens(t, l)
+	want := []tokenExpectation{
+		{lexer.KeywordLet, "let"},
+		{lexer.Identifier, "x"},
+		{lexer.Assign, "="},
+		{lexer.Number, "1"},
+		{lexer.Semicolon, ";"},
+		{lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+	if got[1].NewlineBefore {
+		t.Fatalf("expected an em space not to count as a line break")
+	}
+}
+
+func TestLexerHashbang(t *testing.T) {
+	got := collectTokens(t, lexer.New("#!/usr/bin/env node\nx"))
+	assertTokens(t, got, []tokenExpectation{{lexer.Identifier, "x"}, {lexer.EOF, ""}})
+	if got[0].Start.Line != 2 {
+		t.Fatalf("expected x on line 2, got line %d", got[0].Start.Line)
+	}
+
+	got = collectTokens(t, lexer.New("x\n#!not a comment"))
+	if last := got[len(got)-1]; last.Type != lexer.Illegal {
+		t.Fatalf("expected a later #! to stay illegal, got %s", last.Type)
+	}
+}
+
+func TestLexerLogicalAssignmentOperators(t *testing.T) {
+	got := collectTokens(t, lexer.New("a &&= b ||= c ??= d && e || f ?? g"))
+	want := []tokenExpectation{
+		{lexer.Identifier, "a"}, {lexer.LogicalAndAssign, "&&="},
+		{lexer.Identifier, "b"}, {lexer.LogicalOrAssign, "||="},
+		{lexer.Identifier, "c"}, {lexer.NullishAssign, "??="},
+		{lexer.Identifier, "d"}, {lexer.LogicalAnd, "&&"},
+		{lexer.Identifier, "e"}, {lexer.LogicalOr, "||"},
+		{lexer.Identifier, "f"}, {lexer.NullishCoalescing, "??"},
+		{lexer.Identifier, "g"}, {lexer.EOF, ""},
+	}
+	assertTokens(t, got, want)
+}
@@ -1,9 +1,14 @@
 package tests
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"es6-interpreter/ast"
+	"es6-interpreter/lexer"
 	"es6-interpreter/parser"
 )
 
@@ -17,6 +22,16 @@ func parseProgram(t *testing.T, src string) *ast.Program {
 	return program
 }
 
+func parseProgramExpectError(t *testing.T, src string) error {
+	t.Helper()
+	p := parser.New(src)
+	_, err := p.ParseProgram()
+	if err == nil {
+		t.Fatalf("expected parse error for %q", src)
+	}
+	return err
+}
+
 func TestParseExpressionStatement(t *testing.T) {
 	prog := parseProgram(t, "1 + 2 * 3;")
 
@@ -160,6 +175,60 @@ func TestParseNewExpressionMemberAccess(t *testing.T) {
 	}
 }
 
+func TestParseNewExpressionCallees(t *testing.T) {
+	// shape renders the nesting of new, call and member nodes, with the
+	// argument count of new and call expressions.
+	var shape func(ast.Expression) string
+	shape = func(expr ast.Expression) string {
+		switch e := expr.(type) {
+		case *ast.Identifier:
+			return e.Name
+		case *ast.MemberExpression:
+			if e.Computed {
+				return shape(e.Object) + "[" + shape(e.Property) + "]"
+			}
+			return shape(e.Object) + "." + shape(e.Property)
+		case *ast.CallExpression:
+			return fmt.Sprintf("call(%s)/%d", shape(e.Callee), len(e.Arguments))
+		case *ast.NewExpression:
+			return fmt.Sprintf("new(%s)/%d", shape(e.Callee), len(e.Arguments))
+		default:
+			return fmt.Sprintf("%T", expr)
+		}
+	}
+
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"new a.b.c();", "new(a.b.c)/0"},
+		{"new a.b.c;", "new(a.b.c)/0"},
+		{"new Foo[k](1);", "new(Foo[k])/1"},
+		{"new (f())();", "new(call(f)/0)/0"},
+		{"new (getCtor())(2);", "new(call(getCtor)/0)/1"},
+		{"new (a.b());", "new(call(a.b)/0)/0"},
+		{"new a.b().c;", "new(a.b)/0.c"},
+		{"new a.b(1)[k](2);", "call(new(a.b)/1[k])/1"},
+		{"new a()();", "call(new(a)/0)/0"},
+		{"new new A()(1);", "new(new(A)/0)/1"},
+		{"new new A;", "new(new(A)/0)/0"},
+	}
+	for _, tt := range tests {
+		prog := parseProgram(t, tt.src)
+		stmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("%q: expected ExpressionStatement, got %T", tt.src, prog.Body[0])
+		}
+		if got := shape(stmt.Expression); got != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.src, tt.want, got)
+		}
+	}
+
+	for _, src := range []string{"new a?.b();", "new;", "new )"} {
+		parseProgramExpectError(t, src)
+	}
+}
+
 func TestParseTemplateLiteralSimple(t *testing.T) {
 	prog := parseProgram(t, "`hello`; ")
 
@@ -585,6 +654,27 @@ func TestParseObjectDestructuring(t *testing.T) {
 	}
 }
 
+func TestParseObjectPatternLiteralAndComputedKeys(t *testing.T) {
+	prog := parseProgram(t, `const {"quoted": q, 0: zero, [key]: dynamic} = obj;`)
+
+	objPat := prog.Body[0].(*ast.VariableDeclaration).Declarations[0].ID.(*ast.ObjectPattern)
+	if len(objPat.Properties) != 3 {
+		t.Fatalf("expected 3 properties, got %d", len(objPat.Properties))
+	}
+	if key, ok := objPat.Properties[0].Key.(*ast.StringLiteral); !ok || key.Value != "quoted" {
+		t.Fatalf("unexpected string key: %#v", objPat.Properties[0].Key)
+	}
+	if key, ok := objPat.Properties[1].Key.(*ast.NumberLiteral); !ok || key.Value != "0" {
+		t.Fatalf("unexpected number key: %#v", objPat.Properties[1].Key)
+	}
+	computed := objPat.Properties[2]
+	if key, ok := computed.Key.(*ast.Identifier); !ok || key.Name != "key" || !computed.Computed {
+		t.Fatalf("unexpected computed key: %#v", computed)
+	}
+
+	parseProgramExpectError(t, `const {"quoted"} = obj;`)
+}
+
 func TestParseBlockStatement(t *testing.T) {
 	prog := parseProgram(t, "{ let x = 1; x; }")
 
@@ -948,6 +1038,146 @@ func TestParseWithStatement(t *testing.T) {
 	}
 }
 
+func TestParseErrorsCarryPositions(t *testing.T) {
+	err := parseProgramExpectError(t, "let ok = 1;\nif (ok {\n}")
+
+	var syntaxErr *parser.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *parser.SyntaxError, got %T: %v", err, err)
+	}
+	if syntaxErr.Message != "expected next token to be RPAREN, got LBRACE" {
+		t.Fatalf("unexpected message: %q", syntaxErr.Message)
+	}
+	if syntaxErr.Position.Line != 2 || syntaxErr.Position.Column != 7 || syntaxErr.Position.Offset != 19 {
+		t.Fatalf("expected error at line 2, column 7 (offset 19), got %+v", syntaxErr.Position)
+	}
+	if !strings.Contains(err.Error(), "(line 2, column 8)") {
+		t.Fatalf("expected one-based column in message, got %q", err.Error())
+	}
+
+	p := parser.New("a(1;\nb(2;")
+	if _, err := p.ParseProgram(); err == nil {
+		t.Fatalf("expected parse errors")
+	}
+	for _, e := range p.Errors() {
+		if !errors.As(e, &syntaxErr) {
+			t.Fatalf("expected every error to be a *parser.SyntaxError, got %T", e)
+		}
+	}
+}
+
+func TestParseRecoversToReportEveryStatementError(t *testing.T) {
+	p := parser.New("let a = ;\nlet b = 2;\nif (b {\n  b = 3;\n}\nlet c = 4;")
+	if _, err := p.ParseProgram(); err == nil {
+		t.Fatalf("expected parse errors")
+	}
+	errs := p.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	var syntaxErr *parser.SyntaxError
+	for idx, line := range []int{1, 3} {
+		if !errors.As(errs[idx], &syntaxErr) || syntaxErr.Position.Line != line {
+			t.Fatalf("expected error %d on line %d, got %v", idx, line, errs[idx])
+		}
+	}
+
+	p = parser.New("function f() {\n  let x = );\n  return x;\n}\nlet y = );")
+	if _, err := p.ParseProgram(); err == nil {
+		t.Fatalf("expected parse errors")
+	}
+	if errs := p.Errors(); len(errs) != 2 {
+		t.Fatalf("expected errors inside and after the function body, got %v", errs)
+	}
+}
+
+func TestParseReportsUnexpectedEndOfInputOnce(t *testing.T) {
+	tests := []struct {
+		src    string
+		line   int
+		column int
+	}{
+		{"let x = (1 +", 1, 12},
+		{"function f() {\n  if (x) {\n    let a = [1,", 3, 15},
+		{"{ let a = 1;", 1, 12},
+		{"obj.", 1, 4},
+		{"let", 1, 3},
+	}
+	for _, tt := range tests {
+		p := parser.New(tt.src)
+		if _, err := p.ParseProgram(); err == nil {
+			t.Fatalf("expected parse error for %q", tt.src)
+		}
+		errs := p.Errors()
+		if len(errs) != 1 {
+			t.Fatalf("%q: expected 1 error, got %d: %v", tt.src, len(errs), errs)
+		}
+		var syntaxErr *parser.SyntaxError
+		if !errors.As(errs[0], &syntaxErr) || syntaxErr.Message != "unexpected end of input" {
+			t.Fatalf("%q: expected unexpected end of input, got %v", tt.src, errs[0])
+		}
+		if syntaxErr.Position.Line != tt.line || syntaxErr.Position.Column != tt.column {
+			t.Fatalf("%q: expected error at %d:%d, got %d:%d", tt.src, tt.line, tt.column, syntaxErr.Position.Line, syntaxErr.Position.Column)
+		}
+	}
+}
+
+func TestParseMixedVariableDeclarators(t *testing.T) {
+	program := parseProgram(t, "let a = 1, [b, c] = arr, {d} = obj, e;")
+	decl, ok := program.Body[0].(*ast.VariableDeclaration)
+	if !ok || len(decl.Declarations) != 4 {
+		t.Fatalf("expected a declaration with 4 declarators, got %#v", program.Body[0])
+	}
+	if _, ok := decl.Declarations[0].Init.(*ast.NumberLiteral); !ok {
+		t.Fatalf("expected the first initializer to stop at the comma, got %T", decl.Declarations[0].Init)
+	}
+	if _, ok := decl.Declarations[1].ID.(*ast.ArrayPattern); !ok {
+		t.Fatalf("expected an array pattern, got %T", decl.Declarations[1].ID)
+	}
+	if _, ok := decl.Declarations[2].ID.(*ast.ObjectPattern); !ok {
+		t.Fatalf("expected an object pattern, got %T", decl.Declarations[2].ID)
+	}
+	if decl.Declarations[3].Init != nil {
+		t.Fatalf("expected the last declarator to have no initializer")
+	}
+
+	for _, tt := range []struct{ src, want string }{
+		{"const a = 1, b;", "missing initializer in const declaration (line 1, column 14)"},
+		{"let a = 1, [b] = c, {d};", "missing initializer in destructuring declaration (line 1, column 21)"},
+		{"for (const x; ; ) {}", "missing initializer in const declaration"},
+	} {
+		err := parseProgramExpectError(t, tt.src)
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected %q, got %v", tt.src, tt.want, err)
+		}
+	}
+	parseProgram(t, "for (const [k, v] of entries) {}")
+}
+
+func TestParseWithStatementRejectedInStrictCode(t *testing.T) {
+	err := parseProgramExpectError(t, "\"use strict\";\nwith (x) {}")
+	if !strings.Contains(err.Error(), "with statements are not allowed in strict mode (line 2, column 1)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parseProgramExpectError(t, "function f() { \"use strict\"; with (x) {} }")
+
+	if _, err := parser.New("with (x) {}").ParseModule(); err == nil {
+		t.Fatalf("expected with statement to be rejected in module code")
+	}
+
+	prog := parseProgram(t, "with (x) {}")
+	if prog.SourceType != ast.SourceTypeScript {
+		t.Fatalf("expected script source type, got %s", prog.SourceType)
+	}
+	mod, err := parser.New("x;").ParseModule()
+	if err != nil {
+		t.Fatalf("unexpected module parse error: %v", err)
+	}
+	if mod.SourceType != ast.SourceTypeModule {
+		t.Fatalf("expected module source type, got %s", mod.SourceType)
+	}
+}
+
 func TestParseLabeledStatement(t *testing.T) {
 	prog := parseProgram(t, "loop: while (true) break loop;")
 
@@ -1013,6 +1243,67 @@ func TestParseTryCatchFinally(t *testing.T) {
 	}
 }
 
+func TestParseOptionalCatchBinding(t *testing.T) {
+	prog := parseProgram(t, "try { risky(); } catch { recover(); }")
+
+	tryStmt, ok := prog.Body[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("expected TryStatement, got %T", prog.Body[0])
+	}
+	if tryStmt.Handler == nil || tryStmt.Handler.Param != nil {
+		t.Fatalf("expected catch handler without binding, got %#v", tryStmt.Handler)
+	}
+	if len(tryStmt.Handler.Body.Body) != 1 {
+		t.Fatalf("unexpected catch body: %#v", tryStmt.Handler.Body)
+	}
+}
+
+func TestParseFunctionExpressions(t *testing.T) {
+	prog := parseProgram(t, "const f = function(x){return x;}; const g = function named(){}; const h = function*(a = 1, ...rest){};")
+
+	exprs := make([]*ast.FunctionExpression, len(prog.Body))
+	for idx, stmt := range prog.Body {
+		decl := stmt.(*ast.VariableDeclaration)
+		fn, ok := decl.Declarations[0].Init.(*ast.FunctionExpression)
+		if !ok {
+			t.Fatalf("statement %d: expected FunctionExpression, got %T", idx, decl.Declarations[0].Init)
+		}
+		exprs[idx] = fn
+	}
+
+	if exprs[0].ID != nil || len(exprs[0].Params) != 1 || len(exprs[0].Body.Body) != 1 {
+		t.Fatalf("unexpected anonymous function expression: %#v", exprs[0])
+	}
+	if exprs[1].ID == nil || exprs[1].ID.Name != "named" {
+		t.Fatalf("expected function named 'named', got %#v", exprs[1].ID)
+	}
+	if !exprs[2].Generator || len(exprs[2].Params) != 2 {
+		t.Fatalf("expected generator with two params, got %#v", exprs[2])
+	}
+	if _, ok := exprs[2].Params[0].(*ast.AssignmentPattern); !ok {
+		t.Fatalf("expected default parameter, got %T", exprs[2].Params[0])
+	}
+	if _, ok := exprs[2].Params[1].(*ast.RestElement); !ok {
+		t.Fatalf("expected rest parameter, got %T", exprs[2].Params[1])
+	}
+}
+
+func TestParseImmediatelyInvokedFunctionExpression(t *testing.T) {
+	prog := parseProgram(t, "(function(){})();")
+
+	stmt := prog.Body[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected CallExpression, got %T", stmt.Expression)
+	}
+	if _, ok := call.Callee.(*ast.FunctionExpression); !ok {
+		t.Fatalf("expected FunctionExpression callee, got %T", call.Callee)
+	}
+
+	// The name of a function expression does not leak into the enclosing scope.
+	parseProgram(t, "const f = function g(){}; let g;")
+}
+
 func TestParseFunctionDeclaration(t *testing.T) {
 	prog := parseProgram(t, "function greet(name, title = \"Dr\") { return name; }")
 
@@ -1086,6 +1377,33 @@ func TestParseGeneratorFunctionDeclaration(t *testing.T) {
 	}
 }
 
+func TestParseCallSpreadArguments(t *testing.T) {
+	prog := parseProgram(t, "fn(1, ...[2, 3], 4,);")
+
+	stmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected ExpressionStatement, got %T", prog.Body[0])
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected CallExpression, got %T", stmt.Expression)
+	}
+	if len(call.Arguments) != 3 {
+		t.Fatalf("expected 3 arguments, got %d", len(call.Arguments))
+	}
+	spread, ok := call.Arguments[1].(*ast.SpreadElement)
+	if !ok {
+		t.Fatalf("expected spread argument, got %T", call.Arguments[1])
+	}
+	if _, ok := spread.Argument.(*ast.ArrayLiteral); !ok {
+		t.Fatalf("unexpected spread argument: %#v", spread.Argument)
+	}
+
+	for _, src := range []string{"fn(...);", "fn(a,,);", "fn(,);"} {
+		parseProgramExpectError(t, src)
+	}
+}
+
 func TestParseArrayLiteralExpression(t *testing.T) {
 	prog := parseProgram(t, "const arr = [1,,2,...more];")
 
@@ -1125,6 +1443,50 @@ func TestParseArrayLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestParseArrayLiteralCommaPrecedence(t *testing.T) {
+	elementsOf := func(src string) []ast.Expression {
+		t.Helper()
+		prog := parseProgram(t, src)
+		exprStmt := prog.Body[0].(*ast.ExpressionStatement)
+		arr, ok := exprStmt.Expression.(*ast.ArrayLiteral)
+		if !ok {
+			t.Fatalf("%s: expected ArrayLiteral, got %T", src, exprStmt.Expression)
+		}
+		return arr.Elements
+	}
+
+	elems := elementsOf("[a, b];")
+	if len(elems) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(elems))
+	}
+	for idx, name := range []string{"a", "b"} {
+		if ident, ok := elems[idx].(*ast.Identifier); !ok || ident.Name != name {
+			t.Fatalf("expected element %d to be identifier %s, got %#v", idx, name, elems[idx])
+		}
+	}
+
+	elems = elementsOf("[(a, b)];")
+	if len(elems) != 1 {
+		t.Fatalf("expected 1 element, got %d", len(elems))
+	}
+	seq, ok := elems[0].(*ast.SequenceExpression)
+	if !ok || len(seq.Expressions) != 2 {
+		t.Fatalf("expected two-expression SequenceExpression, got %#v", elems[0])
+	}
+
+	elems = elementsOf("[a = 1, b];")
+	if len(elems) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(elems))
+	}
+	assign, ok := elems[0].(*ast.AssignmentExpression)
+	if !ok || assign.Operator != "=" {
+		t.Fatalf("expected assignment as first element, got %#v", elems[0])
+	}
+	if ident, ok := elems[1].(*ast.Identifier); !ok || ident.Name != "b" {
+		t.Fatalf("expected identifier b as second element, got %#v", elems[1])
+	}
+}
+
 func TestParseObjectLiteralExpression(t *testing.T) {
 	prog := parseProgram(t, "const obj = { foo, bar: 2, [\"baz\"]: value, ...rest };")
 
@@ -1201,6 +1563,62 @@ func TestParseConditionalExpression(t *testing.T) {
 	}
 }
 
+func TestParseConditionalExpressionNesting(t *testing.T) {
+	expr := func(src string) ast.Expression {
+		t.Helper()
+		prog := parseProgram(t, src)
+		return prog.Body[0].(*ast.ExpressionStatement).Expression
+	}
+
+	outer, ok := expr("a ? b : c ? d : e;").(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("expected ConditionalExpression")
+	}
+	if test, ok := outer.Test.(*ast.Identifier); !ok || test.Name != "a" {
+		t.Fatalf("expected a as the outer test, got %#v", outer.Test)
+	}
+	inner, ok := outer.Alternate.(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("expected the nested conditional in the alternate, got %T", outer.Alternate)
+	}
+	if test, ok := inner.Test.(*ast.Identifier); !ok || test.Name != "c" {
+		t.Fatalf("expected c as the inner test, got %#v", inner.Test)
+	}
+
+	outer = expr("a ? b ? c : d : e;").(*ast.ConditionalExpression)
+	if _, ok := outer.Consequent.(*ast.ConditionalExpression); !ok {
+		t.Fatalf("expected the nested conditional in the consequent, got %T", outer.Consequent)
+	}
+
+	assign, ok := expr("x = a ? b : c = d;").(*ast.AssignmentExpression)
+	if !ok {
+		t.Fatalf("expected AssignmentExpression")
+	}
+	cond, ok := assign.Right.(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("expected a conditional on the right, got %T", assign.Right)
+	}
+	if _, ok := cond.Alternate.(*ast.AssignmentExpression); !ok {
+		t.Fatalf("expected an assignment in the alternate, got %T", cond.Alternate)
+	}
+
+	cond = expr("cond ? (x = 1) : (x = 2);").(*ast.ConditionalExpression)
+	if _, ok := cond.Consequent.(*ast.AssignmentExpression); !ok {
+		t.Fatalf("expected an assignment in the consequent, got %T", cond.Consequent)
+	}
+	cond = expr("a ? () => 1 : () => 2;").(*ast.ConditionalExpression)
+	if _, ok := cond.Alternate.(*ast.ArrowFunctionExpression); !ok {
+		t.Fatalf("expected an arrow function in the alternate, got %T", cond.Alternate)
+	}
+
+	if _, ok := expr("a ? b : c, d;").(*ast.SequenceExpression); !ok {
+		t.Fatalf("expected the comma to end the alternate")
+	}
+	parseProgram(t, "for (var x = a ? b in c : d; x; ) {}")
+	parseProgramExpectError(t, "a ? b, c : d;")
+	parseProgramExpectError(t, "a ? b;")
+}
+
 func TestParseSequenceExpression(t *testing.T) {
 	prog := parseProgram(t, "a(), b = 2, c + d;")
 
@@ -1234,3 +1652,868 @@ func TestParseSequenceExpression(t *testing.T) {
 		t.Fatalf("expected binary expression third, got %T", seq.Expressions[2])
 	}
 }
+
+func TestParseStrictModeDeleteIdentifier(t *testing.T) {
+	parseProgramExpectError(t, `"use strict"; delete x;`)
+	parseProgramExpectError(t, `function f() { 'use strict'; delete (x); }`)
+
+	prog := parseProgram(t, "delete x;")
+	exprStmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected ExpressionStatement, got %T", prog.Body[0])
+	}
+	unary, ok := exprStmt.Expression.(*ast.UnaryExpression)
+	if !ok || unary.Operator != "delete" {
+		t.Fatalf("expected delete UnaryExpression, got %#v", exprStmt.Expression)
+	}
+
+	// Member deletion stays legal, and strictness does not leak out of a function body.
+	parseProgram(t, `"use strict"; delete obj.x;`)
+	parseProgram(t, `function f() { "use strict"; } delete x;`)
+}
+
+func TestParseBigIntLiteralWithSeparators(t *testing.T) {
+	prog := parseProgram(t, "1_000_000n;")
+	exprStmt, ok := prog.Body[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected ExpressionStatement, got %T", prog.Body[0])
+	}
+	lit, ok := exprStmt.Expression.(*ast.BigIntLiteral)
+	if !ok {
+		t.Fatalf("expected BigIntLiteral, got %T", exprStmt.Expression)
+	}
+	if lit.Value != "1000000" {
+		t.Fatalf("expected value 1000000, got %q", lit.Value)
+	}
+
+	parseProgramExpectError(t, "1_n;")
+	parseProgramExpectError(t, "1._000n;")
+}
+
+func TestParseDuplicateLexicalDeclarations(t *testing.T) {
+	err := parseProgramExpectError(t, "let x; let x;")
+	if !strings.Contains(err.Error(), "'x' has already been declared (line 1, column 12)") {
+		t.Fatalf("expected redeclaration error with position, got %v", err)
+	}
+
+	err = parseProgramExpectError(t, "const y = 1;\nfunction y() {}")
+	if !strings.Contains(err.Error(), "'y' has already been declared (line 2, column 10)") {
+		t.Fatalf("expected redeclaration error with position, got %v", err)
+	}
+
+	parseProgramExpectError(t, "{ function f() {} let f; }")
+	parseProgramExpectError(t, "let z; { var z; }")
+	parseProgramExpectError(t, "function g(a) { let a; }")
+	parseProgramExpectError(t, "try {} catch (e) { let e; }")
+	parseProgramExpectError(t, "try {} catch ([a, { b }]) { const b = 1; }")
+	parseProgramExpectError(t, "try {} catch (e) { class e {} }")
+	parseProgramExpectError(t, "try {} catch (e) { function e() {} }")
+
+	parseProgram(t, "var x; var x;")
+	parseProgram(t, "let x; { let x; }")
+	parseProgram(t, "function h() {} var h;")
+	parseProgram(t, "for (let i = 0; i < 1; i++) { let i; }")
+	parseProgram(t, "try {} catch (e) { var e; }")
+	parseProgram(t, "try {} catch (e) { { let e; } }")
+	parseProgram(t, "try {} catch (e) {} let e;")
+}
+
+func TestParseFromStartingPosition(t *testing.T) {
+	start := lexer.Position{Line: 10, Column: 4, Offset: 200}
+
+	program, err := parser.NewAt("let a = 1;\nlet b = 2;", start).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	second := program.Body[1].Loc().Start
+	if second.Line != 11 || second.Column != 0 || second.Offset != 211 {
+		t.Fatalf("expected second statement at line 11, column 0, offset 211, got %+v", second)
+	}
+
+	_, err = parser.NewAt("let x;\nlet x;", start).ParseProgram()
+	if err == nil {
+		t.Fatalf("expected redeclaration error")
+	}
+	if !strings.Contains(err.Error(), "(line 11, column 5)") {
+		t.Fatalf("expected error position offset to line 11, got %v", err)
+	}
+}
+
+func TestParseStringLiteralEscapes(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`"\x41";`, "A"},
+		{`"\u{1F600}";`, "\U0001F600"},
+		{`"\uD83D\uDE00";`, "\U0001F600"},
+		{"\"a\\\nb\";", "ab"},
+		{"'a\\\r\nb';", "ab"},
+		{`'it\'s';`, "it's"},
+		{`"\0\b\f\n\r\t\v";`, "\x00\b\f\n\r\t\v"},
+		{`"\101\7\08";`, "A\a\x008"},
+		{`"\q\8";`, "q8"},
+	}
+	for _, tc := range cases {
+		prog := parseProgram(t, tc.src)
+		exprStmt := prog.Body[0].(*ast.ExpressionStatement)
+		lit, ok := exprStmt.Expression.(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("%s: expected StringLiteral, got %T", tc.src, exprStmt.Expression)
+		}
+		if lit.Value != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.src, tc.want, lit.Value)
+		}
+	}
+
+	for _, src := range []string{`"\x4";`, `"\u{110000}";`, `"\u12";`, `"use strict"; "\101";`, `"use strict"; "\8";`} {
+		parseProgramExpectError(t, src)
+	}
+}
+
+func TestParseObjectAccessorsAndMethods(t *testing.T) {
+	prog := parseProgram(t, "({ get: 1, get x() { return 1; }, set x(v) {}, m(a, b) {}, set: 2 });")
+	exprStmt := prog.Body[0].(*ast.ExpressionStatement)
+	obj, ok := exprStmt.Expression.(*ast.ObjectLiteral)
+	if !ok {
+		t.Fatalf("expected ObjectLiteral, got %T", exprStmt.Expression)
+	}
+	want := []struct {
+		key    string
+		kind   ast.PropertyKind
+		params int
+	}{
+		{"get", ast.PropertyInit, -1},
+		{"x", ast.PropertyGet, 0},
+		{"x", ast.PropertySet, 1},
+		{"m", ast.PropertyMethod, 2},
+		{"set", ast.PropertyInit, -1},
+	}
+	if len(obj.Properties) != len(want) {
+		t.Fatalf("expected %d properties, got %d", len(want), len(obj.Properties))
+	}
+	for idx, w := range want {
+		prop := obj.Properties[idx].(*ast.ObjectProperty)
+		if key, ok := prop.Key.(*ast.Identifier); !ok || key.Name != w.key || prop.PropKind != w.kind {
+			t.Fatalf("property %d: expected %s %s, got %s %#v", idx, w.kind, w.key, prop.PropKind, prop.Key)
+		}
+		if w.params < 0 {
+			continue
+		}
+		fn, ok := prop.Value.(*ast.FunctionExpression)
+		if !ok || len(fn.Params) != w.params {
+			t.Fatalf("property %d: expected function with %d params, got %#v", idx, w.params, prop.Value)
+		}
+	}
+
+	parseProgramExpectError(t, "({ get x(a) {} });")
+	parseProgramExpectError(t, "({ set x() {} });")
+	parseProgramExpectError(t, "({ set x(...v) {} });")
+}
+
+func TestParseForInAndForOf(t *testing.T) {
+	prog := parseProgram(t, "for (const k in obj) {} for (x.y of list) ; for (var i = 0, n = (\"a\" in o); i < n; i++) {}")
+
+	forIn, ok := prog.Body[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("expected ForInStatement, got %T", prog.Body[0])
+	}
+	if decl, ok := forIn.Left.(*ast.VariableDeclaration); !ok || decl.DeclareKind != ast.ConstKind {
+		t.Fatalf("expected const declaration on the left, got %#v", forIn.Left)
+	}
+
+	forOf, ok := prog.Body[1].(*ast.ForOfStatement)
+	if !ok {
+		t.Fatalf("expected ForOfStatement, got %T", prog.Body[1])
+	}
+	if _, ok := forOf.Left.(*ast.MemberExpression); !ok {
+		t.Fatalf("expected member expression on the left, got %T", forOf.Left)
+	}
+
+	if _, ok := prog.Body[2].(*ast.ForStatement); !ok {
+		t.Fatalf("expected parenthesized in to leave a plain for statement, got %T", prog.Body[2])
+	}
+
+	parseProgramExpectError(t, "for (let a, b in obj) {}")
+	parseProgramExpectError(t, "for (let a = 1 of list) {}")
+	parseProgramExpectError(t, "for (f() in obj) {}")
+}
+
+func TestParseClassDeclaration(t *testing.T) {
+	prog := parseProgram(t, "class A extends B { constructor(){} foo(){} static bar(){} get x(){} set x(v){} static(){} *gen(){} [key](){} }")
+	decl, ok := prog.Body[0].(*ast.ClassDeclaration)
+	if !ok {
+		t.Fatalf("expected ClassDeclaration, got %T", prog.Body[0])
+	}
+	if decl.ID == nil || decl.ID.Name != "A" {
+		t.Fatalf("expected class name A, got %#v", decl.ID)
+	}
+	if super, ok := decl.SuperClass.(*ast.Identifier); !ok || super.Name != "B" {
+		t.Fatalf("expected superclass B, got %#v", decl.SuperClass)
+	}
+
+	want := []struct {
+		name     string
+		kind     ast.MethodKind
+		static   bool
+		computed bool
+	}{
+		{"constructor", ast.MethodConstructor, false, false},
+		{"foo", ast.MethodMethod, false, false},
+		{"bar", ast.MethodMethod, true, false},
+		{"x", ast.MethodGet, false, false},
+		{"x", ast.MethodSet, false, false},
+		{"static", ast.MethodMethod, false, false},
+		{"gen", ast.MethodMethod, false, false},
+		{"key", ast.MethodMethod, false, true},
+	}
+	if len(decl.Body.Body) != len(want) {
+		t.Fatalf("expected %d class elements, got %d", len(want), len(decl.Body.Body))
+	}
+	for idx, w := range want {
+		m, ok := decl.Body.Body[idx].(*ast.MethodDefinition)
+		if !ok {
+			t.Fatalf("element %d: expected MethodDefinition, got %T", idx, decl.Body.Body[idx])
+		}
+		key, ok := m.Key.(*ast.Identifier)
+		if !ok || key.Name != w.name || m.MethodKind != w.kind || m.Static != w.static || m.Computed != w.computed {
+			t.Fatalf("element %d: expected %s %s (static=%t computed=%t), got %s %#v (static=%t computed=%t)",
+				idx, w.kind, w.name, w.static, w.computed, m.MethodKind, m.Key, m.Static, m.Computed)
+		}
+	}
+	if gen := decl.Body.Body[6].(*ast.MethodDefinition); !gen.Value.Generator {
+		t.Fatalf("expected *gen to be a generator method")
+	}
+}
+
+func TestParseClassStaticBlocks(t *testing.T) {
+	prog := parseProgram(t, `class Config {
+  static { var local = 1; Config.a = local; }
+  static() {}
+  static {
+    let b = 2;
+    Config.b = b;
+  }
+}`)
+	body := prog.Body[0].(*ast.ClassDeclaration).Body.Body
+	if len(body) != 3 {
+		t.Fatalf("expected 3 class elements, got %d", len(body))
+	}
+
+	first, ok := body[0].(*ast.StaticBlock)
+	if !ok || len(first.Body) != 2 {
+		t.Fatalf("expected static block with 2 statements, got %#v", body[0])
+	}
+	if m, ok := body[1].(*ast.MethodDefinition); !ok || m.Static || !propertyKeyIs(m.Key, "static") {
+		t.Fatalf("expected method named static, got %#v", body[1])
+	}
+	second, ok := body[2].(*ast.StaticBlock)
+	if !ok || len(second.Body) != 2 {
+		t.Fatalf("expected second static block with 2 statements, got %#v", body[2])
+	}
+	if _, ok := second.Body[0].(*ast.VariableDeclaration); !ok {
+		t.Fatalf("static blocks should keep source order, got %T first", second.Body[0])
+	}
+
+	// Each block has its own scope, so the same names may be declared again.
+	parseProgram(t, "class A { static { let x; } static { let x; } }")
+	parseProgramExpectError(t, "static { x = 1; }")
+	parseProgramExpectError(t, "function f() { static {} }")
+	parseProgramExpectError(t, "const o = { static {} };")
+}
+
+func propertyKeyIs(key ast.Expression, name string) bool {
+	ident, ok := key.(*ast.Identifier)
+	return ok && ident.Name == name
+}
+
+func TestParseClassExpression(t *testing.T) {
+	prog := parseProgram(t, "const C = class { m() { return 1; } }; const D = class Named extends mixin(C) {};")
+	for idx, wantName := range []string{"", "Named"} {
+		decl := prog.Body[idx].(*ast.VariableDeclaration)
+		expr, ok := decl.Declarations[0].Init.(*ast.ClassExpression)
+		if !ok {
+			t.Fatalf("expected ClassExpression, got %T", decl.Declarations[0].Init)
+		}
+		gotName := ""
+		if expr.ID != nil {
+			gotName = expr.ID.Name
+		}
+		if gotName != wantName {
+			t.Fatalf("expected class name %q, got %q", wantName, gotName)
+		}
+	}
+	second := prog.Body[1].(*ast.VariableDeclaration).Declarations[0].Init.(*ast.ClassExpression)
+	if _, ok := second.SuperClass.(*ast.CallExpression); !ok {
+		t.Fatalf("expected call expression as superclass, got %T", second.SuperClass)
+	}
+
+	parseProgramExpectError(t, "class A { constructor(){} constructor(){} }")
+	parseProgramExpectError(t, "class A { get constructor(){} }")
+	parseProgramExpectError(t, "class A { static prototype(){} }")
+	parseProgramExpectError(t, "class A { x = 1; }")
+	parseProgramExpectError(t, "class A {} let A;")
+}
+
+func TestParseNullishCoalescing(t *testing.T) {
+	prog := parseProgram(t, "a ?? b ?? c;")
+	exprStmt := prog.Body[0].(*ast.ExpressionStatement)
+	outer, ok := exprStmt.Expression.(*ast.LogicalExpression)
+	if !ok || outer.Operator != "??" {
+		t.Fatalf("expected ?? LogicalExpression, got %#v", exprStmt.Expression)
+	}
+	if inner, ok := outer.Left.(*ast.LogicalExpression); !ok || inner.Operator != "??" {
+		t.Fatalf("expected left-associative ??, got %#v", outer.Left)
+	}
+
+	parseProgramExpectError(t, "a || b ?? c;")
+	parseProgramExpectError(t, "a ?? b && c;")
+	parseProgram(t, "(a || b) ?? c;")
+	parseProgram(t, "a ?? (b && c);")
+}
+
+func TestParseOptionalChain(t *testing.T) {
+	prog := parseProgram(t, "a?.b?.c;")
+	exprStmt := prog.Body[0].(*ast.ExpressionStatement)
+	chain, ok := exprStmt.Expression.(*ast.ChainExpression)
+	if !ok {
+		t.Fatalf("expected ChainExpression, got %T", exprStmt.Expression)
+	}
+	outer, ok := chain.Expression.(*ast.MemberExpression)
+	if !ok || !outer.Optional {
+		t.Fatalf("expected optional member, got %#v", chain.Expression)
+	}
+	inner, ok := outer.Object.(*ast.MemberExpression)
+	if !ok || !inner.Optional {
+		t.Fatalf("expected nested optional member, got %#v", outer.Object)
+	}
+	if _, ok := inner.Object.(*ast.Identifier); !ok {
+		t.Fatalf("expected identifier at the chain root, got %T", inner.Object)
+	}
+
+	prog = parseProgram(t, "fn?.(1).x;")
+	chain = prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.ChainExpression)
+	member, ok := chain.Expression.(*ast.MemberExpression)
+	if !ok || member.Optional {
+		t.Fatalf("expected plain member continuing the chain, got %#v", chain.Expression)
+	}
+	if call, ok := member.Object.(*ast.CallExpression); !ok || !call.Optional {
+		t.Fatalf("expected optional call, got %#v", member.Object)
+	}
+
+	// Parentheses end the chain.
+	prog = parseProgram(t, "(a?.b).c;")
+	member, ok = prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("expected MemberExpression outside the chain, got %T", prog.Body[0].(*ast.ExpressionStatement).Expression)
+	}
+	if _, ok := member.Object.(*ast.ChainExpression); !ok {
+		t.Fatalf("expected parenthesized ChainExpression, got %T", member.Object)
+	}
+
+	parseProgramExpectError(t, "a?.b = 1;")
+}
+
+func TestParseExponentiation(t *testing.T) {
+	prog := parseProgram(t, "a ** b ** c;")
+	outer, ok := prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok || outer.Operator != "**" {
+		t.Fatalf("expected ** expression, got %#v", prog.Body[0])
+	}
+	if _, ok := outer.Right.(*ast.BinaryExpression); !ok {
+		t.Fatalf("expected ** to be right-associative, got right operand %#v", outer.Right)
+	}
+
+	parseProgram(t, "(-a) ** b; a ** -b; ++a ** b; x **= 2;")
+	err := parseProgramExpectError(t, "-a ** b;")
+	if !strings.Contains(err.Error(), "parentheses are required") {
+		t.Fatalf("unexpected error for unary base: %v", err)
+	}
+}
+
+func TestParseAutomaticSemicolonInsertion(t *testing.T) {
+	prog := parseProgram(t, "let a = 1\nlet b = 2\na = b\nfoo()\n{ a }")
+	if len(prog.Body) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(prog.Body))
+	}
+
+	prog = parseProgram(t, "function f() {\n  return\n  42\n}")
+	fn := prog.Body[0].(*ast.FunctionDeclaration)
+	if ret, ok := fn.Body.Body[0].(*ast.ReturnStatement); !ok || ret.Argument != nil {
+		t.Fatalf("expected return without argument, got %#v", fn.Body.Body[0])
+	}
+	if len(fn.Body.Body) != 2 {
+		t.Fatalf("expected the value to become its own statement, got %d statements", len(fn.Body.Body))
+	}
+
+	prog = parseProgram(t, "a\n++b")
+	if len(prog.Body) != 2 {
+		t.Fatalf("expected postfix ++ not to continue across a newline, got %d statements", len(prog.Body))
+	}
+	update, ok := prog.Body[1].(*ast.ExpressionStatement).Expression.(*ast.UpdateExpression)
+	if !ok || !update.Prefix {
+		t.Fatalf("expected a prefix update, got %#v", prog.Body[1])
+	}
+
+	prog = parseProgram(t, "outer: for (;;) { break\nouter }")
+	loop := prog.Body[0].(*ast.LabeledStatement).Body.(*ast.ForStatement)
+	if brk := loop.Body.(*ast.BlockStatement).Body[0].(*ast.BreakStatement); brk.Label != nil {
+		t.Fatalf("expected break without label, got %#v", brk.Label)
+	}
+
+	for _, src := range []string{"let a = 1 let b = 2", "a b", "function f() { return 1 2 }", "throw\nerr"} {
+		parseProgramExpectError(t, src)
+	}
+}
+
+func TestParseNulAndLegacyOctalEscapes(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`"\0";`, "\x00"},
+		{`"a\0b";`, "a\x00b"},
+		{`"\01";`, "\x01"},
+		{`"\00";`, "\x00"},
+		{`"\08";`, "\x008"},
+		{`"\101";`, "A"},
+		{`"\400";`, " 0"},
+	}
+	for _, tc := range cases {
+		prog := parseProgram(t, tc.src)
+		lit, ok := prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.StringLiteral)
+		if !ok || lit.Value != tc.want {
+			t.Fatalf("%s: expected %q, got %#v", tc.src, tc.want, prog.Body[0])
+		}
+	}
+
+	parseProgram(t, `"use strict"; "\0";`)
+	for _, src := range []string{
+		`"use strict"; "\01";`,
+		`"use strict"; "\08";`,
+		`function f() { "use strict"; return "\00"; }`,
+		`function f() { "\01"; "use strict"; }`,
+	} {
+		err := parseProgramExpectError(t, src)
+		if !strings.Contains(err.Error(), "not allowed in strict mode") {
+			t.Fatalf("%s: unexpected error %v", src, err)
+		}
+	}
+}
+
+func TestParseImportDeclarations(t *testing.T) {
+	mod, err := parser.New(`import "side";
+import def from "a";
+import def2, { x, y as z, default as w } from "b";
+import def3, * as ns from "c";
+import * as all from "d";`).ParseModule()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if len(mod.Body) != 5 {
+		t.Fatalf("expected 5 statements, got %d", len(mod.Body))
+	}
+
+	side := mod.Body[0].(*ast.ImportDeclaration)
+	if len(side.Specifiers) != 0 || side.Source.Value != "side" {
+		t.Fatalf("unexpected side-effect import: %#v", side)
+	}
+
+	named := mod.Body[2].(*ast.ImportDeclaration)
+	if len(named.Specifiers) != 4 {
+		t.Fatalf("expected 4 specifiers, got %d", len(named.Specifiers))
+	}
+	if def, ok := named.Specifiers[0].(*ast.ImportDefaultSpecifier); !ok || def.Local.Name != "def2" {
+		t.Fatalf("unexpected default specifier: %#v", named.Specifiers[0])
+	}
+	renamed, ok := named.Specifiers[2].(*ast.ImportSpecifier)
+	if !ok || renamed.Imported.Name != "y" || renamed.Local.Name != "z" {
+		t.Fatalf("unexpected renamed specifier: %#v", named.Specifiers[2])
+	}
+	if keyword := named.Specifiers[3].(*ast.ImportSpecifier); keyword.Imported.Name != "default" || keyword.Local.Name != "w" {
+		t.Fatalf("unexpected keyword specifier: %#v", keyword)
+	}
+
+	ns := mod.Body[3].(*ast.ImportDeclaration)
+	if spec, ok := ns.Specifiers[1].(*ast.ImportNamespaceSpecifier); !ok || spec.Local.Name != "ns" {
+		t.Fatalf("unexpected namespace specifier: %#v", ns.Specifiers[1])
+	}
+
+	for _, src := range []string{
+		`import { default } from "m";`,
+		`import * from "m";`,
+		`import a, b from "m";`,
+		`import { a } "m";`,
+		`import a from "m"; let a;`,
+		`{ import a from "m"; }`,
+	} {
+		if _, err := parser.New(src).ParseModule(); err == nil {
+			t.Errorf("expected %q to be rejected", src)
+		}
+	}
+	if _, err := parser.New(`import a from "m";`).ParseProgram(); err == nil {
+		t.Errorf("expected import to be rejected in script code")
+	}
+	if mod.SourceType != ast.SourceTypeModule {
+		t.Errorf("expected module source type, got %q", mod.SourceType)
+	}
+
+	for _, src := range []string{
+		"import { a, b as c } from \"m\";\nlet d = 1;",
+		"import { a } from \"m\";\nlet a = 1;",
+		"import a, * as ns from \"m\";\nclass a {}\nconst ns = 2;",
+		"export var v = 1;\nlet v = 2;",
+		"export { x };\nlet y;",
+	} {
+		script := parser.New(src)
+		program, err := script.ParseProgram()
+		if err == nil || len(script.Errors()) != 1 {
+			t.Errorf("%q: expected a single error for module syntax in script code, got %v", src, script.Errors())
+		}
+		if program != nil && program.SourceType != ast.SourceTypeScript {
+			t.Errorf("%q: expected script source type, got %q", src, program.SourceType)
+		}
+	}
+}
+
+func TestParseExportDeclarations(t *testing.T) {
+	mod, err := parser.New(`export var a = 1, b = 2;
+export function f() {}
+export class C {}
+export { a as x, b as y };
+export { y as default2, z } from "m";
+export * from "n";
+export * as ns from "o";
+export default a + b;`).ParseModule()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if len(mod.Body) != 8 {
+		t.Fatalf("expected 8 statements, got %d", len(mod.Body))
+	}
+
+	decl := mod.Body[0].(*ast.ExportNamedDeclaration)
+	if _, ok := decl.Declaration.(*ast.VariableDeclaration); !ok {
+		t.Fatalf("expected exported variable declaration, got %T", decl.Declaration)
+	}
+	if fn := mod.Body[1].(*ast.ExportNamedDeclaration); fn.Declaration.(*ast.FunctionDeclaration).ID.Name != "f" {
+		t.Fatalf("unexpected exported function: %#v", fn.Declaration)
+	}
+
+	local := mod.Body[3].(*ast.ExportNamedDeclaration)
+	if local.Source != nil || len(local.Specifiers) != 2 || local.Specifiers[0].Local.Name != "a" || local.Specifiers[0].Exported.Name != "x" {
+		t.Fatalf("unexpected export specifiers: %#v", local)
+	}
+	if from := mod.Body[4].(*ast.ExportNamedDeclaration); from.Source == nil || from.Source.Value != "m" {
+		t.Fatalf("expected re-export from m, got %#v", from.Source)
+	}
+	if all := mod.Body[5].(*ast.ExportAllDeclaration); all.Exported != nil || all.Source.Value != "n" {
+		t.Fatalf("unexpected export all: %#v", all)
+	}
+	if all := mod.Body[6].(*ast.ExportAllDeclaration); all.Exported == nil || all.Exported.Name != "ns" {
+		t.Fatalf("unexpected namespace re-export: %#v", all)
+	}
+	if def := mod.Body[7].(*ast.ExportDefaultDeclaration); def.Declaration.(*ast.BinaryExpression).Operator != "+" {
+		t.Fatalf("unexpected default export: %#v", def.Declaration)
+	}
+
+	named, err := parser.New("export default function g() {}\nexport default2;").ParseModule()
+	if err == nil {
+		t.Fatalf("expected export of a bare identifier to be rejected, got %#v", named)
+	}
+	anon, err := parser.New("export default function () {}\nexport default class {}").ParseModule()
+	if err == nil {
+		t.Fatalf("expected duplicate default export to be rejected, got %#v", anon)
+	}
+	decl2, err := parser.New("export default function g() {}\ng();").ParseModule()
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if _, ok := decl2.Body[0].(*ast.ExportDefaultDeclaration).Declaration.(*ast.FunctionDeclaration); !ok {
+		t.Fatalf("expected named default export to be a declaration")
+	}
+
+	for _, src := range []string{
+		`export { a as b, c as b };`,
+		`export var a; export { a };`,
+		`export * as ns "m";`,
+		`function f() { export var a; }`,
+	} {
+		if _, err := parser.New(src).ParseModule(); err == nil {
+			t.Errorf("expected %q to be rejected", src)
+		}
+	}
+}
+
+func TestParseYieldExpressions(t *testing.T) {
+	prog := parseProgram(t, `function* g() {
+  yield;
+  yield 1, 2;
+  yield* inner();
+  const x = yield
+  3;
+  f(yield a, yield);
+}
+const o = { *m() { yield this; } };
+gen.return(gen.throw);`)
+
+	body := prog.Body[0].(*ast.FunctionDeclaration).Body.Body
+	yieldOf := func(idx int) *ast.YieldExpression {
+		t.Helper()
+		y, ok := body[idx].(*ast.ExpressionStatement).Expression.(*ast.YieldExpression)
+		if !ok {
+			t.Fatalf("statement %d: expected YieldExpression, got %T", idx, body[idx].(*ast.ExpressionStatement).Expression)
+		}
+		return y
+	}
+	if y := yieldOf(0); y.Argument != nil || y.Delegate {
+		t.Fatalf("expected bare yield, got %#v", y)
+	}
+	seq, ok := body[1].(*ast.ExpressionStatement).Expression.(*ast.SequenceExpression)
+	if !ok || len(seq.Expressions) != 2 {
+		t.Fatalf("expected yield to bind tighter than the comma operator, got %#v", body[1])
+	}
+	if y := yieldOf(2); !y.Delegate {
+		t.Fatalf("expected delegating yield, got %#v", y)
+	}
+	decl := body[3].(*ast.VariableDeclaration)
+	if y := decl.Declarations[0].Init.(*ast.YieldExpression); y.Argument != nil {
+		t.Fatalf("expected a line break to end the yield operand, got %#v", y.Argument)
+	}
+	args := body[5].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments
+	if len(args) != 2 || args[1].(*ast.YieldExpression).Argument != nil {
+		t.Fatalf("unexpected yield arguments: %#v", args)
+	}
+
+	method := prog.Body[1].(*ast.VariableDeclaration).Declarations[0].Init.(*ast.ObjectLiteral).Properties[0].(*ast.ObjectProperty)
+	if fn := method.Value.(*ast.FunctionExpression); !fn.Generator {
+		t.Fatalf("expected generator method")
+	}
+	call := prog.Body[2].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if name := call.Callee.(*ast.MemberExpression).Property.(*ast.Identifier).Name; name != "return" {
+		t.Fatalf("expected reserved word member name, got %q", name)
+	}
+
+	parseProgramExpectError(t, "function f() { yield 1; }")
+	parseProgramExpectError(t, "function* g() { const f = () => yield 1; }")
+	parseProgramExpectError(t, "function* g() { function inner() { yield 1; } }")
+}
+
+func TestParseArrayLiteralTrailingComma(t *testing.T) {
+	tests := []struct {
+		src   string
+		holes []bool
+	}{
+		{"[1, 2,];", []bool{false, false}},
+		{"[1, 2,,];", []bool{false, false, true}},
+		{"[,];", []bool{true}},
+		{"[,,];", []bool{true, true}},
+		{"[1,, 2];", []bool{false, true, false}},
+		{"[...rest,];", []bool{false}},
+	}
+	for _, tt := range tests {
+		prog := parseProgram(t, tt.src)
+		arr, ok := prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.ArrayLiteral)
+		if !ok {
+			t.Fatalf("%s: expected ArrayLiteral, got %T", tt.src, prog.Body[0].(*ast.ExpressionStatement).Expression)
+		}
+		if len(arr.Elements) != len(tt.holes) {
+			t.Fatalf("%s: expected %d elements, got %d", tt.src, len(tt.holes), len(arr.Elements))
+		}
+		for idx, hole := range tt.holes {
+			if (arr.Elements[idx] == nil) != hole {
+				t.Fatalf("%s: element %d: expected hole=%v, got %#v", tt.src, idx, hole, arr.Elements[idx])
+			}
+		}
+	}
+}
+
+func TestParseArrayPatternTrailingComma(t *testing.T) {
+	prog := parseProgram(t, "let [a, b,] = xs;")
+	decl := prog.Body[0].(*ast.VariableDeclaration)
+	pattern, ok := decl.Declarations[0].ID.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("expected ArrayPattern, got %T", decl.Declarations[0].ID)
+	}
+	if len(pattern.Elements) != 2 {
+		t.Fatalf("expected 2 elements, got %d", len(pattern.Elements))
+	}
+}
+
+func TestParseUnicodeLineSeparatorEndsStatement(t *testing.T) {
+	prog := parseProgram(t, "let a = 1\u2028let b = a\u2029return_ = /x/")
+	if len(prog.Body) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(prog.Body))
+	}
+	for idx, line := range []int{1, 2, 3} {
+		if got := prog.Body[idx].Loc().Start.Line; got != line {
+			t.Fatalf("statement %d: expected line %d, got %d", idx, line, got)
+		}
+	}
+	if _, err := parser.New("/x\u2028/").ParseProgram(); err == nil {
+		t.Fatalf("expected a regular expression broken by U+2028 to fail")
+	}
+}
+
+func TestParseModuleSemantics(t *testing.T) {
+	for _, src := range []string{
+		`import a from "m"; export { a };`,
+		`export { f as g }; function f() {}`,
+		`export { v }; { var v; }`,
+		`export { x } from "m";`,
+	} {
+		prog, err := parser.New(src).ParseModule()
+		if err != nil {
+			t.Errorf("%s: unexpected module parse error: %v", src, err)
+			continue
+		}
+		if prog.SourceType != ast.SourceTypeModule {
+			t.Errorf("%s: expected module source type, got %q", src, prog.SourceType)
+		}
+		if _, err := parser.New(src).ParseProgram(); err == nil {
+			t.Errorf("%s: expected script parse to reject import/export", src)
+		}
+	}
+
+	for _, src := range []string{
+		`with (o) {}`,
+		`let a; const a = 1;`,
+		`let a; var a;`,
+		`var await;`,
+		`function await() {}`,
+		`await: for (;;) break await;`,
+		`let { await } = o;`,
+		`import await from "m";`,
+		`export { missing };`,
+		`export { inner }; { let inner; }`,
+	} {
+		if _, err := parser.New(src).ParseModule(); err == nil {
+			t.Errorf("expected module parse of %q to fail", src)
+		}
+	}
+	for _, src := range []string{`with (o) {}`, `var await; await = 1;`} {
+		if _, err := parser.New(src).ParseProgram(); err != nil {
+			t.Errorf("expected script parse of %q to succeed: %v", src, err)
+		}
+	}
+}
+
+func TestParseDirectivePrologues(t *testing.T) {
+	prog := parseProgram(t, `"use strict"; 'other'; x;`)
+	if !prog.Strict {
+		t.Fatalf("expected program to be strict")
+	}
+	if want := []string{`"use strict"`, `'other'`}; !reflect.DeepEqual(prog.Directives, want) {
+		t.Fatalf("expected directives %q, got %q", want, prog.Directives)
+	}
+
+	for _, src := range []string{`x; "use strict";`, `'use\x20strict';`, `("use strict");`, `"use strict" + x;`} {
+		if prog := parseProgram(t, src); prog.Strict {
+			t.Errorf("%s: expected program not to be strict", src)
+		}
+	}
+	if prog := parseProgram(t, `'use\x20strict';`); len(prog.Directives) != 1 || prog.Directives[0] != `'use\x20strict'` {
+		t.Errorf("expected the escaped directive to keep its raw text, got %q", prog.Directives)
+	}
+
+	prog = parseProgram(t, `
+function sloppy() { return 1; }
+function strict() { "use strict"; function inner() {} }
+const arrow = () => { "use strict"; };
+const bare = () => 1;
+class C { m() {} }`)
+	sloppy := prog.Body[0].(*ast.FunctionDeclaration)
+	strict := prog.Body[1].(*ast.FunctionDeclaration)
+	inner := strict.Body.Body[1].(*ast.FunctionDeclaration)
+	arrow := prog.Body[2].(*ast.VariableDeclaration).Declarations[0].Init.(*ast.ArrowFunctionExpression)
+	bare := prog.Body[3].(*ast.VariableDeclaration).Declarations[0].Init.(*ast.ArrowFunctionExpression)
+	method := prog.Body[4].(*ast.ClassDeclaration).Body.Body[0].(*ast.MethodDefinition).Value
+	if prog.Strict || sloppy.Strict || bare.Strict {
+		t.Errorf("expected sloppy code to stay sloppy")
+	}
+	if !strict.Strict || len(strict.Directives) != 1 || !inner.Strict || inner.Directives != nil {
+		t.Errorf("expected strict function and its nested function to be strict")
+	}
+	if !arrow.Strict || !method.Strict {
+		t.Errorf("expected arrow with directive and class method to be strict")
+	}
+
+	mod, err := parser.New(`x;`).ParseModule()
+	if err != nil || !mod.Strict {
+		t.Errorf("expected module code to be strict, got %v", err)
+	}
+}
+
+func TestParseStrictModeEarlyErrors(t *testing.T) {
+	for _, src := range []string{
+		`with (o) {}`,
+		`x = 0755;`,
+		`x = 08;`,
+		`function f(a, a) {}`,
+		`eval = 1;`,
+		`arguments += 1;`,
+		`eval++;`,
+		`--arguments;`,
+		`var eval;`,
+		`function f(arguments) {}`,
+		`try {} catch (eval) {}`,
+		`var interface;`,
+		`let private = 1;`,
+		`implements + 1;`,
+		`function f(package) {}`,
+		`var static;`,
+		`var { public } = o;`,
+		`var f = (eval) => 1;`,
+	} {
+		if _, err := parser.New(src).ParseProgram(); err != nil {
+			t.Errorf("%s: expected sloppy mode code to parse, got %v", src, err)
+		}
+		if _, err := parser.New(`"use strict"; ` + src).ParseProgram(); err == nil {
+			t.Errorf("%s: expected a strict mode error", src)
+		}
+	}
+
+	// A function's own directive applies to its name and parameters too.
+	for _, src := range []string{
+		`function f(a, a) { "use strict"; }`,
+		`function f(eval) { "use strict"; }`,
+		`function f(a = 1) { "use strict"; }`,
+	} {
+		if _, err := parser.New(src).ParseProgram(); err == nil {
+			t.Errorf("%s: expected a strict mode error", src)
+		}
+	}
+
+	// Some duplicate parameters are errors in sloppy mode code as well.
+	for _, src := range []string{`(a, a) => 1;`, `({ m(a, a) {} });`, `function f(a, [a]) {}`} {
+		if _, err := parser.New(src).ParseProgram(); err == nil {
+			t.Errorf("%s: expected duplicate parameters to be rejected", src)
+		}
+	}
+
+	p := parser.New(`"use strict";
+var x = 010;`)
+	_, err := p.ParseProgram()
+	var syntaxErr *parser.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Position.Line != 2 || syntaxErr.Position.Column != 8 {
+		t.Fatalf("expected a positioned error on line 2, column 9, got %v", err)
+	}
+}
+
+func TestParseLogicalAssignment(t *testing.T) {
+	prog := parseProgram(t, "a ||= b ??= c; o.x &&= 1;")
+	outer := prog.Body[0].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+	if outer.Operator != "||=" {
+		t.Fatalf("expected ||=, got %q", outer.Operator)
+	}
+	if inner, ok := outer.Right.(*ast.AssignmentExpression); !ok || inner.Operator != "??=" {
+		t.Fatalf("expected right-associative ??= assignment, got %#v", outer.Right)
+	}
+	member := prog.Body[1].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+	if _, ok := member.Left.(*ast.MemberExpression); !ok || member.Operator != "&&=" {
+		t.Fatalf("expected &&= on a member target, got %#v", member)
+	}
+	if _, err := parser.New("a + b ||= c;").ParseProgram(); err == nil {
+		t.Fatalf("expected an invalid logical assignment target to be rejected")
+	}
+}
@@ -0,0 +1,346 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"es6-interpreter/test262"
+)
+
+// newTest262Root lays out a fake test262 checkout holding a minimal harness
+// and the given test files, keyed by path relative to the root.
+func newTest262Root(t *testing.T, files map[string]string) *test262.Runner {
+	t.Helper()
+	root := t.TempDir()
+	files["harness/assert.js"] = `
+function Test262Error(message) { this.message = message; }
+function assert(mustBeTrue, message) {
+  if (mustBeTrue !== true) { throw new Test262Error(message); }
+}
+assert.sameValue = function (actual, expected, message) {
+  if (actual !== expected) { throw new Test262Error(message); }
+};
+`
+	files["harness/sta.js"] = `
+function $DONOTEVALUATE() { throw "Test262: This statement should not be evaluated."; }
+`
+	for name, src := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runner, err := test262.NewRunner(root, filepath.Join(root, "out"))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	return runner
+}
+
+func TestTest262RunClassifiesCases(t *testing.T) {
+	runner := newTest262Root(t, map[string]string{
+		"harness/double.js": "function double(n) { return n * 2; }\n",
+		"test/pass.js": `/*---
+description: addition works
+---*/
+assert.sameValue(1 + 1, 2);
+`,
+		"test/includes.js": `/*---
+includes:
+  - double.js
+---*/
+assert.sameValue(double(4), 8);
+`,
+		"test/negative-syntax.js": `/*---
+negative:
+  phase: parse
+  type: SyntaxError
+---*/
+$DONOTEVALUATE();
+var x = ;
+`,
+		"test/negative-runtime.js": `/*---
+negative:
+  phase: runtime
+  type: Test262Error
+---*/
+throw new Test262Error("expected");
+`,
+		"test/strict-only.js": `/*---
+flags: [onlyStrict]
+negative:
+  phase: parse
+  type: SyntaxError
+---*/
+var eval = 1;
+`,
+		"test/raw.js": `/*---
+flags: [raw]
+negative:
+  phase: runtime
+  type: ReferenceError
+---*/
+assert(true);
+`,
+		"test/fail.js": `/*---
+description: a failing assertion
+---*/
+assert.sameValue(1, 2, "one is not two");
+`,
+		"test/fails-when-strict.js": `/*---
+description: runs in both modes by default
+---*/
+var eval = 1;
+`,
+		"test/wrong-negative.js": `/*---
+negative:
+  phase: parse
+  type: SyntaxError
+---*/
+throw new Test262Error("not a parse error");
+`,
+		"test/async.js": `/*---
+flags: [async]
+---*/
+$DONE();
+`,
+	})
+
+	cases := []test262.TestCase{
+		{Path: "test/pass.js"},
+		{Path: "test/includes.js"},
+		{Path: "test/negative-syntax.js"},
+		{Path: "test/negative-runtime.js"},
+		{Path: "test/strict-only.js"},
+		{Path: "test/raw.js"},
+		{Path: "test/fail.js"},
+		{Path: "test/fails-when-strict.js"},
+		{Path: "test/wrong-negative.js"},
+		{Path: "test/async.js"},
+	}
+	report, err := runner.Run(cases)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	got := [4]int{report.Total, report.Passed, report.Failed, report.Skipped}
+	if want := [4]int{10, 6, 3, 1}; got != want {
+		t.Fatalf("expected total/passed/failed/skipped %v, got %v: %+v", want, got, report.Results)
+	}
+	failed := map[string]bool{}
+	for _, result := range report.Results {
+		if result.Status == test262.StatusFail {
+			failed[result.Path] = true
+		}
+	}
+	for _, path := range []string{"test/fail.js", "test/fails-when-strict.js", "test/wrong-negative.js"} {
+		if !failed[path] {
+			t.Errorf("expected %s to fail, got %+v", path, report.Results)
+		}
+	}
+}
+
+func TestTest262WriteReport(t *testing.T) {
+	runner := newTest262Root(t, map[string]string{
+		"test/pass.js": "assert.sameValue(2 * 3, 6);\n",
+		"test/fail.js": "assert.sameValue(1, 2, \"one is not two\");\n",
+	})
+	report, err := runner.Run([]test262.TestCase{{Path: "test/pass.js"}, {Path: "test/fail.js"}, {Path: "test/missing.js"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	path, err := runner.WriteReport(report, "json")
+	if err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+	if filepath.Dir(path) != runner.OutDir {
+		t.Fatalf("expected the report in %s, got %s", runner.OutDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Total   int
+		Failed  int
+		Results []struct {
+			Path   string
+			Status string
+			Error  string
+		}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, data)
+	}
+	if decoded.Total != 3 || decoded.Failed != 2 || len(decoded.Results) != 3 {
+		t.Fatalf("unexpected totals in report: %s", data)
+	}
+	// Results are sorted by path.
+	wantStatus := []string{"fail", "fail", "pass"}
+	for idx, result := range decoded.Results {
+		if result.Status != wantStatus[idx] {
+			t.Errorf("result %d (%s): expected status %s, got %s", idx, result.Path, wantStatus[idx], result.Status)
+		}
+	}
+	if !strings.Contains(decoded.Results[0].Error, "Test262Error: one is not two") {
+		t.Errorf("expected the failing assertion in the error, got %q", decoded.Results[0].Error)
+	}
+
+	var tap strings.Builder
+	if err := report.WriteReport(&tap, "tap"); err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+	for _, line := range []string{"1..3\n", "not ok 1 - test/fail.js\n# ", "not ok 2 - test/missing.js\n", "ok 3 - test/pass.js\n"} {
+		if !strings.Contains(tap.String(), line) {
+			t.Errorf("expected TAP output to contain %q:\n%s", line, tap.String())
+		}
+	}
+	if err := report.WriteReport(&tap, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestTest262IsAsyncRelated(t *testing.T) {
+	tests := []struct {
+		tc   test262.TestCase
+		want bool
+	}{
+		{test262.TestCase{Path: "test/language/expressions/awaiting.js"}, false},
+		{test262.TestCase{Path: "test/built-ins/Array/asyncish.js"}, false},
+		{test262.TestCase{Path: "test/language/statements/for-await-of/head.js"}, true},
+		{test262.TestCase{Path: "test/language/expressions/async-arrow-function/body.js"}, true},
+		{test262.TestCase{Path: "test/language/misc/plain.js", Features: []string{"async-functions"}}, true},
+		{test262.TestCase{Path: "test/language/misc/plain.js", Features: []string{"top-level-await"}, Flags: []string{"module"}}, true},
+		{test262.TestCase{Path: "test/language/misc/plain.js", Flags: []string{"async"}}, true},
+		{test262.TestCase{Path: "test/language/expressions/await/ident.js", Flags: []string{"noStrict"}}, false},
+		{test262.TestCase{Path: "test/language/misc/generators.js", Features: []string{"generators"}}, false},
+	}
+	for _, tt := range tests {
+		if got := test262.IsAsyncRelated(tt.tc); got != tt.want {
+			t.Errorf("IsAsyncRelated(%+v) = %v, want %v", tt.tc, got, tt.want)
+		}
+	}
+
+	runner := newTest262Root(t, map[string]string{
+		"test/awaiting.js":   "/*---\ndescription: not async despite the name\n---*/\nassert(true);\n",
+		"test/uses-async.js": "/*---\nfeatures: [async-functions]\n---*/\nassert(true);\n",
+	})
+	report, err := runner.Run([]test262.TestCase{{Path: "test/awaiting.js"}, {Path: "test/uses-async.js"}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Passed != 1 || report.Skipped != 1 || report.Results[1].Status != test262.StatusSkip {
+		t.Fatalf("expected only the async-functions case to be skipped, got %+v", report.Results)
+	}
+}
+
+func TestTest262RunConcurrently(t *testing.T) {
+	files := map[string]string{}
+	var cases []test262.TestCase
+	for n := range 40 {
+		path := fmt.Sprintf("test/case%02d.js", n)
+		if n%4 == 0 {
+			files[path] = fmt.Sprintf("assert.sameValue(%d, -1);\n", n)
+		} else {
+			files[path] = fmt.Sprintf("var n = %d;\nassert.sameValue(n * 2, %d);\n", n, n*2)
+		}
+		cases = append(cases, test262.TestCase{Path: path})
+	}
+	runner := newTest262Root(t, files)
+	runner.Concurrency = 4
+	// Submit the cases in reverse to show the report is sorted regardless.
+	slices.Reverse(cases)
+	report, err := runner.Run(cases)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total != 40 || report.Passed != 30 || report.Failed != 10 || len(report.Results) != 40 {
+		t.Fatalf("unexpected totals: %d total, %d passed, %d failed, %d results",
+			report.Total, report.Passed, report.Failed, len(report.Results))
+	}
+	for n, result := range report.Results {
+		wantStatus := test262.StatusPass
+		if n%4 == 0 {
+			wantStatus = test262.StatusFail
+		}
+		if want := fmt.Sprintf("test/case%02d.js", n); result.Path != want || result.Status != wantStatus {
+			t.Fatalf("result %d: expected %s %s, got %s %s", n, want, wantStatus, result.Path, result.Status)
+		}
+	}
+}
+
+func TestTest262RunTimesOutAndTimesEveryCase(t *testing.T) {
+	runner := newTest262Root(t, map[string]string{
+		"test/spin.js": "while (true) {}\n",
+		"test/spin-module.js": `/*---
+flags: [module]
+---*/
+for (;;) {}
+`,
+		"test/async.js": `/*---
+flags: [async]
+---*/
+$DONE();
+`,
+		"test/pass.js": "assert.sameValue(1, 1);\n",
+	})
+	runner.Timeout = 50 * time.Millisecond
+	report, err := runner.Run([]test262.TestCase{
+		{Path: "test/spin.js"},
+		{Path: "test/spin-module.js"},
+		{Path: "test/async.js"},
+		{Path: "test/missing.js"},
+		{Path: "test/pass.js"},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	got := [4]int{report.Total, report.Passed, report.Failed, report.Skipped}
+	if want := [4]int{5, 1, 3, 1}; got != want {
+		t.Fatalf("expected total/passed/failed/skipped %v, got %v: %+v", want, got, report.Results)
+	}
+	for _, result := range report.Results {
+		if strings.HasPrefix(result.Path, "test/spin") && result.Error != "timed out after 50ms" {
+			t.Errorf("expected %s to time out, got %+v", result.Path, result)
+		}
+		if result.Duration <= 0 {
+			t.Errorf("expected %s to be timed, got %+v", result.Path, result)
+		}
+	}
+}
+
+func TestTest262RunStopsAfterFirstError(t *testing.T) {
+	files := map[string]string{
+		"test/broken.js": `/*---
+includes: [missing.js]
+---*/
+`,
+	}
+	cases := []test262.TestCase{{Path: "test/broken.js"}}
+	for n := range 20 {
+		path := fmt.Sprintf("test/spin%02d.js", n)
+		files[path] = "while (true) {}\n"
+		cases = append(cases, test262.TestCase{Path: path})
+	}
+	runner := newTest262Root(t, files)
+	runner.Concurrency = 2
+	runner.Timeout = 200 * time.Millisecond
+
+	started := time.Now()
+	if _, err := runner.Run(cases); err == nil || !strings.Contains(err.Error(), "harness") {
+		t.Fatalf("expected the missing harness file to be reported, got %v", err)
+	}
+	// Running every spinning case would take about two seconds.
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected Run to stop dispatching after the error, took %s", elapsed)
+	}
+}
@@ -0,0 +1,329 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// newArray allocates an array inheriting from Array.prototype.
+func (i *Interpreter) newArray(elements []Value) *Object {
+	arr := NewArray(elements)
+	arr.prototype = i.arrayPrototype
+	return arr
+}
+
+func (i *Interpreter) newArrayPrototype() *Object {
+	proto := NewObject(nil)
+	for _, kind := range []string{"keys", "values", "entries"} {
+		i.defineMethod(proto, kind, func(this Value, _ []Value) (Value, error) {
+			return i.arrayIterator(this, kind)
+		})
+	}
+	i.defineMethod(proto, "push", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "push")
+		if err != nil {
+			return Value{}, err
+		}
+		length := arr.Length()
+		for idx, arg := range args {
+			if err := i.setProperty(this, indexKey(length+uint32(idx)), arg, true); err != nil {
+				return Value{}, err
+			}
+		}
+		return NewNumber(float64(arr.Length())), nil
+	})
+	i.defineMethod(proto, "pop", func(this Value, _ []Value) (Value, error) {
+		arr, err := thisArray(this, "pop")
+		if err != nil {
+			return Value{}, err
+		}
+		length := arr.Length()
+		if length == 0 {
+			return Undefined, nil
+		}
+		last, err := i.getProperty(this, indexKey(length-1))
+		if err != nil {
+			return Value{}, err
+		}
+		arr.Delete(indexKey(length - 1))
+		arr.setLength(length - 1)
+		return last, nil
+	})
+	i.defineMethod(proto, "shift", func(this Value, _ []Value) (Value, error) {
+		arr, err := thisArray(this, "shift")
+		if err != nil {
+			return Value{}, err
+		}
+		length := arr.Length()
+		if length == 0 {
+			return Undefined, nil
+		}
+		first, err := i.getProperty(this, "0")
+		if err != nil {
+			return Value{}, err
+		}
+		if err := i.moveElements(arr, 1, 0, length-1); err != nil {
+			return Value{}, err
+		}
+		arr.Delete(indexKey(length - 1))
+		arr.setLength(length - 1)
+		return first, nil
+	})
+	i.defineMethod(proto, "unshift", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "unshift")
+		if err != nil {
+			return Value{}, err
+		}
+		length := arr.Length()
+		if err := i.moveElements(arr, 0, uint32(len(args)), length); err != nil {
+			return Value{}, err
+		}
+		for idx, arg := range args {
+			if err := i.setProperty(this, indexKey(uint32(idx)), arg, true); err != nil {
+				return Value{}, err
+			}
+		}
+		return NewNumber(float64(arr.Length())), nil
+	})
+	i.defineMethod(proto, "slice", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "slice")
+		if err != nil {
+			return Value{}, err
+		}
+		length := int(arr.Length())
+		start := relativeIndex(argAt(args, 0), length, 0)
+		end := relativeIndex(argAt(args, 1), length, length)
+		result := i.newArray(nil)
+		for idx := start; idx < end; idx++ {
+			key := indexKey(uint32(idx))
+			if !arr.Has(key) {
+				continue
+			}
+			elem, err := i.getProperty(this, key)
+			if err != nil {
+				return Value{}, err
+			}
+			result.Set(indexKey(uint32(idx-start)), elem)
+		}
+		if end > start {
+			result.setLength(uint32(end - start))
+		}
+		return NewObjectValue(result), nil
+	})
+	i.defineMethod(proto, "indexOf", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "indexOf")
+		if err != nil {
+			return Value{}, err
+		}
+		length := int(arr.Length())
+		from := relativeIndex(argAt(args, 1), length, 0)
+		for idx := from; idx < length; idx++ {
+			key := indexKey(uint32(idx))
+			if !arr.Has(key) {
+				continue
+			}
+			elem, err := i.getProperty(this, key)
+			if err != nil {
+				return Value{}, err
+			}
+			if StrictEquals(elem, argAt(args, 0)) {
+				return NewNumber(float64(idx)), nil
+			}
+		}
+		return NewNumber(-1), nil
+	})
+	i.defineMethod(proto, "join", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "join")
+		if err != nil {
+			return Value{}, err
+		}
+		sep := ","
+		if v := argAt(args, 0); v.Kind() != UndefinedKind {
+			sep = ToString(v).StringValue()
+		}
+		return NewString(joinArray(arr, sep, nil)), nil
+	})
+	i.defineMethod(proto, "forEach", func(this Value, args []Value) (Value, error) {
+		err := i.eachElement(this, args, "forEach", func(_ uint32, _, _ Value) error { return nil })
+		return Undefined, err
+	})
+	i.defineMethod(proto, "map", func(this Value, args []Value) (Value, error) {
+		result := i.newArray(nil)
+		err := i.eachElement(this, args, "map", func(idx uint32, _, mapped Value) error {
+			result.Set(indexKey(idx), mapped)
+			return nil
+		})
+		if err != nil {
+			return Value{}, err
+		}
+		result.setLength(this.Object().Length())
+		return NewObjectValue(result), nil
+	})
+	i.defineMethod(proto, "filter", func(this Value, args []Value) (Value, error) {
+		var kept []Value
+		err := i.eachElement(this, args, "filter", func(_ uint32, elem, selected Value) error {
+			if ToBoolean(selected) {
+				kept = append(kept, elem)
+			}
+			return nil
+		})
+		if err != nil {
+			return Value{}, err
+		}
+		return NewObjectValue(i.newArray(kept)), nil
+	})
+	i.defineMethod(proto, "reduce", func(this Value, args []Value) (Value, error) {
+		arr, err := thisArray(this, "reduce")
+		if err != nil {
+			return Value{}, err
+		}
+		callback := argAt(args, 0)
+		if !callback.IsCallable() {
+			return Value{}, fmt.Errorf("TypeError: %s is not a function", callback.Inspect())
+		}
+		length := arr.Length()
+		idx := uint32(0)
+		var acc Value
+		if len(args) > 1 {
+			acc = args[1]
+		} else {
+			for idx < length && !arr.Has(indexKey(idx)) {
+				idx++
+			}
+			if idx == length {
+				return Value{}, fmt.Errorf("TypeError: Reduce of empty array with no initial value")
+			}
+			if acc, err = i.getProperty(this, indexKey(idx)); err != nil {
+				return Value{}, err
+			}
+			idx++
+		}
+		for ; idx < length; idx++ {
+			key := indexKey(idx)
+			if !arr.Has(key) {
+				continue
+			}
+			elem, err := i.getProperty(this, key)
+			if err != nil {
+				return Value{}, err
+			}
+			acc, err = i.callFunction(callback, Undefined, []Value{acc, elem, NewNumber(float64(idx)), this})
+			if err != nil {
+				return Value{}, err
+			}
+		}
+		return acc, nil
+	})
+	return proto
+}
+
+// thisArray resolves the receiver of an Array.prototype method. Array-likes
+// are not supported yet, so the receiver must be an array.
+func thisArray(this Value, method string) (*Object, error) {
+	if this.Kind() != ObjectKind || this.Object().Class() != "Array" {
+		return nil, fmt.Errorf("TypeError: Array.prototype.%s called on %s", method, this.Inspect())
+	}
+	return this.Object(), nil
+}
+
+// eachElement calls the callback argument of an iteration method with
+// (element, index, array) for every present element of this, skipping holes,
+// and passes each element and callback result to visit. The length is read
+// once up front, so elements appended by the callback are not visited.
+func (i *Interpreter) eachElement(this Value, args []Value, method string, visit func(idx uint32, elem, result Value) error) error {
+	arr, err := thisArray(this, method)
+	if err != nil {
+		return err
+	}
+	callback, thisArg := argAt(args, 0), argAt(args, 1)
+	if !callback.IsCallable() {
+		return fmt.Errorf("TypeError: %s is not a function", callback.Inspect())
+	}
+	length := arr.Length()
+	for idx := uint32(0); idx < length; idx++ {
+		key := indexKey(idx)
+		if !arr.Has(key) {
+			continue
+		}
+		elem, err := i.getProperty(this, key)
+		if err != nil {
+			return err
+		}
+		result, err := i.callFunction(callback, thisArg, []Value{elem, NewNumber(float64(idx)), this})
+		if err != nil {
+			return err
+		}
+		if err := visit(idx, elem, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveElements copies count elements of arr starting at from to start at to,
+// preserving holes. Overlapping ranges are handled in either direction.
+func (i *Interpreter) moveElements(arr *Object, from, to, count uint32) error {
+	move := func(k uint32) error {
+		src, dst := indexKey(from+k), indexKey(to+k)
+		if !arr.Has(src) {
+			arr.Delete(dst)
+			return nil
+		}
+		elem, err := i.getProperty(NewObjectValue(arr), src)
+		if err != nil {
+			return err
+		}
+		return i.setProperty(NewObjectValue(arr), dst, elem, true)
+	}
+	if to > from {
+		for k := count; k > 0; k-- {
+			if err := move(k - 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for k := uint32(0); k < count; k++ {
+		if err := move(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indexKey(idx uint32) string {
+	return strconv.FormatUint(uint64(idx), 10)
+}
+
+// arrayIterator returns an iterator over the indices, values or [index, value]
+// entries of this, depending on kind. The length is read on every step, so
+// elements appended during iteration are visited and holes read as
+// undefined.
+func (i *Interpreter) arrayIterator(this Value, kind string) (Value, error) {
+	if this.Kind() != ObjectKind {
+		return Value{}, fmt.Errorf("TypeError: Array.prototype.%s called on %s", kind, this.Inspect())
+	}
+	arr := this.Object()
+	var next uint32
+	done := false
+	iter := i.newIterator("Array Iterator", func() (Value, bool, error) {
+		if done || next >= arr.Length() {
+			done = true
+			return Undefined, true, nil
+		}
+		idx := next
+		next++
+		if kind == "keys" {
+			return NewNumber(float64(idx)), false, nil
+		}
+		val, err := i.getProperty(this, indexKey(idx))
+		if err != nil {
+			return Value{}, false, err
+		}
+		if kind == "entries" {
+			return NewObjectValue(i.newArray([]Value{NewNumber(float64(idx)), val})), false, nil
+		}
+		return val, false, nil
+	})
+	return NewObjectValue(iter), nil
+}
@@ -0,0 +1,341 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+)
+
+// NativeFunction implements a built-in function in Go. It receives the this
+// value and arguments of a call; an error such as "TypeError: ..." becomes an
+// exception that scripts can catch.
+type NativeFunction func(this Value, args []Value) (Value, error)
+
+func (i *Interpreter) newNativeFunction(name string, fn NativeFunction) Value {
+	return i.newFunction(&function{name: name, native: fn})
+}
+
+// DefineFunction binds name as a global var holding a function implemented
+// in Go. It fails if a script has already declared name with let, const or
+// class.
+func (i *Interpreter) DefineFunction(name string, fn NativeFunction) error {
+	if err := i.global.Declare(name, BindingVar); err != nil {
+		return err
+	}
+	return i.global.Set(name, i.newNativeFunction(name, fn))
+}
+
+// installGlobals seeds the global environment. Only pure built-ins are
+// installed here; bindings that reach the host (console output, clocks,
+// the filesystem) must check i.sandboxed before being registered.
+func (i *Interpreter) installGlobals() {
+	// Global bindings live in a declarative environment, so the global object
+	// does not mirror them as properties.
+	i.globalObject = NewObject(nil)
+	i.global.bindThis(NewObjectValue(i.globalObject))
+	i.defineGlobal("globalThis", NewObjectValue(i.globalObject))
+	i.defineReadOnlyGlobal("undefined", Undefined)
+	i.defineReadOnlyGlobal("NaN", NewNumber(math.NaN()))
+	i.defineReadOnlyGlobal("Infinity", NewNumber(math.Inf(1)))
+	i.stringPrototype = i.newStringPrototype()
+	i.numberPrototype = i.newNumberPrototype()
+	i.regexpPrototype = i.newRegExpPrototype()
+	i.arrayPrototype = i.newArrayPrototype()
+	i.generatorPrototype = i.newGeneratorPrototype()
+	i.defineGlobal("Object", i.newObjectConstructor())
+	i.defineGlobal("Math", NewObjectValue(i.newMathObject()))
+	i.defineGlobal("JSON", NewObjectValue(i.newJSONObject()))
+	i.installNumberFunctions()
+	if !i.sandboxed {
+		i.defineGlobal("console", NewObjectValue(i.newConsoleObject()))
+	}
+}
+
+func (i *Interpreter) defineGlobal(name string, v Value) {
+	// The global environment is fresh, so neither call can fail.
+	_ = i.global.Declare(name, BindingVar)
+	_ = i.global.Set(name, v)
+}
+
+// defineReadOnlyGlobal binds name to v as a var that scripts cannot change.
+func (i *Interpreter) defineReadOnlyGlobal(name string, v Value) {
+	i.defineGlobal(name, v)
+	b, _ := i.global.own(name)
+	b.readOnly = true
+}
+
+// newObjectConstructor builds the Object global. Calling it returns objects
+// unchanged and a fresh empty object for anything else; its static methods
+// inspect, copy and freeze own properties and manage prototypes.
+func (i *Interpreter) newObjectConstructor() Value {
+	ctor := i.newNativeFunction("Object", func(_ Value, args []Value) (Value, error) {
+		if len(args) > 0 && args[0].Kind() == ObjectKind {
+			return args[0], nil
+		}
+		return NewObjectValue(NewObject(nil)), nil
+	})
+	obj := ctor.Object()
+
+	i.defineMethod(obj, "keys", func(_ Value, args []Value) (Value, error) {
+		return i.ownKeysArray(args, true)
+	})
+	i.defineMethod(obj, "values", func(_ Value, args []Value) (Value, error) {
+		return i.ownEntriesArray(args, func(_ string, v Value) Value { return v })
+	})
+	i.defineMethod(obj, "entries", func(_ Value, args []Value) (Value, error) {
+		return i.ownEntriesArray(args, func(key string, v Value) Value {
+			return NewObjectValue(i.newArray([]Value{NewString(key), v}))
+		})
+	})
+	i.defineMethod(obj, "assign", func(_ Value, args []Value) (Value, error) {
+		target := argAt(args, 0)
+		if isNullish(target) {
+			return Value{}, fmt.Errorf("TypeError: Cannot convert undefined or null to object")
+		}
+		for _, source := range args[1:] {
+			keys, err := ownKeysOf([]Value{source}, true)
+			if err != nil {
+				// null and undefined sources are skipped.
+				continue
+			}
+			for _, key := range keys {
+				v, err := i.getProperty(source, key)
+				if err != nil {
+					return Value{}, err
+				}
+				if err := i.setProperty(target, key, v, true); err != nil {
+					return Value{}, err
+				}
+			}
+		}
+		return target, nil
+	})
+	i.defineMethod(obj, "freeze", func(_ Value, args []Value) (Value, error) {
+		v := argAt(args, 0)
+		if v.Kind() == ObjectKind {
+			v.Object().Freeze()
+		}
+		return v, nil
+	})
+	i.defineMethod(obj, "getOwnPropertyNames", func(_ Value, args []Value) (Value, error) {
+		return i.ownKeysArray(args, false)
+	})
+	i.defineMethod(obj, "getOwnPropertySymbols", func(_ Value, args []Value) (Value, error) {
+		if _, err := ownKeysOf(args, false); err != nil {
+			return Value{}, err
+		}
+		// There are no symbols yet, so no object has symbol keys.
+		return NewObjectValue(i.newArray(nil)), nil
+	})
+	i.defineMethod(obj, "getPrototypeOf", func(_ Value, args []Value) (Value, error) {
+		v := argAt(args, 0)
+		switch v.Kind() {
+		case UndefinedKind, NullKind:
+			return Value{}, fmt.Errorf("TypeError: Cannot convert undefined or null to object")
+		case ObjectKind:
+			if proto := v.Object().Prototype(); proto != nil {
+				return NewObjectValue(proto), nil
+			}
+			return Null, nil
+		case StringKind:
+			return NewObjectValue(i.stringPrototype), nil
+		case NumberKind:
+			return NewObjectValue(i.numberPrototype), nil
+		default:
+			return Null, nil
+		}
+	})
+	i.defineMethod(obj, "setPrototypeOf", func(_ Value, args []Value) (Value, error) {
+		v, proto := argAt(args, 0), argAt(args, 1)
+		if isNullish(v) {
+			return Value{}, fmt.Errorf("TypeError: Object.setPrototypeOf called on null or undefined")
+		}
+		var protoObj *Object
+		switch proto.Kind() {
+		case ObjectKind:
+			protoObj = proto.Object()
+		case NullKind:
+		default:
+			return Value{}, fmt.Errorf("TypeError: Object prototype may only be an Object or null: %s", proto.Inspect())
+		}
+		if v.Kind() != ObjectKind {
+			return v, nil
+		}
+		if !v.Object().SetPrototype(protoObj) {
+			return Value{}, fmt.Errorf("TypeError: Cannot set prototype of %s", v.Inspect())
+		}
+		return v, nil
+	})
+	return ctor
+}
+
+// ownKeysArray returns the own string keys of the first argument as an
+// array, limited to enumerable keys when enumerableOnly is set.
+func (i *Interpreter) ownKeysArray(args []Value, enumerableOnly bool) (Value, error) {
+	keys, err := ownKeysOf(args, enumerableOnly)
+	if err != nil {
+		return Value{}, err
+	}
+	elems := make([]Value, len(keys))
+	for idx, key := range keys {
+		elems[idx] = NewString(key)
+	}
+	return NewObjectValue(i.newArray(elems)), nil
+}
+
+// ownEntriesArray reads each own enumerable property of args[0] in key
+// order and collects entry(key, value) into a new array.
+func (i *Interpreter) ownEntriesArray(args []Value, entry func(key string, v Value) Value) (Value, error) {
+	keys, err := ownKeysOf(args, true)
+	if err != nil {
+		return Value{}, err
+	}
+	elems := make([]Value, 0, len(keys))
+	for _, key := range keys {
+		v, err := i.getProperty(args[0], key)
+		if err != nil {
+			return Value{}, err
+		}
+		elems = append(elems, entry(key, v))
+	}
+	return NewObjectValue(i.newArray(elems)), nil
+}
+
+func ownKeysOf(args []Value, enumerableOnly bool) ([]string, error) {
+	v := Undefined
+	if len(args) > 0 {
+		v = args[0]
+	}
+	switch v.Kind() {
+	case UndefinedKind, NullKind:
+		return nil, fmt.Errorf("TypeError: Cannot convert undefined or null to object")
+	case StringKind:
+		// A string wrapper has an index per code unit and a non-enumerable length.
+		var keys []string
+		for idx := 0; idx < utf16Length(v.StringValue()); idx++ {
+			keys = append(keys, strconv.Itoa(idx))
+		}
+		if !enumerableOnly {
+			keys = append(keys, "length")
+		}
+		return keys, nil
+	case ObjectKind:
+		obj := v.Object()
+		var keys []string
+		for _, key := range obj.OwnKeys() {
+			if !enumerableOnly || obj.properties[key].enumerable {
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (i *Interpreter) newMathObject() *Object {
+	obj := NewObject(nil)
+	obj.class = "Math"
+	obj.DefineProperty("PI", NewNumber(math.Pi), false, false, false)
+	obj.DefineProperty("E", NewNumber(math.E), false, false, false)
+
+	unary := []struct {
+		name string
+		op   func(float64) float64
+	}{
+		{"abs", math.Abs},
+		{"ceil", math.Ceil},
+		{"floor", math.Floor},
+		{"round", mathRound},
+		{"sign", mathSign},
+		{"sqrt", math.Sqrt},
+		{"trunc", math.Trunc},
+	}
+	for _, entry := range unary {
+		op := entry.op
+		i.defineMethod(obj, entry.name, func(_ Value, args []Value) (Value, error) {
+			return NewNumber(op(numberArg(args, 0))), nil
+		})
+	}
+
+	i.defineMethod(obj, "pow", func(_ Value, args []Value) (Value, error) {
+		return NewNumber(math.Pow(numberArg(args, 0), numberArg(args, 1))), nil
+	})
+	i.defineMethod(obj, "max", func(_ Value, args []Value) (Value, error) {
+		result := math.Inf(-1)
+		for idx := range args {
+			n := numberArg(args, idx)
+			if math.IsNaN(n) {
+				return NewNumber(math.NaN()), nil
+			}
+			result = math.Max(result, n)
+		}
+		return NewNumber(result), nil
+	})
+	i.defineMethod(obj, "min", func(_ Value, args []Value) (Value, error) {
+		result := math.Inf(1)
+		for idx := range args {
+			n := numberArg(args, idx)
+			if math.IsNaN(n) {
+				return NewNumber(math.NaN()), nil
+			}
+			result = math.Min(result, n)
+		}
+		return NewNumber(result), nil
+	})
+	i.defineMethod(obj, "random", func(_ Value, _ []Value) (Value, error) {
+		return NewNumber(i.random.Float64()), nil
+	})
+	return obj
+}
+
+// SeedRandom reseeds the generator behind Math.random, making the sequence
+// it returns deterministic.
+func (i *Interpreter) SeedRandom(seed uint64) {
+	i.random = rand.New(rand.NewPCG(seed, 0))
+}
+
+// defineMethod installs a native function as a writable, non-enumerable
+// property, the layout used for built-in methods.
+func (i *Interpreter) defineMethod(obj *Object, name string, fn NativeFunction) {
+	obj.DefineProperty(name, i.newNativeFunction(name, fn), true, false, true)
+}
+
+// mathRound rounds half-way cases towards +Infinity, as Math.round does.
+// Values of at least 2^52 in magnitude are already integers, and values in
+// [-0.5, 0] round to zero of the same sign.
+func mathRound(x float64) float64 {
+	if math.IsNaN(x) || math.Abs(x) >= 1<<52 {
+		return x
+	}
+	if x >= -0.5 && x <= 0 {
+		return math.Copysign(0, x)
+	}
+	// Adding 0.5 before flooring could round up values just below a half.
+	r := math.Floor(x)
+	if x-r >= 0.5 {
+		r++
+	}
+	return r
+}
+
+func mathSign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return x
+	}
+}
+
+// numberArg converts the argument at idx to a number, treating missing
+// arguments as undefined.
+func numberArg(args []Value, idx int) float64 {
+	if idx >= len(args) {
+		return math.NaN()
+	}
+	return ToNumber(args[idx]).Number()
+}
@@ -0,0 +1,183 @@
+package vm
+
+import (
+	"fmt"
+
+	"es6-interpreter/ast"
+)
+
+// evalClass creates the constructor of a class declaration or expression.
+// An anonymous class takes name instead, as anonymous functions do.
+//
+// Construction follows the pre-class model used by new: the instance is
+// created before the constructor runs, and super(...) runs the parent
+// constructor against that same this value.
+func (i *Interpreter) evalClass(env *Environment, id *ast.Identifier, superClass ast.Expression, body *ast.ClassBody, name string) (Value, error) {
+	classEnv := env
+	if id != nil {
+		name = id.Name
+		// The class body sees its own name as an immutable binding.
+		classEnv = NewEnvironment(env)
+		if err := classEnv.Declare(name, BindingConst); err != nil {
+			return Value{}, err
+		}
+	}
+
+	var protoParent, ctorParent *Object
+	if superClass != nil {
+		parent, err := i.evalExpression(classEnv, superClass)
+		if err != nil {
+			return Value{}, err
+		}
+		if parent.Kind() != NullKind {
+			if !isConstructor(parent) {
+				return Value{}, fmt.Errorf("TypeError: Class extends value %s is not a constructor or null", parent.Inspect())
+			}
+			parentProto, err := i.getProperty(parent, "prototype")
+			if err != nil {
+				return Value{}, err
+			}
+			switch parentProto.Kind() {
+			case ObjectKind:
+				protoParent = parentProto.Object()
+			case NullKind:
+			default:
+				return Value{}, fmt.Errorf("TypeError: Class extends value does not have valid prototype property %s", parentProto.Inspect())
+			}
+			ctorParent = parent.Object()
+		}
+	}
+
+	proto := NewObject(protoParent)
+	fn := &function{
+		name:             name,
+		env:              classEnv,
+		strict:           true,
+		home:             proto,
+		classConstructor: true,
+	}
+	for _, elem := range body.Body {
+		if m, ok := elem.(*ast.MethodDefinition); ok && m.MethodKind == ast.MethodConstructor {
+			fn.params = m.Value.Params
+			fn.body = m.Value.Body
+			fn.slots, fn.flat = i.flatScope(m.Value)
+		}
+	}
+	if fn.body == nil {
+		fn.body = ast.NewBlockStatement(nil, body.Loc())
+		fn.defaultDerived = superClass != nil
+	}
+	ctor := i.newFunction(fn)
+	ctorObj := ctor.Object()
+	ctorObj.prototype = ctorParent
+	ctorObj.DefineProperty("prototype", NewObjectValue(proto), false, false, false)
+	proto.DefineProperty("constructor", ctor, true, false, true)
+
+	for _, elem := range body.Body {
+		switch e := elem.(type) {
+		case *ast.MethodDefinition:
+			if e.MethodKind == ast.MethodConstructor {
+				continue
+			}
+			target := proto
+			if e.Static {
+				target = ctorObj
+			}
+			if err := i.defineClassMethod(classEnv, target, e); err != nil {
+				return Value{}, err
+			}
+		case *ast.StaticBlock:
+			blockEnv := NewVariableEnvironment(classEnv)
+			blockEnv.strict = true
+			blockEnv.bindThis(ctor)
+			blockEnv.home = ctorObj
+			i.hoistVarDeclarations(blockEnv, e.Body)
+			if _, err := i.evalStatementList(blockEnv, e.Body); err != nil {
+				return Value{}, err
+			}
+		default:
+			return Value{}, fmt.Errorf("runtime error: class element %T not supported", e)
+		}
+	}
+
+	if id != nil {
+		if err := classEnv.Initialize(name, ctor); err != nil {
+			return Value{}, err
+		}
+	}
+	return ctor, nil
+}
+
+// defineClassMethod installs a method, getter or setter on target, which is
+// the prototype for instance members and the constructor for static ones.
+// Class members are not enumerable.
+func (i *Interpreter) defineClassMethod(env *Environment, target *Object, m *ast.MethodDefinition) error {
+	key, err := i.evalPropertyName(env, m.Key, m.Computed)
+	if err != nil {
+		return err
+	}
+	slots, flat := i.flatScope(m.Value)
+	fn := i.newFunction(&function{
+		name:      key,
+		params:    m.Value.Params,
+		body:      m.Value.Body,
+		env:       env,
+		strict:    true,
+		home:      target,
+		method:    true,
+		generator: m.Value.Generator,
+		flat:      flat,
+		slots:     slots,
+	}).Object()
+	switch m.MethodKind {
+	case ast.MethodGet:
+		target.DefineAccessor(key, fn, nil, false, true)
+	case ast.MethodSet:
+		target.DefineAccessor(key, nil, fn, false, true)
+	default:
+		target.DefineProperty(key, NewObjectValue(fn), true, false, true)
+	}
+	return nil
+}
+
+// callSuperConstructor runs the parent of the class constructor callee
+// against this, as super(...) does.
+func (i *Interpreter) callSuperConstructor(callee *Object, this Value, args []Value) error {
+	parent := callee.prototype
+	if parent == nil || !isConstructor(NewObjectValue(parent)) {
+		return fmt.Errorf("TypeError: Super constructor of %s is not a constructor", callee.function.name)
+	}
+	_, err := i.invoke(parent, this, args)
+	return err
+}
+
+// evalSuperCall evaluates super(...) inside a derived class constructor.
+func (i *Interpreter) evalSuperCall(env *Environment, call *ast.CallExpression) (Value, error) {
+	thisEnv := env.thisEnvironment()
+	if thisEnv == nil || thisEnv.callee == nil || !thisEnv.callee.function.classConstructor {
+		return Value{}, fmt.Errorf("SyntaxError: 'super' keyword unexpected here")
+	}
+	args, err := i.evalArguments(env, call.Arguments)
+	if err != nil {
+		return Value{}, err
+	}
+	return Undefined, i.callSuperConstructor(thisEnv.callee, thisEnv.thisValue, args)
+}
+
+// evalSuperProperty reads super[key]: key is looked up from the prototype of
+// the current method's home object, and getters run with the current this.
+func (i *Interpreter) evalSuperProperty(env *Environment, member *ast.MemberExpression) (Value, error) {
+	thisEnv := env.thisEnvironment()
+	if thisEnv == nil || thisEnv.home == nil {
+		return Value{}, fmt.Errorf("SyntaxError: 'super' keyword unexpected here")
+	}
+	key, err := i.evalPropertyKey(env, member)
+	if err != nil {
+		return Value{}, err
+	}
+	proto := thisEnv.home.prototype
+	if proto == nil {
+		return Undefined, nil
+	}
+	return i.getObjectProperty(proto, thisEnv.thisValue, key)
+}
@@ -0,0 +1,81 @@
+package vm
+
+import (
+	"context"
+	"maps"
+
+	"es6-interpreter/analysis"
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+// CompiledProgram is a script that has been parsed and analyzed once so it
+// can be run many times with RunCompiled. It is never modified after Compile
+// returns and may be shared between interpreters.
+type CompiledProgram struct {
+	program    *ast.Program
+	flatScopes map[ast.Node]flatScope
+}
+
+// Compile parses src and precomputes the scope analysis of every function in
+// it.
+func Compile(src string) (*CompiledProgram, error) {
+	program, err := parser.New(src).ParseProgram()
+	if err != nil {
+		return nil, err
+	}
+	compiled := &CompiledProgram{
+		program:    program,
+		flatScopes: make(map[ast.Node]flatScope),
+	}
+	compiled.analyze(program)
+	return compiled, nil
+}
+
+func (c *CompiledProgram) analyze(n ast.Node) {
+	switch n.(type) {
+	case *ast.FunctionDeclaration, *ast.FunctionExpression, *ast.ArrowFunctionExpression:
+		slots, flat := analysis.FlatScope(n)
+		c.flatScopes[n] = flatScope{slots: slots, flat: flat}
+	}
+	for _, child := range ast.Children(n) {
+		c.analyze(child)
+	}
+}
+
+// Program returns the parsed program.
+func (c *CompiledProgram) Program() *ast.Program { return c.program }
+
+// RunCompiled executes prog in a fresh global scope holding the standard
+// built-ins and the supplied globals. Bindings from earlier runs, from
+// Execute or from DefineFunction are not visible, so runs are independent of
+// each other. The interpreter's sandboxing, output, random source and
+// MaxCallDepth apply.
+func (i *Interpreter) RunCompiled(prog *CompiledProgram, globals map[string]Value) (Value, error) {
+	run := i.compiledRun(prog, globals)
+	defer run.Close()
+	return run.Execute(prog.program)
+}
+
+// RunCompiledContext is like RunCompiled but stops running prog once ctx is
+// done, as ExecuteContext does.
+func (i *Interpreter) RunCompiledContext(ctx context.Context, prog *CompiledProgram, globals map[string]Value) (Value, error) {
+	run := i.compiledRun(prog, globals)
+	defer run.Close()
+	return run.ExecuteContext(ctx, prog.program)
+}
+
+// compiledRun creates the interpreter a run of prog executes on.
+func (i *Interpreter) compiledRun(prog *CompiledProgram, globals map[string]Value) *Interpreter {
+	run := newInterpreter(i.sandboxed)
+	run.output = i.output
+	run.random = i.random
+	run.MaxCallDepth = i.MaxCallDepth
+	run.noFlatScopes = i.noFlatScopes
+	// Each run gets its own copy, since flatScope caches into the map.
+	run.flatScopes = maps.Clone(prog.flatScopes)
+	for name, v := range globals {
+		run.defineGlobal(name, v)
+	}
+	return run
+}
@@ -0,0 +1,41 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SetOutput directs console output to w. Interpreters write to standard
+// output until it is called.
+func (i *Interpreter) SetOutput(w io.Writer) { i.output = w }
+
+// newConsoleObject builds the console global. log, error and warn all write
+// one line to the interpreter's output.
+func (i *Interpreter) newConsoleObject() *Object {
+	obj := NewObject(nil)
+	obj.class = "console"
+	for _, name := range []string{"log", "error", "warn"} {
+		i.defineMethod(obj, name, func(_ Value, args []Value) (Value, error) {
+			if _, err := fmt.Fprintln(i.output, formatConsoleArgs(args)); err != nil {
+				return Value{}, fmt.Errorf("runtime error: console output failed: %v", err)
+			}
+			return Undefined, nil
+		})
+	}
+	return obj
+}
+
+// formatConsoleArgs joins console arguments with spaces. Strings are written
+// as-is; other values use their Inspect form.
+func formatConsoleArgs(args []Value) string {
+	parts := make([]string, len(args))
+	for idx, arg := range args {
+		if arg.Kind() == StringKind {
+			parts[idx] = arg.StringValue()
+		} else {
+			parts[idx] = arg.Inspect()
+		}
+	}
+	return strings.Join(parts, " ")
+}
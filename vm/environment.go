@@ -16,14 +16,47 @@ type binding struct {
 	mutable     bool
 	initialized bool
 	kind        BindingKind
+	// readOnly marks the non-writable globals such as undefined and NaN.
+	// Assigning them is ignored in sloppy code and a TypeError in strict
+	// code, unlike assigning a const.
+	readOnly bool
+
+	// importEnv is set for bindings created by import declarations, which
+	// read the binding importName of the exporting module's environment and
+	// cannot be assigned.
+	importEnv  *Environment
+	importName string
+}
+
+// slot holds a binding of a flat environment; declared is false until the
+// binding is created, so a slot behaves like a missing map entry before then.
+type slot struct {
+	binding
+	declared bool
 }
 
 // Environment models a lexical environment (scope) with an optional outer scope.
+// Environments of functions that create no closures keep the names known
+// ahead of time in slots, falling back to record for any others.
 type Environment struct {
 	outer     *Environment
 	record    map[string]*binding
+	names     []string // slot names, shared by every call of a function
+	slots     []slot
 	varParent *Environment
 	isVarEnv  bool
+
+	thisValue Value
+	hasThis   bool
+	strict    bool // meaningful on var environments only
+
+	// home is the object holding the method being run, whose prototype
+	// super property references search. callee is the function itself,
+	// whose prototype is the parent constructor called by super(...).
+	home   *Object
+	callee *Object
+	// generator is set on the var environment of a generator body.
+	generator *generator
 }
 
 // NewEnvironment creates a new environment with the provided outer environment.
@@ -53,6 +86,34 @@ func NewVariableEnvironment(outer *Environment) *Environment {
 	return env
 }
 
+// NewSlotEnvironment constructs a var environment like NewVariableEnvironment
+// whose bindings for names are stored in a slice rather than a map. names is
+// not copied and must not be modified afterwards.
+func NewSlotEnvironment(outer *Environment, names []string) *Environment {
+	env := &Environment{
+		outer:    outer,
+		names:    names,
+		slots:    make([]slot, len(names)),
+		isVarEnv: true,
+	}
+	env.varParent = env
+	return env
+}
+
+// own returns the binding for name held directly by this environment.
+func (e *Environment) own(name string) (*binding, bool) {
+	for idx, n := range e.names {
+		if n == name {
+			if !e.slots[idx].declared {
+				return nil, false
+			}
+			return &e.slots[idx].binding, true
+		}
+	}
+	b, ok := e.record[name]
+	return b, ok
+}
+
 // Outer returns the parent environment.
 func (e *Environment) Outer() *Environment { return e.outer }
 
@@ -66,7 +127,7 @@ func (e *Environment) VarParent() *Environment {
 
 // HasOwn reports whether the current environment contains a binding for name.
 func (e *Environment) HasOwn(name string) bool {
-	_, ok := e.record[name]
+	_, ok := e.own(name)
 	return ok
 }
 
@@ -84,7 +145,7 @@ func (e *Environment) targetFor(kind BindingKind) *Environment {
 // Redeclaring a var in the same scope is a no-op.
 func (e *Environment) Declare(name string, kind BindingKind) error {
 	target := e.targetFor(kind)
-	if existing, ok := target.record[name]; ok {
+	if existing, ok := target.own(name); ok {
 		if kind == BindingVar && existing.kind == BindingVar {
 			return nil
 		}
@@ -105,6 +166,15 @@ func (e *Environment) Declare(name string, kind BindingKind) error {
 		return fmt.Errorf("internal error: unknown binding kind %d", kind)
 	}
 
+	for idx, n := range target.names {
+		if n == name {
+			target.slots[idx] = slot{binding: *b, declared: true}
+			return nil
+		}
+	}
+	if target.record == nil {
+		target.record = make(map[string]*binding)
+	}
 	target.record[name] = b
 	return nil
 }
@@ -112,7 +182,7 @@ func (e *Environment) Declare(name string, kind BindingKind) error {
 // Initialize assigns the first value to a previously declared binding in the
 // current environment. It is primarily used for let/const declarations.
 func (e *Environment) Initialize(name string, value Value) error {
-	b, ok := e.record[name]
+	b, ok := e.own(name)
 	if !ok {
 		return fmt.Errorf("ReferenceError: %s is not defined", name)
 	}
@@ -125,10 +195,29 @@ func (e *Environment) Initialize(name string, value Value) error {
 	return nil
 }
 
+// declareImport creates an immutable binding for name that reads the binding
+// importName of env, so updates made by the exporting module are visible.
+func (e *Environment) declareImport(name string, env *Environment, importName string) error {
+	if err := e.Declare(name, BindingConst); err != nil {
+		return err
+	}
+	b, _ := e.own(name)
+	b.importEnv = env
+	b.importName = importName
+	b.initialized = true
+	return nil
+}
+
 // Get returns the value bound to name, searching outward through parent
 // environments.
 func (e *Environment) Get(name string) (Value, error) {
-	if b, ok := e.record[name]; ok {
+	if b, ok := e.own(name); ok {
+		if b.importEnv != nil {
+			b, ok = b.importEnv.own(b.importName)
+			if !ok {
+				return Value{}, fmt.Errorf("ReferenceError: Cannot access '%s' before initialization", name)
+			}
+		}
 		if !b.initialized {
 			return Value{}, fmt.Errorf("ReferenceError: Cannot access '%s' before initialization", name)
 		}
@@ -143,25 +232,32 @@ func (e *Environment) Get(name string) (Value, error) {
 // Set updates the value bound to name, searching outward through parent
 // environments. Attempting to update an immutable binding yields an error.
 func (e *Environment) Set(name string, value Value) error {
-	if b, ok := e.record[name]; ok {
+	for env := e; env != nil; env = env.outer {
+		b, ok := env.own(name)
+		if !ok {
+			continue
+		}
 		if !b.initialized {
 			return fmt.Errorf("ReferenceError: Cannot access '%s' before initialization", name)
 		}
+		if b.readOnly {
+			if e.isStrict() {
+				return fmt.Errorf("TypeError: Cannot assign to read only property '%s' of object", name)
+			}
+			return nil
+		}
 		if !b.mutable {
 			return fmt.Errorf("TypeError: Assignment to constant variable %q", name)
 		}
 		b.value = value
 		return nil
 	}
-	if e.outer != nil {
-		return e.outer.Set(name, value)
-	}
 	return fmt.Errorf("ReferenceError: %s is not defined", name)
 }
 
 // Resolve finds the binding entry for name, searching through outer environments.
 func (e *Environment) Resolve(name string) (*binding, bool) {
-	if b, ok := e.record[name]; ok {
+	if b, ok := e.own(name); ok {
 		return b, true
 	}
 	if e.outer != nil {
@@ -169,3 +265,33 @@ func (e *Environment) Resolve(name string) (*binding, bool) {
 	}
 	return nil, false
 }
+
+// bindThis records the this value provided to a function environment.
+func (e *Environment) bindThis(v Value) {
+	e.thisValue = v
+	e.hasThis = true
+}
+
+// This resolves the nearest this binding, skipping environments such as arrow
+// function scopes that do not provide their own.
+func (e *Environment) This() Value {
+	if env := e.thisEnvironment(); env != nil {
+		return env.thisValue
+	}
+	return Undefined
+}
+
+// thisEnvironment returns the nearest environment that binds this.
+func (e *Environment) thisEnvironment() *Environment {
+	for cur := e; cur != nil; cur = cur.outer {
+		if cur.hasThis {
+			return cur
+		}
+	}
+	return nil
+}
+
+// isStrict reports whether code running in this environment is strict mode code.
+func (e *Environment) isStrict() bool {
+	return e.VarParent().strict
+}
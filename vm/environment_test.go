@@ -1,6 +1,10 @@
 package vm
 
-import "testing"
+import (
+	"testing"
+
+	"es6-interpreter/parser"
+)
 
 func TestEnvironmentLetLifecycle(t *testing.T) {
 	env := NewEnvironment(nil)
@@ -106,3 +110,90 @@ func TestEnvironmentSetBeforeInitialization(t *testing.T) {
 		t.Fatalf("expected error when assigning before initialization")
 	}
 }
+
+func TestSlotEnvironmentFallsBackToRecord(t *testing.T) {
+	env := NewSlotEnvironment(nil, []string{"a"})
+	if env.HasOwn("a") {
+		t.Fatalf("slot binding should not exist before it is declared")
+	}
+	if err := env.Declare("a", BindingLet); err != nil {
+		t.Fatalf("declare slot binding: %v", err)
+	}
+	if err := env.Declare("a", BindingLet); err == nil {
+		t.Fatalf("expected redeclaration of slot binding to fail")
+	}
+	if err := env.Declare("extra", BindingVar); err != nil {
+		t.Fatalf("declare binding outside the slots: %v", err)
+	}
+	if err := env.Initialize("a", NewNumber(1)); err != nil {
+		t.Fatalf("initialize slot binding: %v", err)
+	}
+	if err := env.Set("extra", NewNumber(2)); err != nil {
+		t.Fatalf("set extra: %v", err)
+	}
+	a, _ := env.Get("a")
+	extra, _ := env.Get("extra")
+	if !StrictEquals(a, NewNumber(1)) || !StrictEquals(extra, NewNumber(2)) {
+		t.Fatalf("unexpected values a=%s extra=%s", a.Inspect(), extra.Inspect())
+	}
+}
+
+// callHeavyScript spends nearly all its time calling small functions that
+// create no closures.
+const callHeavyScript = `
+function add(a, b) { let sum = a + b; return sum; }
+function fib(n) { if (n < 2) { return n; } return add(fib(n - 1), fib(n - 2)); }
+var total = 0;
+for (var i = 0; i < 5; i = i + 1) {
+  total = total + fib(12);
+}
+total;
+`
+
+func runWithFlatScopes(tb testing.TB, src string, flat bool) (Value, error) {
+	tb.Helper()
+	program, err := parser.New(src).ParseProgram()
+	if err != nil {
+		tb.Fatalf("parse error: %v", err)
+	}
+	intr := NewInterpreter()
+	intr.noFlatScopes = !flat
+	return intr.Execute(program)
+}
+
+func TestFlatScopesMatchMapEnvironments(t *testing.T) {
+	scripts := []string{
+		callHeavyScript,
+		`function f(a, b = a * 2) { var a; if (a) { let a = 10; b = b + a; } return a + b; } f(1) + f(0);`,
+		`function g(n) { var out = ""; for (let i = 0; i < n; i = i + 1) { out = out + i; } return out + typeof undeclared; } g(3);`,
+		`function h() { "use strict"; const c = 1; try { c = 2; } catch (e) { return "caught"; } } h();`,
+		`function tdz() { try { x; } catch (e) { return "tdz"; } let x = 1; } tdz();`,
+		`function outer() { let v = 1; const inner = () => v + 1; v = 5; return inner(); } outer();`,
+	}
+	for _, src := range scripts {
+		withSlots, errSlots := runWithFlatScopes(t, src, true)
+		withMaps, errMaps := runWithFlatScopes(t, src, false)
+		if (errSlots == nil) != (errMaps == nil) {
+			t.Fatalf("%s: error mismatch: slots=%v maps=%v", src, errSlots, errMaps)
+		}
+		if errSlots == nil && withSlots.Inspect() != withMaps.Inspect() {
+			t.Fatalf("%s: result mismatch: slots=%s maps=%s", src, withSlots.Inspect(), withMaps.Inspect())
+		}
+	}
+}
+
+func BenchmarkCallsWithFlatScopes(b *testing.B) {
+	for range b.N {
+		if _, err := runWithFlatScopes(b, callHeavyScript, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCallsWithMapScopes(b *testing.B) {
+	for range b.N {
+		if _, err := runWithFlatScopes(b, callHeavyScript, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"es6-interpreter/ast"
+)
+
+// Exception carries a thrown ECMAScript value through the interpreter. It is
+// kept distinct from internal Go errors so that try statements can intercept
+// it and hand the original value to the catch clause.
+type Exception struct {
+	Value Value
+}
+
+// NewException wraps a thrown value.
+func NewException(v Value) *Exception {
+	return &Exception{Value: v}
+}
+
+// Error renders the exception the way an uncaught throw is reported.
+func (e *Exception) Error() string {
+	return "Uncaught " + ToString(e.Value).StringValue()
+}
+
+// nativeErrorNames lists the error prefixes produced by the runtime itself
+// (environment lookups, const assignment, ...) that scripts may catch.
+var nativeErrorNames = []string{"Error", "TypeError", "ReferenceError", "RangeError", "SyntaxError"}
+
+// asException reports whether err is catchable by a script. Thrown values
+// are returned as-is; runtime errors carrying a native error prefix are
+// surfaced to the catch clause as their message string.
+func asException(err error) (*Exception, bool) {
+	var exc *Exception
+	if errors.As(err, &exc) {
+		return exc, true
+	}
+	msg := err.Error()
+	var located *RuntimeError
+	if errors.As(err, &located) {
+		// Scripts see the message without the position.
+		msg = located.Err.Error()
+	}
+	for _, name := range nativeErrorNames {
+		if strings.HasPrefix(msg, name+":") {
+			return NewException(NewString(msg)), true
+		}
+	}
+	return nil, false
+}
+
+// RuntimeError is an error raised by the runtime itself, such as a
+// ReferenceError for an undeclared name, located at the innermost node that
+// was being evaluated. Values thrown by scripts stay *Exception.
+type RuntimeError struct {
+	Err error
+	// Position is where the node starts. Its column is zero-based; Error
+	// reports it one-based.
+	Position ast.Position
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s (%d:%d)", e.Err, e.Position.Line, e.Position.Column+1)
+}
+
+func (e *RuntimeError) Unwrap() error { return e.Err }
+
+// located wraps err in a RuntimeError at node unless it already carries a
+// position, is a thrown value or unwinds a generator.
+func located(node ast.Node, err error) error {
+	switch err.(type) {
+	case *RuntimeError, *Exception, *generatorReturn, *generatorAbort:
+		return err
+	}
+	loc := node.Loc()
+	if !loc.IsValid() {
+		return err
+	}
+	return &RuntimeError{Err: err, Position: loc.Start}
+}
@@ -0,0 +1,447 @@
+package vm
+
+import (
+	"fmt"
+
+	"es6-interpreter/analysis"
+	"es6-interpreter/ast"
+)
+
+// function holds the closure state of a script-defined callable.
+type function struct {
+	name           string
+	params         []ast.Pattern
+	body           ast.Node // *ast.BlockStatement, or an Expression for concise arrow bodies
+	expressionBody bool
+	arrow          bool
+	env            *Environment
+	strict         bool
+	native         NativeFunction // set for built-ins, which have no body
+
+	// Class members: home is the object a method was defined on, for super
+	// property references. Methods are not constructors, and class
+	// constructors can only be called with new.
+	home             *Object
+	method           bool
+	generator        bool
+	classConstructor bool
+	defaultDerived   bool // an omitted constructor of a class with extends
+
+	// flat is set for functions that create no closures; their calls keep
+	// the locals named in slots in a slice-backed environment.
+	flat  bool
+	slots []string
+}
+
+func (i *Interpreter) newFunction(fn *function) Value {
+	obj := NewObject(nil)
+	obj.class = "Function"
+	obj.function = fn
+	obj.DefineProperty("length", NewNumber(float64(expectedArgumentCount(fn.params))), false, false, true)
+	obj.DefineProperty("name", NewString(fn.name), false, false, true)
+	switch {
+	case fn.generator:
+		// The prototype of the generator objects the function returns.
+		proto := NewObject(i.generatorPrototype)
+		obj.DefineProperty("prototype", NewObjectValue(proto), true, false, false)
+	case fn.native == nil && !fn.arrow && !fn.method:
+		// The prototype given to objects the function constructs.
+		proto := NewObject(nil)
+		proto.DefineProperty("constructor", NewObjectValue(obj), true, false, true)
+		obj.DefineProperty("prototype", NewObjectValue(proto), true, false, false)
+	}
+	return NewObjectValue(obj)
+}
+
+// expectedArgumentCount is the length of a function: the number of
+// parameters before the first one with a default value or a rest element.
+func expectedArgumentCount(params []ast.Pattern) int {
+	for idx, param := range params {
+		switch param.(type) {
+		case *ast.AssignmentPattern, *ast.RestElement:
+			return idx
+		}
+	}
+	return len(params)
+}
+
+// evalNamedExpression evaluates expr, giving it name when it is an anonymous
+// function definition, as the right-hand side of a binding or property is
+// named after its target.
+func (i *Interpreter) evalNamedExpression(env *Environment, expr ast.Expression, name string) (Value, error) {
+	switch e := expr.(type) {
+	case *ast.FunctionExpression:
+		if e.ID == nil {
+			return i.newFunctionFromExpression(env, e, name), nil
+		}
+	case *ast.ArrowFunctionExpression:
+		return i.newArrowFunction(env, e, name), nil
+	case *ast.ClassExpression:
+		if e.ID == nil {
+			return i.evalClass(env, nil, e.SuperClass, e.Body, name)
+		}
+	}
+	return i.evalExpression(env, expr)
+}
+
+// flatScope returns the analysis.FlatScope result for the function node fn,
+// computing it once per node.
+func (i *Interpreter) flatScope(fn ast.Node) ([]string, bool) {
+	if i.noFlatScopes {
+		return nil, false
+	}
+	if cached, ok := i.flatScopes[fn]; ok {
+		return cached.slots, cached.flat
+	}
+	slots, flat := analysis.FlatScope(fn)
+	if i.flatScopes == nil {
+		i.flatScopes = make(map[ast.Node]flatScope)
+	}
+	i.flatScopes[fn] = flatScope{slots: slots, flat: flat}
+	return slots, flat
+}
+
+func (i *Interpreter) newFunctionFromDeclaration(env *Environment, decl *ast.FunctionDeclaration) Value {
+	slots, flat := i.flatScope(decl)
+	return i.newFunction(&function{
+		name:      decl.ID.Name,
+		params:    decl.Params,
+		body:      decl.Body,
+		env:       env,
+		strict:    env.isStrict() || decl.Strict,
+		generator: decl.Generator,
+		flat:      flat,
+		slots:     slots,
+	})
+}
+
+// newFunctionFromExpression creates the closure for a function expression.
+// Anonymous expressions, such as object literal methods, take name instead.
+// A named expression closes over a scope that binds its own name to the
+// function, so it can refer to itself recursively.
+func (i *Interpreter) newFunctionFromExpression(env *Environment, expr *ast.FunctionExpression, name string) Value {
+	closureEnv := env
+	if expr.ID != nil {
+		name = expr.ID.Name
+		closureEnv = NewEnvironment(env)
+		_ = closureEnv.Declare(name, BindingConst)
+	}
+	slots, flat := i.flatScope(expr)
+	fn := i.newFunction(&function{
+		name:      name,
+		params:    expr.Params,
+		body:      expr.Body,
+		env:       closureEnv,
+		strict:    env.isStrict() || expr.Strict,
+		generator: expr.Generator,
+		flat:      flat,
+		slots:     slots,
+	})
+	if expr.ID != nil {
+		_ = closureEnv.Initialize(name, fn)
+	}
+	return fn
+}
+
+func (i *Interpreter) newArrowFunction(env *Environment, arrow *ast.ArrowFunctionExpression, name string) Value {
+	slots, flat := i.flatScope(arrow)
+	return i.newFunction(&function{
+		name:           name,
+		params:         arrow.Params,
+		body:           arrow.Body,
+		expressionBody: arrow.ExpressionBody,
+		arrow:          true,
+		env:            env,
+		strict:         env.isStrict() || arrow.Strict,
+		flat:           flat,
+		slots:          slots,
+	})
+}
+
+// callFunction invokes callee with the provided receiver and arguments.
+func (i *Interpreter) callFunction(callee Value, this Value, args []Value) (Value, error) {
+	if !callee.IsCallable() {
+		return Value{}, fmt.Errorf("TypeError: %s is not a function", callee.Inspect())
+	}
+	if fn := callee.obj.function; fn.classConstructor {
+		return Value{}, fmt.Errorf("TypeError: Class constructor %s cannot be invoked without 'new'", fn.name)
+	}
+	return i.invoke(callee.obj, this, args)
+}
+
+// invoke runs the callable object callee without the checks callFunction
+// makes on behalf of ordinary calls.
+func (i *Interpreter) invoke(callee *Object, this Value, args []Value) (Value, error) {
+	if i.MaxCallDepth > 0 && i.callDepth >= i.MaxCallDepth {
+		return Value{}, fmt.Errorf("RangeError: Maximum call stack size exceeded")
+	}
+	i.callDepth++
+	defer func() { i.callDepth-- }()
+
+	fn := callee.function
+	if fn.native != nil {
+		return fn.native(this, args)
+	}
+	if fn.defaultDerived {
+		// An omitted derived constructor passes its arguments on.
+		return Undefined, i.callSuperConstructor(callee, this, args)
+	}
+
+	var env *Environment
+	if fn.flat {
+		env = NewSlotEnvironment(fn.env, fn.slots)
+	} else {
+		env = NewVariableEnvironment(fn.env)
+	}
+	env.strict = fn.strict
+	if !fn.arrow {
+		// Sloppy functions called without a receiver see the global object.
+		if !fn.strict && isNullish(this) {
+			this = NewObjectValue(i.globalObject)
+		}
+		env.bindThis(this)
+		env.home = fn.home
+		env.callee = callee
+	}
+	if err := i.bindParameters(env, fn.params, args); err != nil {
+		return Value{}, err
+	}
+
+	if fn.generator {
+		return NewObjectValue(i.newGenerator(callee, env)), nil
+	}
+	return i.runBody(env, fn)
+}
+
+// runBody evaluates the body of fn in env, which holds its parameters.
+func (i *Interpreter) runBody(env *Environment, fn *function) (Value, error) {
+	if fn.expressionBody {
+		body, ok := fn.body.(ast.Expression)
+		if !ok {
+			return Value{}, fmt.Errorf("runtime error: invalid concise function body %T", fn.body)
+		}
+		return i.evalExpression(env, body)
+	}
+
+	block, ok := fn.body.(*ast.BlockStatement)
+	if !ok {
+		return Value{}, fmt.Errorf("runtime error: invalid function body %T", fn.body)
+	}
+	i.hoistVarDeclarations(env, block.Body)
+	comp, err := i.evalStatementList(env, block.Body)
+	if err != nil {
+		return Value{}, err
+	}
+	switch comp.kind {
+	case completionReturn:
+		return comp.value, nil
+	case completionNormal:
+		return Undefined, nil
+	default:
+		return Value{}, fmt.Errorf("runtime error: unexpected %s outside of loop", i.describeCompletion(comp))
+	}
+}
+
+// construct creates an object whose prototype is the prototype property of
+// callee and runs callee with it as this. The object is the result unless
+// callee returns an object of its own.
+func (i *Interpreter) construct(callee Value, args []Value) (Value, error) {
+	if !isConstructor(callee) {
+		return Value{}, fmt.Errorf("TypeError: %s is not a constructor", callee.Inspect())
+	}
+	proto, err := i.getProperty(callee, "prototype")
+	if err != nil {
+		return Value{}, err
+	}
+	var protoObj *Object
+	if proto.Kind() == ObjectKind {
+		protoObj = proto.Object()
+	}
+	obj := NewObjectValue(NewObject(protoObj))
+	result, err := i.invoke(callee.obj, obj, args)
+	if err != nil {
+		return Value{}, err
+	}
+	if result.Kind() == ObjectKind {
+		return result, nil
+	}
+	return obj, nil
+}
+
+// isConstructor reports whether v can be used with new. Arrow functions and
+// methods cannot.
+func isConstructor(v Value) bool {
+	if !v.IsCallable() {
+		return false
+	}
+	fn := v.obj.function
+	return !fn.arrow && !fn.method && !fn.generator
+}
+
+// bindParameters binds args to params in env. Each parameter may be any
+// binding pattern; a trailing rest parameter receives a fresh array holding
+// the remaining arguments.
+func (i *Interpreter) bindParameters(env *Environment, params []ast.Pattern, args []Value) error {
+	for idx, param := range params {
+		for _, name := range patternNames(param) {
+			if err := env.Declare(name, BindingVar); err != nil {
+				return err
+			}
+		}
+
+		if rest, ok := param.(*ast.RestElement); ok {
+			var remaining []Value
+			if idx < len(args) {
+				remaining = append(remaining, args[idx:]...)
+			}
+			if err := i.bindPattern(env, rest.Argument, NewObjectValue(i.newArray(remaining)), BindingVar); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := i.bindPattern(env, param, argAt(args, idx), BindingVar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// instantiateFunctionDeclarations binds every function declared directly in
+// stmts before the list runs, so functions may be called ahead of their
+// declaration. Declarations are var-scoped at function and script level and
+// lexically scoped inside blocks.
+func (i *Interpreter) instantiateFunctionDeclarations(env *Environment, stmts []ast.Statement) error {
+	for _, stmt := range stmts {
+		decl, ok := stmt.(*ast.FunctionDeclaration)
+		if !ok {
+			continue
+		}
+		fn := i.newFunctionFromDeclaration(env, decl)
+		name := decl.ID.Name
+		if env.isVarEnv {
+			if err := env.Declare(name, BindingVar); err != nil {
+				return err
+			}
+			if err := env.Set(name, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if env.HasOwn(name) {
+			if err := env.Set(name, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := env.Declare(name, BindingLet); err != nil {
+			return err
+		}
+		if err := env.Initialize(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hoistVarDeclarations declares every var binding reachable from stmts without
+// crossing a function boundary, so reads before the declaration see undefined.
+func (i *Interpreter) hoistVarDeclarations(env *Environment, stmts []ast.Statement) {
+	var names []string
+	for _, stmt := range stmts {
+		collectVarNames(stmt, &names)
+	}
+	target := env.VarParent()
+	for _, name := range names {
+		// Declaring a var twice is a no-op; collisions with lexical bindings
+		// are reported when the declaration itself executes.
+		_ = target.Declare(name, BindingVar)
+	}
+}
+
+func collectVarNames(node ast.Node, names *[]string) {
+	switch s := node.(type) {
+	case *ast.VariableDeclaration:
+		if s.DeclareKind != ast.VarKind {
+			return
+		}
+		for _, d := range s.Declarations {
+			*names = append(*names, patternNames(d.ID)...)
+		}
+	case *ast.BlockStatement:
+		for _, inner := range s.Body {
+			collectVarNames(inner, names)
+		}
+	case *ast.IfStatement:
+		collectVarNames(s.Consequent, names)
+		if s.Alternate != nil {
+			collectVarNames(s.Alternate, names)
+		}
+	case *ast.WhileStatement:
+		collectVarNames(s.Body, names)
+	case *ast.DoWhileStatement:
+		collectVarNames(s.Body, names)
+	case *ast.ForStatement:
+		if s.Init != nil {
+			collectVarNames(s.Init, names)
+		}
+		collectVarNames(s.Body, names)
+	case *ast.ForInStatement:
+		collectVarNames(s.Left, names)
+		collectVarNames(s.Body, names)
+	case *ast.ForOfStatement:
+		collectVarNames(s.Left, names)
+		collectVarNames(s.Body, names)
+	case *ast.LabeledStatement:
+		collectVarNames(s.Body, names)
+	case *ast.WithStatement:
+		collectVarNames(s.Body, names)
+	case *ast.TryStatement:
+		collectVarNames(s.Block, names)
+		if s.Handler != nil {
+			collectVarNames(s.Handler.Body, names)
+		}
+		if s.Finalizer != nil {
+			collectVarNames(s.Finalizer, names)
+		}
+	case *ast.SwitchStatement:
+		for _, c := range s.Cases {
+			for _, inner := range c.Consequent {
+				collectVarNames(inner, names)
+			}
+		}
+	}
+}
+
+// patternNames lists the identifiers bound by a binding pattern.
+func patternNames(pattern ast.Pattern) []string {
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		return []string{p.Name}
+	case *ast.AssignmentPattern:
+		return patternNames(p.Left)
+	case *ast.RestElement:
+		return patternNames(p.Argument)
+	case *ast.ArrayPattern:
+		var names []string
+		for _, elem := range p.Elements {
+			if elem != nil {
+				names = append(names, patternNames(elem)...)
+			}
+		}
+		if p.Rest != nil {
+			names = append(names, patternNames(p.Rest)...)
+		}
+		return names
+	case *ast.ObjectPattern:
+		var names []string
+		for _, prop := range p.Properties {
+			names = append(names, patternNames(prop.Value)...)
+		}
+		if p.Rest != nil {
+			names = append(names, patternNames(p.Rest)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,304 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"es6-interpreter/ast"
+)
+
+type generatorState int
+
+const (
+	generatorSuspendedStart generatorState = iota
+	generatorSuspendedYield
+	generatorRunning
+	generatorDone
+)
+
+type resumeMode int
+
+const (
+	resumeNext resumeMode = iota
+	resumeThrow
+	resumeReturn
+	// resumeAbort unwinds a suspended body without running any more script
+	// code, once the program that created it has finished.
+	resumeAbort
+)
+
+// generatorResume is sent to a suspended generator body by next, throw and
+// return.
+type generatorResume struct {
+	mode  resumeMode
+	value Value
+}
+
+// generatorYield is sent back by the body when it yields or finishes.
+type generatorYield struct {
+	value Value
+	done  bool
+	err   error
+}
+
+// generator holds the state of a generator object. Its body runs on a
+// goroutine of its own that hands control back and forth with the caller
+// over unbuffered channels, so only one side ever runs at a time. A body
+// left suspended keeps its goroutine parked until the generator is resumed
+// to completion, closed with return, or aborted by Interpreter.Close.
+type generator struct {
+	fn     *function
+	env    *Environment
+	state  generatorState
+	resume chan generatorResume
+	yield  chan generatorYield
+}
+
+// generatorReturn unwinds a generator body closed by return. It is not
+// catchable by scripts, but finally blocks run as it passes through.
+type generatorReturn struct {
+	value Value
+}
+
+func (r *generatorReturn) Error() string { return "generator closed" }
+
+// generatorAbort unwinds a suspended generator body aborted by Close.
+// Neither catch nor finally blocks run as it passes through.
+type generatorAbort struct{}
+
+func (*generatorAbort) Error() string { return "generator aborted" }
+
+func isGeneratorAbort(err error) bool {
+	var abort *generatorAbort
+	return errors.As(err, &abort)
+}
+
+// newGenerator creates the generator object returned by calling the
+// generator function callee, whose parameters are already bound in env.
+func (i *Interpreter) newGenerator(callee *Object, env *Environment) *Object {
+	proto := i.generatorPrototype
+	if p, ok := callee.GetOwn("prototype"); ok && p.Kind() == ObjectKind {
+		proto = p.Object()
+	}
+	obj := NewObject(proto)
+	obj.class = "Generator"
+	env.generator = &generator{fn: callee.function, env: env}
+	obj.generator = env.generator
+	return obj
+}
+
+func (i *Interpreter) newGeneratorPrototype() *Object {
+	proto := NewObject(nil)
+	proto.class = "Generator"
+	resumeWith := func(mode resumeMode) NativeFunction {
+		return func(this Value, args []Value) (Value, error) {
+			if this.Kind() != ObjectKind || this.Object().generator == nil {
+				return Value{}, fmt.Errorf("TypeError: next method called on incompatible receiver %s", this.Inspect())
+			}
+			return i.resumeGenerator(this.Object().generator, generatorResume{mode: mode, value: argAt(args, 0)})
+		}
+	}
+	i.defineMethod(proto, "next", resumeWith(resumeNext))
+	i.defineMethod(proto, "throw", resumeWith(resumeThrow))
+	i.defineMethod(proto, "return", resumeWith(resumeReturn))
+	return proto
+}
+
+// resumeGenerator runs the body of g until it next yields or finishes and
+// returns the resulting iterator result object.
+func (i *Interpreter) resumeGenerator(g *generator, r generatorResume) (Value, error) {
+	switch g.state {
+	case generatorRunning:
+		return Value{}, fmt.Errorf("TypeError: Generator is already running")
+	case generatorSuspendedStart:
+		if r.mode != resumeNext {
+			// Closing a generator that never started skips its body.
+			g.state = generatorDone
+			break
+		}
+		g.resume = make(chan generatorResume)
+		g.yield = make(chan generatorYield)
+		if i.generators == nil {
+			i.generators = make(map[*generator]struct{})
+		}
+		i.generators[g] = struct{}{}
+		go i.runGenerator(g)
+	}
+	if g.state == generatorDone {
+		switch r.mode {
+		case resumeThrow:
+			return Value{}, NewException(r.value)
+		case resumeReturn:
+			return i.iteratorResult(r.value, true), nil
+		}
+		return i.iteratorResult(Undefined, true), nil
+	}
+
+	g.state = generatorRunning
+	g.resume <- r
+	y := <-g.yield
+	g.state = generatorSuspendedYield
+	if y.done {
+		g.state = generatorDone
+		delete(i.generators, g)
+	}
+	if y.err != nil {
+		return Value{}, y.err
+	}
+	return i.iteratorResult(y.value, y.done), nil
+}
+
+// closeGenerators aborts every suspended generator body so that its
+// goroutine exits. Aborted generators report done from then on.
+func (i *Interpreter) closeGenerators() {
+	for g := range i.generators {
+		g.resume <- generatorResume{mode: resumeAbort}
+		<-g.yield
+		g.state = generatorDone
+	}
+	clear(i.generators)
+}
+
+// runGenerator is the goroutine running a generator body. It waits for the
+// first resumption and reports the outcome of the body when it finishes.
+func (i *Interpreter) runGenerator(g *generator) {
+	<-g.resume
+	val, err := i.runBody(g.env, g.fn)
+	var ret *generatorReturn
+	if errors.As(err, &ret) {
+		val, err = ret.value, nil
+	}
+	g.yield <- generatorYield{value: val, done: true, err: err}
+}
+
+// suspend hands v to the caller as the next value of the generator and
+// waits for the resumption that follows.
+func (g *generator) suspend(v Value) generatorResume {
+	g.yield <- generatorYield{value: v}
+	return <-g.resume
+}
+
+// yieldValue suspends the running generator body with v and returns the
+// value passed to next when it is resumed. Resuming with throw or return
+// surfaces as an error that unwinds the body.
+func (g *generator) yieldValue(v Value) (Value, error) {
+	r := g.suspend(v)
+	switch r.mode {
+	case resumeThrow:
+		return Value{}, NewException(r.value)
+	case resumeReturn:
+		return Value{}, &generatorReturn{value: r.value}
+	case resumeAbort:
+		return Value{}, &generatorAbort{}
+	}
+	return r.value, nil
+}
+
+// evalYieldExpression evaluates yield and yield* inside a generator body.
+func (i *Interpreter) evalYieldExpression(env *Environment, expr *ast.YieldExpression) (Value, error) {
+	g := env.VarParent().generator
+	if g == nil {
+		return Value{}, fmt.Errorf("SyntaxError: yield is only valid in generator functions")
+	}
+	arg := Undefined
+	if expr.Argument != nil {
+		v, err := i.evalExpression(env, expr.Argument)
+		if err != nil {
+			return Value{}, err
+		}
+		arg = v
+	}
+	if !expr.Delegate {
+		return g.yieldValue(arg)
+	}
+	return i.delegateYield(g, arg)
+}
+
+// delegateYield runs yield* over iterable. Every value of the inner iterator
+// is passed on, and next, throw and return calls made on the generator are
+// forwarded to the inner iterator. The value of the expression is the value
+// the inner iterator finishes with.
+func (i *Interpreter) delegateYield(g *generator, iterable Value) (Value, error) {
+	iter := iterable
+	next := Undefined
+	if iterable.Kind() == ObjectKind && iterable.Object().class != "Array" {
+		v, err := i.getProperty(iterable, "next")
+		if err != nil {
+			return Value{}, err
+		}
+		next = v
+	}
+	if !next.IsCallable() {
+		// Arrays and strings are iterated directly; wrap them in an
+		// iterator that only provides next.
+		step, err := i.iterate(iterable)
+		if err != nil {
+			return Value{}, err
+		}
+		iter = NewObjectValue(i.newIterator("Iterator", step))
+		next, _ = iter.Object().GetOwn("next")
+	}
+
+	received := generatorResume{mode: resumeNext, value: Undefined}
+	for {
+		var result Value
+		switch received.mode {
+		case resumeAbort:
+			return Value{}, &generatorAbort{}
+		case resumeNext:
+			v, err := i.callFunction(next, iter, []Value{received.value})
+			if err != nil {
+				return Value{}, err
+			}
+			result = v
+		case resumeThrow:
+			throw, err := i.getProperty(iter, "throw")
+			if err != nil {
+				return Value{}, err
+			}
+			if !throw.IsCallable() {
+				if err := i.closeIterator(iter); err != nil {
+					return Value{}, err
+				}
+				return Value{}, fmt.Errorf("TypeError: The iterator does not provide a 'throw' method")
+			}
+			v, err := i.callFunction(throw, iter, []Value{received.value})
+			if err != nil {
+				return Value{}, err
+			}
+			result = v
+		case resumeReturn:
+			ret, err := i.getProperty(iter, "return")
+			if err != nil {
+				return Value{}, err
+			}
+			if !ret.IsCallable() {
+				return Value{}, &generatorReturn{value: received.value}
+			}
+			v, err := i.callFunction(ret, iter, []Value{received.value})
+			if err != nil {
+				return Value{}, err
+			}
+			result = v
+		}
+
+		if result.Kind() != ObjectKind {
+			return Value{}, fmt.Errorf("TypeError: Iterator result %s is not an object", result.Inspect())
+		}
+		done, err := i.getProperty(result, "done")
+		if err != nil {
+			return Value{}, err
+		}
+		value, err := i.getProperty(result, "value")
+		if err != nil {
+			return Value{}, err
+		}
+		if ToBoolean(done) {
+			if received.mode == resumeReturn {
+				return Value{}, &generatorReturn{value: value}
+			}
+			return value, nil
+		}
+		received = g.suspend(value)
+	}
+}
@@ -1,36 +1,168 @@
 package vm
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
+	"math/rand/v2"
+	"os"
 	"strconv"
-	"strings"
 
 	"es6-interpreter/ast"
 )
 
 // Interpreter evaluates ECMAScript AST nodes to produce runtime values.
 type Interpreter struct {
-	global *Environment
+	global    *Environment
+	sandboxed bool
+	output    io.Writer // receives console output
+	random    *rand.Rand
+
+	globalObject    *Object // this for top-level code and sloppy plain calls
+	stringPrototype *Object
+	numberPrototype *Object
+	regexpPrototype *Object
+	arrayPrototype  *Object
+
+	generatorPrototype *Object
+	// generators holds the generators whose bodies are suspended on a
+	// goroutine of their own, so Close can release them.
+	generators map[*generator]struct{}
+
+	flatScopes   map[ast.Node]flatScope
+	noFlatScopes bool // disables slot-backed environments, for comparison
+
+	moduleLoader ModuleLoader
+	modules      map[string]*module // by specifier
+
+	// MaxCallDepth bounds the number of nested function calls. A call beyond
+	// it fails instead of exhausting the Go stack; as with other runtime
+	// errors, scripts catch the failure as the string "RangeError: Maximum
+	// call stack size exceeded", not as an error object. Zero or less
+	// disables the limit.
+	MaxCallDepth int
+	callDepth    int
+
+	// ctx is the context of the running ExecuteContext call, or nil. It is
+	// polled every cancelCheckInterval statements.
+	ctx   context.Context
+	steps int
+}
+
+// DefaultMaxCallDepth is the MaxCallDepth of a new interpreter.
+const DefaultMaxCallDepth = 10000
+
+// cancelCheckInterval is how many statements run between checks of the
+// context passed to ExecuteContext.
+const cancelCheckInterval = 1024
+
+// flatScope caches the analysis.FlatScope result for a function node.
+type flatScope struct {
+	slots []string
+	flat  bool
 }
 
-// NewInterpreter constructs a fresh interpreter instance with an empty global scope.
+// NewInterpreter constructs a fresh interpreter instance with the standard
+// global bindings installed.
 func NewInterpreter() *Interpreter {
-	global := NewEnvironment(nil)
-	return &Interpreter{global: global}
+	return newInterpreter(false)
+}
+
+// NewSandboxInterpreter constructs an interpreter for untrusted code. Its global
+// scope only contains pure built-ins; nothing that can observe or affect the
+// host is reachable from scripts.
+func NewSandboxInterpreter() *Interpreter {
+	return newInterpreter(true)
+}
+
+func newInterpreter(sandboxed bool) *Interpreter {
+	intr := &Interpreter{
+		global:    NewEnvironment(nil),
+		sandboxed: sandboxed,
+		output:    os.Stdout,
+		random:    rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+
+		MaxCallDepth: DefaultMaxCallDepth,
+	}
+	intr.installGlobals()
+	return intr
 }
 
+// Sandboxed reports whether the interpreter was created by NewSandboxInterpreter.
+func (i *Interpreter) Sandboxed() bool { return i.sandboxed }
+
 // Execute runs the supplied program and returns the completion value produced by
 // the final statement. Scripts that do not yield a value return undefined.
 func Execute(program *ast.Program) (Value, error) {
 	intr := NewInterpreter()
-	comp, err := intr.evalProgram(program)
+	defer intr.Close()
+	return intr.Execute(program)
+}
+
+// Execute runs program against the interpreter's global scope, so bindings
+// created by earlier programs remain visible, as do suspended generators.
+func (i *Interpreter) Execute(program *ast.Program) (Value, error) {
+	comp, err := i.evalProgram(program)
 	if err != nil {
 		return Value{}, err
 	}
 	return comp.value, nil
 }
 
+// ExecuteContext is like Execute but stops running program once ctx is done,
+// returning an error that wraps ctx.Err(). Scripts cannot catch that error,
+// so a deadline on ctx bounds the running time of untrusted code.
+func (i *Interpreter) ExecuteContext(ctx context.Context, program *ast.Program) (Value, error) {
+	saved := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = saved }()
+	return i.Execute(program)
+}
+
+// Close releases the goroutines of generators left suspended by the
+// programs run so far. Those generators report done if resumed afterwards.
+// Call it once the interpreter will run no more code.
+func (i *Interpreter) Close() {
+	i.closeGenerators()
+}
+
+// checkCancelled reports an error once the context of the running
+// ExecuteContext call is done. It only consults the context every
+// cancelCheckInterval calls.
+func (i *Interpreter) checkCancelled() error {
+	if i.ctx == nil {
+		return nil
+	}
+	if i.steps++; i.steps < cancelCheckInterval {
+		return nil
+	}
+	i.steps = 0
+	if err := i.ctx.Err(); err != nil {
+		return &abortError{err: err}
+	}
+	return nil
+}
+
+// abortError stops a program whose ExecuteContext context is done. It wraps
+// the context's error.
+type abortError struct {
+	err error
+}
+
+func (e *abortError) Error() string { return "execution aborted: " + e.err.Error() }
+func (e *abortError) Unwrap() error { return e.err }
+
+// isUnwinding reports whether err stops the program or a generator body
+// outright: catch and finally blocks do not run as it passes through, so
+// scripts can neither swallow nor delay it.
+func isUnwinding(err error) bool {
+	var aborted *abortError
+	return errors.As(err, &aborted) || isGeneratorAbort(err)
+}
+
 type completionType int
 
 const (
@@ -56,6 +188,12 @@ func (c completion) withValue(v Value) completion {
 }
 
 func (i *Interpreter) evalProgram(program *ast.Program) (completion, error) {
+	i.global.strict = program.Strict
+	i.hoistVarDeclarations(i.global, program.Body)
+	if err := i.instantiateFunctionDeclarations(i.global, program.Body); err != nil {
+		return completion{}, err
+	}
+
 	var last Value = Undefined
 	for _, stmt := range program.Body {
 		comp, err := i.evalStatement(i.global, stmt)
@@ -77,6 +215,17 @@ func (i *Interpreter) evalProgram(program *ast.Program) (completion, error) {
 }
 
 func (i *Interpreter) evalStatement(env *Environment, stmt ast.Statement) (completion, error) {
+	if err := i.checkCancelled(); err != nil {
+		return completion{}, err
+	}
+	comp, err := i.evalStatementNode(env, stmt)
+	if err != nil {
+		return completion{}, located(stmt, err)
+	}
+	return comp, nil
+}
+
+func (i *Interpreter) evalStatementNode(env *Environment, stmt ast.Statement) (completion, error) {
 	switch s := stmt.(type) {
 	case *ast.BlockStatement:
 		blockEnv := NewEnvironment(env)
@@ -97,9 +246,17 @@ func (i *Interpreter) evalStatement(env *Environment, stmt ast.Statement) (compl
 	case *ast.IfStatement:
 		return i.evalIfStatement(env, s)
 	case *ast.WhileStatement:
-		return i.evalWhileStatement(env, s)
+		return i.evalWhileStatement(env, s, nil)
+	case *ast.DoWhileStatement:
+		return i.evalDoWhileStatement(env, s, nil)
 	case *ast.ForStatement:
-		return i.evalForStatement(env, s)
+		return i.evalForStatement(env, s, nil)
+	case *ast.ForInStatement:
+		return i.evalForInStatement(env, s, nil)
+	case *ast.ForOfStatement:
+		return i.evalForOfStatement(env, s, nil)
+	case *ast.SwitchStatement:
+		return i.evalSwitchStatement(env, s)
 	case *ast.BreakStatement:
 		label := ""
 		if s.Label != nil {
@@ -122,21 +279,90 @@ func (i *Interpreter) evalStatement(env *Environment, stmt ast.Statement) (compl
 			val = result
 		}
 		return completion{kind: completionReturn, value: val}, nil
-	case *ast.LabeledStatement:
-		comp, err := i.evalStatement(env, s.Body)
+	case *ast.ThrowStatement:
+		val, err := i.evalExpression(env, s.Argument)
+		if err != nil {
+			return completion{}, err
+		}
+		return completion{}, NewException(val)
+	case *ast.TryStatement:
+		return i.evalTryStatement(env, s)
+	case *ast.FunctionDeclaration:
+		// Bound ahead of time by instantiateFunctionDeclarations.
+		return normalCompletion(Undefined), nil
+	case *ast.ClassDeclaration:
+		if err := env.Declare(s.ID.Name, BindingLet); err != nil {
+			return completion{}, err
+		}
+		ctor, err := i.evalClass(env, s.ID, s.SuperClass, s.Body, "")
 		if err != nil {
 			return completion{}, err
 		}
-		if comp.kind == completionBreak && comp.label == s.Label.Name {
-			return normalCompletion(comp.value), nil
+		if err := env.Initialize(s.ID.Name, ctor); err != nil {
+			return completion{}, err
 		}
-		return comp, nil
+		return normalCompletion(Undefined), nil
+	case *ast.LabeledStatement:
+		return i.evalLabeledStatement(env, s, nil)
 	default:
 		return completion{}, fmt.Errorf("runtime error: statement %T not supported", s)
 	}
 }
 
+// evalLabeledStatement runs a labeled statement. labels holds the labels of
+// directly enclosing labeled statements, so every label in a chain such as
+// "a: b: while (...)" reaches the loop and continue statements naming any of
+// them are treated as the loop's own. Any labeled statement can be exited with
+// a break naming its label.
+func (i *Interpreter) evalLabeledStatement(env *Environment, stmt *ast.LabeledStatement, labels []string) (completion, error) {
+	labels = append(labels[:len(labels):len(labels)], stmt.Label.Name)
+
+	var comp completion
+	var err error
+	switch body := stmt.Body.(type) {
+	case *ast.LabeledStatement:
+		comp, err = i.evalLabeledStatement(env, body, labels)
+	case *ast.WhileStatement:
+		comp, err = i.evalWhileStatement(env, body, labels)
+	case *ast.DoWhileStatement:
+		comp, err = i.evalDoWhileStatement(env, body, labels)
+	case *ast.ForStatement:
+		comp, err = i.evalForStatement(env, body, labels)
+	case *ast.ForInStatement:
+		comp, err = i.evalForInStatement(env, body, labels)
+	case *ast.ForOfStatement:
+		comp, err = i.evalForOfStatement(env, body, labels)
+	default:
+		comp, err = i.evalStatement(env, body)
+	}
+	if err != nil {
+		return completion{}, err
+	}
+	if comp.kind == completionBreak && comp.label == stmt.Label.Name {
+		return normalCompletion(comp.value), nil
+	}
+	return comp, nil
+}
+
+// continuesLoop reports whether a continue completion targets the loop
+// carrying the provided labels.
+func continuesLoop(c completion, labels []string) bool {
+	if c.label == "" {
+		return true
+	}
+	for _, label := range labels {
+		if label == c.label {
+			return true
+		}
+	}
+	return false
+}
+
 func (i *Interpreter) evalStatementList(env *Environment, stmts []ast.Statement) (completion, error) {
+	if err := i.instantiateFunctionDeclarations(env, stmts); err != nil {
+		return completion{}, err
+	}
+
 	var last Value = Undefined
 	for _, stmt := range stmts {
 		comp, err := i.evalStatement(env, stmt)
@@ -169,7 +395,113 @@ func (i *Interpreter) evalIfStatement(env *Environment, stmt *ast.IfStatement) (
 	return normalCompletion(Undefined), nil
 }
 
-func (i *Interpreter) evalWhileStatement(env *Environment, stmt *ast.WhileStatement) (completion, error) {
+func (i *Interpreter) evalTryStatement(env *Environment, stmt *ast.TryStatement) (completion, error) {
+	comp, err := i.evalStatement(env, stmt.Block)
+	if err != nil && stmt.Handler != nil {
+		if exc, ok := asException(err); ok {
+			comp, err = i.evalCatchClause(env, stmt.Handler, exc.Value)
+		}
+	}
+	if isUnwinding(err) {
+		return completion{}, err
+	}
+
+	if stmt.Finalizer != nil {
+		finComp, finErr := i.evalStatement(env, stmt.Finalizer)
+		if finErr != nil {
+			return completion{}, finErr
+		}
+		// An abrupt finally block overrides whatever the try/catch produced.
+		if finComp.kind != completionNormal {
+			return finComp, nil
+		}
+	}
+
+	if err != nil {
+		return completion{}, err
+	}
+	return comp, nil
+}
+
+func (i *Interpreter) evalCatchClause(env *Environment, clause *ast.CatchClause, thrown Value) (completion, error) {
+	catchEnv := NewEnvironment(env)
+	if clause.Param != nil {
+		for _, name := range patternNames(clause.Param) {
+			if err := catchEnv.Declare(name, BindingLet); err != nil {
+				return completion{}, err
+			}
+		}
+		if err := i.bindPattern(catchEnv, clause.Param, thrown, BindingLet); err != nil {
+			return completion{}, err
+		}
+	}
+	return i.evalStatement(catchEnv, clause.Body)
+}
+
+func (i *Interpreter) evalSwitchStatement(env *Environment, stmt *ast.SwitchStatement) (completion, error) {
+	discriminant, err := i.evalExpression(env, stmt.Discriminant)
+	if err != nil {
+		return completion{}, err
+	}
+
+	// All case clauses share one lexical scope.
+	switchEnv := NewEnvironment(env)
+	var body []ast.Statement
+	for _, c := range stmt.Cases {
+		body = append(body, c.Consequent...)
+	}
+	if err := i.instantiateFunctionDeclarations(switchEnv, body); err != nil {
+		return completion{}, err
+	}
+
+	start, defaultIdx := -1, -1
+	for idx, c := range stmt.Cases {
+		if c.Test == nil {
+			defaultIdx = idx
+			continue
+		}
+		testVal, err := i.evalExpression(switchEnv, c.Test)
+		if err != nil {
+			return completion{}, err
+		}
+		if StrictEquals(discriminant, testVal) {
+			start = idx
+			break
+		}
+	}
+	if start < 0 {
+		// The default clause is only considered once every case test failed,
+		// wherever it appears in the body.
+		if defaultIdx < 0 {
+			return normalCompletion(Undefined), nil
+		}
+		start = defaultIdx
+	}
+
+	var last Value = Undefined
+	for _, c := range stmt.Cases[start:] {
+		for _, s := range c.Consequent {
+			comp, err := i.evalStatement(switchEnv, s)
+			if err != nil {
+				return completion{}, err
+			}
+			switch comp.kind {
+			case completionNormal:
+				last = comp.value
+			case completionBreak:
+				if comp.label == "" {
+					return normalCompletion(last), nil
+				}
+				return comp, nil
+			default:
+				return comp, nil
+			}
+		}
+	}
+	return normalCompletion(last), nil
+}
+
+func (i *Interpreter) evalWhileStatement(env *Environment, stmt *ast.WhileStatement, labels []string) (completion, error) {
 	var last Value = Undefined
 	for {
 		testVal, err := i.evalExpression(env, stmt.Test)
@@ -196,7 +528,7 @@ func (i *Interpreter) evalWhileStatement(env *Environment, stmt *ast.WhileStatem
 			}
 			return bodyComp, nil
 		case completionContinue:
-			if bodyComp.label != "" {
+			if !continuesLoop(bodyComp, labels) {
 				return bodyComp, nil
 			}
 			continue
@@ -206,8 +538,57 @@ func (i *Interpreter) evalWhileStatement(env *Environment, stmt *ast.WhileStatem
 	}
 }
 
-func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement) (completion, error) {
-	loopEnv := NewEnvironment(env)
+func (i *Interpreter) evalDoWhileStatement(env *Environment, stmt *ast.DoWhileStatement, labels []string) (completion, error) {
+	var last Value = Undefined
+	for {
+		bodyComp, err := i.evalStatement(env, stmt.Body)
+		if err != nil {
+			return completion{}, err
+		}
+
+		switch bodyComp.kind {
+		case completionNormal:
+			last = bodyComp.value
+		case completionReturn:
+			return bodyComp, nil
+		case completionBreak:
+			if bodyComp.label == "" {
+				return normalCompletion(bodyComp.value), nil
+			}
+			return bodyComp, nil
+		case completionContinue:
+			if !continuesLoop(bodyComp, labels) {
+				return bodyComp, nil
+			}
+		default:
+			return completion{}, fmt.Errorf("runtime error: unsupported completion in do-while body: %d", bodyComp.kind)
+		}
+
+		testVal, err := i.evalExpression(env, stmt.Test)
+		if err != nil {
+			return completion{}, err
+		}
+		if !ToBoolean(testVal) {
+			return normalCompletion(last), nil
+		}
+	}
+}
+
+// evalForStatement runs a for loop. let and const declarations in the head
+// get a scope of their own around the loop, and each iteration receives fresh
+// copies of the let bindings so closures capture that iteration's values. var
+// declarations go to the enclosing var scope and outlive the loop.
+func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement, labels []string) (completion, error) {
+	loopEnv := env
+	var perIteration []string
+	if decl, ok := stmt.Init.(*ast.VariableDeclaration); ok && decl.DeclareKind != ast.VarKind {
+		loopEnv = NewEnvironment(env)
+		if decl.DeclareKind == ast.LetKind {
+			for _, d := range decl.Declarations {
+				perIteration = append(perIteration, patternNames(d.ID)...)
+			}
+		}
+	}
 	if stmt.Init != nil {
 		switch init := stmt.Init.(type) {
 		case ast.Expression:
@@ -224,6 +605,7 @@ func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement)
 	}
 
 	var last Value = Undefined
+	loopEnv = nextIterationEnv(loopEnv, perIteration)
 	for {
 		if stmt.Test != nil {
 			testVal, err := i.evalExpression(loopEnv, stmt.Test)
@@ -240,7 +622,6 @@ func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement)
 			return completion{}, err
 		}
 
-		skipUpdate := false
 		switch bodyComp.kind {
 		case completionNormal:
 			last = bodyComp.value
@@ -252,15 +633,16 @@ func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement)
 			}
 			return bodyComp, nil
 		case completionContinue:
-			if bodyComp.label != "" {
+			// A continue aimed at this loop still runs the update clause.
+			if !continuesLoop(bodyComp, labels) {
 				return bodyComp, nil
 			}
-			skipUpdate = false
 		default:
 			return completion{}, fmt.Errorf("runtime error: unsupported completion in for body: %d", bodyComp.kind)
 		}
 
-		if stmt.Update != nil && !skipUpdate {
+		loopEnv = nextIterationEnv(loopEnv, perIteration)
+		if stmt.Update != nil {
 			if _, err := i.evalExpression(loopEnv, stmt.Update); err != nil {
 				return completion{}, err
 			}
@@ -268,81 +650,336 @@ func (i *Interpreter) evalForStatement(env *Environment, stmt *ast.ForStatement)
 	}
 }
 
-func (i *Interpreter) evalVariableDeclaration(env *Environment, decl *ast.VariableDeclaration) error {
-	var kind BindingKind
-	switch decl.DeclareKind {
-	case ast.VarKind:
-		kind = BindingVar
-	case ast.LetKind:
-		kind = BindingLet
-	case ast.ConstKind:
-		kind = BindingConst
-	default:
-		return fmt.Errorf("runtime error: unsupported variable kind %s", decl.DeclareKind)
+// nextIterationEnv copies the named let bindings of a for loop into a new
+// environment for the next iteration, leaving the previous one to any
+// closures that captured it.
+func nextIterationEnv(last *Environment, names []string) *Environment {
+	if len(names) == 0 {
+		return last
+	}
+	next := NewEnvironment(last.Outer())
+	for _, name := range names {
+		v, err := last.Get(name)
+		if err != nil {
+			v = Undefined
+		}
+		_ = next.Declare(name, BindingLet)
+		_ = next.Initialize(name, v)
 	}
+	return next
+}
 
-	for _, d := range decl.Declarations {
-		ident, ok := d.ID.(*ast.Identifier)
-		if !ok {
-			return fmt.Errorf("runtime error: destructuring bindings are not implemented yet (%T)", d.ID)
+// evalForInStatement enumerates the enumerable string keys of the object and
+// its prototypes. The keys are collected up front; a key deleted before the
+// loop reaches it is skipped, while keys added during the loop are not
+// visited.
+func (i *Interpreter) evalForInStatement(env *Environment, stmt *ast.ForInStatement, labels []string) (completion, error) {
+	right, err := i.evalExpression(env, stmt.Right)
+	if err != nil {
+		return completion{}, err
+	}
+
+	var keys []string
+	var obj *Object
+	switch right.Kind() {
+	case ObjectKind:
+		obj = right.Object()
+		keys = forInKeys(obj)
+	case StringKind:
+		for idx := 0; idx < utf16Length(right.StringValue()); idx++ {
+			keys = append(keys, strconv.Itoa(idx))
 		}
+	}
 
-		target := env
-		if kind == BindingVar {
-			target = env.VarParent()
+	var last Value = Undefined
+	for _, key := range keys {
+		if obj != nil && !obj.Has(key) {
+			continue
 		}
 
-		if err := target.Declare(ident.Name, kind); err != nil {
-			return err
+		iterEnv, err := i.bindForTarget(env, stmt.Left, NewString(key))
+		if err != nil {
+			return completion{}, err
 		}
 
-		if d.Init != nil {
-			initVal, err := i.evalExpression(env, d.Init)
-			if err != nil {
-				return err
+		bodyComp, err := i.evalStatement(iterEnv, stmt.Body)
+		if err != nil {
+			return completion{}, err
+		}
+
+		switch bodyComp.kind {
+		case completionNormal:
+			last = bodyComp.value
+		case completionReturn:
+			return bodyComp, nil
+		case completionBreak:
+			if bodyComp.label == "" {
+				return normalCompletion(bodyComp.value), nil
 			}
-			switch kind {
-			case BindingVar:
-				if err := target.Set(ident.Name, initVal); err != nil {
-					return err
-				}
-			case BindingLet, BindingConst:
-				if err := target.Initialize(ident.Name, initVal); err != nil {
-					return err
-				}
+			return bodyComp, nil
+		case completionContinue:
+			if !continuesLoop(bodyComp, labels) {
+				return bodyComp, nil
 			}
-		} else if kind == BindingConst {
-			return fmt.Errorf("TypeError: const declaration %q requires an initializer", ident.Name)
+		default:
+			return completion{}, fmt.Errorf("runtime error: unsupported completion in for-in body: %d", bodyComp.kind)
 		}
 	}
-
-	return nil
+	return normalCompletion(last), nil
 }
 
-func (i *Interpreter) evalExpression(env *Environment, expr ast.Expression) (Value, error) {
-	switch e := expr.(type) {
-	case *ast.NumberLiteral:
-		return i.evalNumberLiteral(e)
-	case *ast.StringLiteral:
-		return NewString(e.Value), nil
-	case *ast.BooleanLiteral:
-		return NewBoolean(e.Value), nil
-	case *ast.NullLiteral:
-		return Null, nil
-	case *ast.Identifier:
-		val, err := env.Get(e.Name)
+// evalForOfStatement runs the body once per value produced by iterating the
+// right-hand side. Leaving the loop early with break, return or an error
+// closes the iterator by calling its return method, if it has one.
+func (i *Interpreter) evalForOfStatement(env *Environment, stmt *ast.ForOfStatement, labels []string) (completion, error) {
+	if stmt.Await {
+		return completion{}, fmt.Errorf("runtime error: for await is not supported")
+	}
+	right, err := i.evalExpression(env, stmt.Right)
+	if err != nil {
+		return completion{}, err
+	}
+	step, err := i.iterate(right)
+	if err != nil {
+		return completion{}, err
+	}
+
+	var last Value = Undefined
+	for {
+		v, done, err := step()
 		if err != nil {
-			return Value{}, err
+			return completion{}, err
 		}
-		return val, nil
-	case *ast.BinaryExpression:
-		left, err := i.evalExpression(env, e.Left)
+		if done {
+			return normalCompletion(last), nil
+		}
+
+		// An error leaving the loop wins over any raised while closing.
+		iterEnv, err := i.bindForTarget(env, stmt.Left, v)
 		if err != nil {
-			return Value{}, err
+			_ = i.closeIterator(right)
+			return completion{}, err
+		}
+
+		bodyComp, err := i.evalStatement(iterEnv, stmt.Body)
+		if isUnwinding(err) {
+			return completion{}, err
 		}
-		right, err := i.evalExpression(env, e.Right)
 		if err != nil {
-			return Value{}, err
+			_ = i.closeIterator(right)
+			return completion{}, err
+		}
+
+		switch bodyComp.kind {
+		case completionNormal:
+			last = bodyComp.value
+			continue
+		case completionContinue:
+			if continuesLoop(bodyComp, labels) {
+				continue
+			}
+		case completionReturn, completionBreak:
+		default:
+			return completion{}, fmt.Errorf("runtime error: unsupported completion in for-of body: %d", bodyComp.kind)
+		}
+		if err := i.closeIterator(right); err != nil {
+			return completion{}, err
+		}
+		if bodyComp.kind == completionBreak && bodyComp.label == "" {
+			return normalCompletion(bodyComp.value), nil
+		}
+		return bodyComp, nil
+	}
+}
+
+// forInKeys lists the enumerable keys visible on obj, own keys first and then
+// those of each prototype. A key is listed once, and not at all when the
+// nearest object holding it marks it non-enumerable.
+func forInKeys(obj *Object) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for cur := obj; cur != nil; cur = cur.prototype {
+		for _, key := range cur.OwnKeys() {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if cur.properties[key].enumerable {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// bindForTarget assigns the value of one for-in/of iteration to the loop's
+// left-hand side. Lexical declarations get a fresh environment per iteration,
+// which is returned for evaluating the body.
+func (i *Interpreter) bindForTarget(env *Environment, left ast.Node, v Value) (*Environment, error) {
+	switch l := left.(type) {
+	case *ast.VariableDeclaration:
+		pattern := l.Declarations[0].ID
+		if l.DeclareKind == ast.VarKind {
+			return env, i.bindPattern(env, pattern, v, BindingVar)
+		}
+		kind := BindingLet
+		if l.DeclareKind == ast.ConstKind {
+			kind = BindingConst
+		}
+		iterEnv := NewEnvironment(env)
+		for _, name := range patternNames(pattern) {
+			if err := iterEnv.Declare(name, kind); err != nil {
+				return nil, err
+			}
+		}
+		return iterEnv, i.bindPattern(iterEnv, pattern, v, kind)
+	case *ast.Identifier:
+		return env, env.Set(l.Name, v)
+	case *ast.ArrayPattern, *ast.ObjectPattern:
+		// Destructuring assignment to existing bindings.
+		return env, i.bindPattern(env, l.(ast.Pattern), v, BindingVar)
+	case *ast.MemberExpression:
+		base, err := i.evalExpression(env, l.Object)
+		if err != nil {
+			return nil, err
+		}
+		key, err := i.evalPropertyKey(env, l)
+		if err != nil {
+			return nil, err
+		}
+		return env, i.setProperty(base, key, v, env.isStrict())
+	default:
+		return nil, fmt.Errorf("runtime error: loop target %T not supported", left)
+	}
+}
+
+func (i *Interpreter) evalVariableDeclaration(env *Environment, decl *ast.VariableDeclaration) error {
+	var kind BindingKind
+	switch decl.DeclareKind {
+	case ast.VarKind:
+		kind = BindingVar
+	case ast.LetKind:
+		kind = BindingLet
+	case ast.ConstKind:
+		kind = BindingConst
+	default:
+		return fmt.Errorf("runtime error: unsupported variable kind %s", decl.DeclareKind)
+	}
+
+	for _, d := range decl.Declarations {
+		target := env
+		if kind == BindingVar {
+			target = env.VarParent()
+		}
+
+		ident, isIdent := d.ID.(*ast.Identifier)
+		if isIdent {
+			if err := target.Declare(ident.Name, kind); err != nil {
+				return err
+			}
+		} else {
+			for _, name := range patternNames(d.ID) {
+				if err := target.Declare(name, kind); err != nil {
+					return err
+				}
+			}
+		}
+
+		if d.Init != nil {
+			var initVal Value
+			var err error
+			if isIdent {
+				initVal, err = i.evalNamedExpression(env, d.Init, ident.Name)
+			} else {
+				initVal, err = i.evalExpression(env, d.Init)
+			}
+			if err != nil {
+				return err
+			}
+			if err := i.bindPattern(env, d.ID, initVal, kind); err != nil {
+				return err
+			}
+		} else if kind == BindingConst {
+			name := "pattern"
+			if isIdent {
+				name = ident.Name
+			}
+			return fmt.Errorf("TypeError: const declaration %q requires an initializer", name)
+		} else if kind == BindingLet && isIdent {
+			// let x; leaves the temporal dead zone holding undefined.
+			if err := target.Initialize(ident.Name, Undefined); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Interpreter) evalExpression(env *Environment, expr ast.Expression) (Value, error) {
+	val, err := i.evalExpressionNode(env, expr)
+	if err != nil {
+		return Value{}, located(expr, err)
+	}
+	return val, nil
+}
+
+func (i *Interpreter) evalExpressionNode(env *Environment, expr ast.Expression) (Value, error) {
+	switch e := expr.(type) {
+	case *ast.NumberLiteral:
+		return i.evalNumberLiteral(e)
+	case *ast.BigIntLiteral:
+		return i.evalBigIntLiteral(e)
+	case *ast.StringLiteral:
+		return NewString(e.Value), nil
+	case *ast.RegExpLiteral:
+		return i.newRegExp(e.Pattern, e.Flags)
+	case *ast.BooleanLiteral:
+		return NewBoolean(e.Value), nil
+	case *ast.NullLiteral:
+		return Null, nil
+	case *ast.Identifier:
+		val, err := env.Get(e.Name)
+		if err != nil {
+			return Value{}, err
+		}
+		return val, nil
+	case *ast.ThisExpression:
+		return env.This(), nil
+	case *ast.ObjectLiteral:
+		return i.evalObjectLiteral(env, e)
+	case *ast.ArrayLiteral:
+		return i.evalArrayLiteral(env, e)
+	case *ast.MemberExpression, *ast.CallExpression:
+		val, _, _, err := i.evalChainElement(env, e)
+		return val, err
+	case *ast.ChainExpression:
+		val, _, shortCircuited, err := i.evalChainElement(env, e.Expression)
+		if err != nil {
+			return Value{}, err
+		}
+		if shortCircuited {
+			return Undefined, nil
+		}
+		return val, nil
+	case *ast.NewExpression:
+		return i.evalNewExpression(env, e)
+	case *ast.ArrowFunctionExpression:
+		return i.newArrowFunction(env, e, ""), nil
+	case *ast.FunctionExpression:
+		return i.newFunctionFromExpression(env, e, ""), nil
+	case *ast.ClassExpression:
+		return i.evalClass(env, e.ID, e.SuperClass, e.Body, "")
+	case *ast.YieldExpression:
+		return i.evalYieldExpression(env, e)
+	case *ast.BinaryExpression:
+		left, err := i.evalExpression(env, e.Left)
+		if err != nil {
+			return Value{}, err
+		}
+		right, err := i.evalExpression(env, e.Right)
+		if err != nil {
+			return Value{}, err
 		}
 		return i.applyBinary(e.Operator, left, right)
 	case *ast.AssignmentExpression:
@@ -378,47 +1015,487 @@ func (i *Interpreter) evalExpression(env *Environment, expr ast.Expression) (Val
 }
 
 func (i *Interpreter) evalNumberLiteral(lit *ast.NumberLiteral) (Value, error) {
-	num, err := parseNumericLiteral(lit.Value)
+	num, err := lit.Float64()
 	if err != nil {
 		return Value{}, fmt.Errorf("runtime error: invalid numeric literal %q: %v", lit.Value, err)
 	}
 	return NewNumber(num), nil
 }
 
-func (i *Interpreter) evalAssignmentExpression(env *Environment, expr *ast.AssignmentExpression) (Value, error) {
-	target, ok := expr.Left.(*ast.Identifier)
+func (i *Interpreter) evalBigIntLiteral(lit *ast.BigIntLiteral) (Value, error) {
+	n, ok := new(big.Int).SetString(lit.Value, 0)
 	if !ok {
-		return Value{}, fmt.Errorf("runtime error: assignment target %T not supported", expr.Left)
+		return Value{}, fmt.Errorf("runtime error: invalid BigInt literal %q", lit.Value)
 	}
+	return NewBigInt(n), nil
+}
 
-	right, err := i.evalExpression(env, expr.Right)
+func (i *Interpreter) evalObjectLiteral(env *Environment, lit *ast.ObjectLiteral) (Value, error) {
+	obj := NewObject(nil)
+	for _, prop := range lit.Properties {
+		if spread, ok := prop.(*ast.SpreadElement); ok {
+			source, err := i.evalExpression(env, spread.Argument)
+			if err != nil {
+				return Value{}, err
+			}
+			if err := i.copyDataProperties(obj, source, nil); err != nil {
+				return Value{}, err
+			}
+			continue
+		}
+		p, ok := prop.(*ast.ObjectProperty)
+		if !ok {
+			return Value{}, fmt.Errorf("runtime error: object literal property %T not supported", prop)
+		}
+		key, err := i.evalPropertyName(env, p.Key, p.Computed)
+		if err != nil {
+			return Value{}, err
+		}
+		if p.PropKind != ast.PropertyInit {
+			fnExpr, ok := p.Value.(*ast.FunctionExpression)
+			if !ok {
+				return Value{}, fmt.Errorf("runtime error: invalid %s property value %T", p.PropKind, p.Value)
+			}
+			fn := i.newFunctionFromExpression(env, fnExpr, key)
+			switch p.PropKind {
+			case ast.PropertyGet:
+				obj.DefineAccessor(key, fn.Object(), nil, true, true)
+			case ast.PropertySet:
+				obj.DefineAccessor(key, nil, fn.Object(), true, true)
+			default:
+				obj.DefineProperty(key, fn, true, true, true)
+			}
+			continue
+		}
+		val, err := i.evalNamedExpression(env, p.Value, key)
+		if err != nil {
+			return Value{}, err
+		}
+		obj.DefineProperty(key, val, true, true, true)
+	}
+	return NewObjectValue(obj), nil
+}
+
+func (i *Interpreter) evalArrayLiteral(env *Environment, lit *ast.ArrayLiteral) (Value, error) {
+	arr := i.newArray(nil)
+	var length uint32
+	for _, elem := range lit.Elements {
+		if elem == nil {
+			length++
+			continue
+		}
+		if spread, ok := elem.(*ast.SpreadElement); ok {
+			source, err := i.evalExpression(env, spread.Argument)
+			if err != nil {
+				return Value{}, err
+			}
+			values, err := i.iterableValues(source)
+			if err != nil {
+				return Value{}, err
+			}
+			for _, val := range values {
+				arr.Set(strconv.FormatUint(uint64(length), 10), val)
+				length++
+			}
+			continue
+		}
+		val, err := i.evalExpression(env, elem)
+		if err != nil {
+			return Value{}, err
+		}
+		arr.Set(strconv.FormatUint(uint64(length), 10), val)
+		length++
+	}
+	// Trailing holes still count towards the length.
+	arr.setLength(length)
+	return NewObjectValue(arr), nil
+}
+
+// copyDataProperties copies the own enumerable properties of source onto
+// target, as object spread and object rest patterns do, skipping the keys in
+// excluded. Getters on source are invoked; null and undefined sources
+// contribute nothing.
+func (i *Interpreter) copyDataProperties(target *Object, source Value, excluded map[string]bool) error {
+	var keys []string
+	switch source.Kind() {
+	case StringKind:
+		for idx := 0; idx < utf16Length(source.StringValue()); idx++ {
+			keys = append(keys, strconv.Itoa(idx))
+		}
+	case ObjectKind:
+		for _, key := range source.Object().OwnKeys() {
+			if source.Object().properties[key].enumerable {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, key := range keys {
+		if excluded[key] {
+			continue
+		}
+		val, err := i.getProperty(source, key)
+		if err != nil {
+			return err
+		}
+		target.DefineProperty(key, val, true, true, true)
+	}
+	return nil
+}
+
+// evalPropertyName resolves the key of an object literal property or object
+// pattern property to a property name.
+func (i *Interpreter) evalPropertyName(env *Environment, key ast.Expression, computed bool) (string, error) {
+	if computed {
+		val, err := i.evalExpression(env, key)
+		if err != nil {
+			return "", err
+		}
+		return ToString(val).StringValue(), nil
+	}
+	switch k := key.(type) {
+	case *ast.Identifier:
+		return k.Name, nil
+	case *ast.StringLiteral:
+		return k.Value, nil
+	case *ast.NumberLiteral:
+		n, err := i.evalNumberLiteral(k)
+		if err != nil {
+			return "", err
+		}
+		return ToString(n).StringValue(), nil
+	default:
+		return "", fmt.Errorf("runtime error: property key %T not supported", key)
+	}
+}
+
+// evalPropertyKey resolves the property name of a member expression. Computed
+// keys are evaluated after the object, matching the source order.
+func (i *Interpreter) evalPropertyKey(env *Environment, member *ast.MemberExpression) (string, error) {
+	if !member.Computed {
+		ident, ok := member.Property.(*ast.Identifier)
+		if !ok {
+			return "", fmt.Errorf("runtime error: member property %T not supported", member.Property)
+		}
+		return ident.Name, nil
+	}
+	key, err := i.evalExpression(env, member.Property)
+	if err != nil {
+		return "", err
+	}
+	return ToString(key).StringValue(), nil
+}
+
+func (i *Interpreter) getProperty(base Value, key string) (Value, error) {
+	switch base.Kind() {
+	case UndefinedKind, NullKind:
+		return Value{}, fmt.Errorf("TypeError: Cannot read properties of %s (reading '%s')", base.Inspect(), key)
+	case ObjectKind:
+		return i.getObjectProperty(base.Object(), base, key)
+	case StringKind:
+		return i.getStringProperty(base.StringValue(), key), nil
+	case NumberKind:
+		return i.numberPrototype.Get(key), nil
+	default:
+		return Undefined, nil
+	}
+}
+
+// getObjectProperty looks key up on obj and its prototype chain, calling a
+// getter with receiver as this.
+func (i *Interpreter) getObjectProperty(obj *Object, receiver Value, key string) (Value, error) {
+	prop, ok := obj.lookup(key)
+	if !ok {
+		return Undefined, nil
+	}
+	if !prop.accessor {
+		return prop.value, nil
+	}
+	// A setter-only property reads as undefined.
+	if prop.getter == nil {
+		return Undefined, nil
+	}
+	return i.callFunction(NewObjectValue(prop.getter), receiver, nil)
+}
+
+// setProperty assigns v to key on base. Assignments that cannot take effect,
+// such as writing a read-only or getter-only property, are ignored in sloppy
+// mode and throw a TypeError in strict mode code.
+func (i *Interpreter) setProperty(base Value, key string, v Value, strict bool) error {
+	switch base.Kind() {
+	case UndefinedKind, NullKind:
+		return fmt.Errorf("TypeError: Cannot set properties of %s (setting '%s')", base.Inspect(), key)
+	case ObjectKind:
+		obj := base.Object()
+		if prop, ok := obj.lookup(key); ok && prop.accessor {
+			if prop.setter == nil {
+				if strict {
+					return fmt.Errorf("TypeError: Cannot set property %s of %s which has only a getter", key, base.Inspect())
+				}
+				return nil
+			}
+			_, err := i.callFunction(NewObjectValue(prop.setter), base, []Value{v})
+			return err
+		}
+		if !obj.Set(key, v) && strict {
+			return fmt.Errorf("TypeError: Cannot assign to read only property '%s' of %s", key, base.Inspect())
+		}
+		return nil
+	default:
+		// Assignments to primitive bases are silently dropped in sloppy mode.
+		return nil
+	}
+}
+
+// evalChainElement evaluates a member access or call, along with the members
+// and calls it is built on, strictly left to right. Besides the value it
+// returns the object a member was read from, which becomes the receiver when
+// the member is called. It reports whether an optional link met null or
+// undefined, in which case the rest of the chain is skipped.
+func (i *Interpreter) evalChainElement(env *Environment, expr ast.Expression) (val Value, base Value, shortCircuited bool, err error) {
+	switch e := expr.(type) {
+	case *ast.MemberExpression:
+		if _, ok := e.Object.(*ast.Super); ok {
+			val, err := i.evalSuperProperty(env, e)
+			return val, env.This(), false, err
+		}
+		obj, _, short, err := i.evalChainElement(env, e.Object)
+		if err != nil || short {
+			return Value{}, Value{}, short, err
+		}
+		if e.Optional && isNullish(obj) {
+			return Undefined, Undefined, true, nil
+		}
+		key, err := i.evalPropertyKey(env, e)
+		if err != nil {
+			return Value{}, Value{}, false, err
+		}
+		val, err := i.getProperty(obj, key)
+		if err != nil {
+			return Value{}, Value{}, false, err
+		}
+		return val, obj, false, nil
+	case *ast.CallExpression:
+		if _, ok := e.Callee.(*ast.Super); ok {
+			val, err := i.evalSuperCall(env, e)
+			return val, Undefined, false, err
+		}
+		callee, this, short, err := i.evalChainElement(env, e.Callee)
+		if err != nil || short {
+			return Value{}, Value{}, short, err
+		}
+		if e.Optional && isNullish(callee) {
+			return Undefined, Undefined, true, nil
+		}
+		args, err := i.evalArguments(env, e.Arguments)
+		if err != nil {
+			return Value{}, Value{}, false, err
+		}
+		if !callee.IsCallable() {
+			return Value{}, Value{}, false, fmt.Errorf("TypeError: %s is not a function", describeCallee(e.Callee))
+		}
+		val, err := i.callFunction(callee, this, args)
+		if err != nil {
+			return Value{}, Value{}, false, err
+		}
+		return val, Undefined, false, nil
+	default:
+		val, err := i.evalExpression(env, expr)
+		return val, Undefined, false, err
+	}
+}
+
+// evalArguments evaluates the arguments of a call or new expression in order,
+// expanding each spread argument into the values of its iterable.
+func (i *Interpreter) evalArguments(env *Environment, exprs []ast.Expression) ([]Value, error) {
+	args := make([]Value, 0, len(exprs))
+	for _, argExpr := range exprs {
+		if spread, ok := argExpr.(*ast.SpreadElement); ok {
+			source, err := i.evalExpression(env, spread.Argument)
+			if err != nil {
+				return nil, err
+			}
+			values, err := i.iterableValues(source)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, values...)
+			continue
+		}
+		arg, err := i.evalExpression(env, argExpr)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// evalNewExpression evaluates new callee(args).
+func (i *Interpreter) evalNewExpression(env *Environment, expr *ast.NewExpression) (Value, error) {
+	callee, err := i.evalExpression(env, expr.Callee)
+	if err != nil {
+		return Value{}, err
+	}
+	args, err := i.evalArguments(env, expr.Arguments)
 	if err != nil {
 		return Value{}, err
 	}
+	if !callee.IsCallable() {
+		return Value{}, fmt.Errorf("TypeError: %s is not a constructor", describeCallee(expr.Callee))
+	}
+	return i.construct(callee, args)
+}
 
-	switch expr.Operator {
-	case "=":
+func isNullish(v Value) bool {
+	return v.Kind() == UndefinedKind || v.Kind() == NullKind
+}
+
+// describeCallee renders a callee expression for error messages.
+func describeCallee(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return e.Name
+	case *ast.ThisExpression:
+		return "this"
+	case *ast.MemberExpression:
+		if prop, ok := e.Property.(*ast.Identifier); ok && !e.Computed {
+			return describeCallee(e.Object) + "." + prop.Name
+		}
+		return describeCallee(e.Object) + "[...]"
+	default:
+		return "expression"
+	}
+}
+
+func (i *Interpreter) evalAssignmentExpression(env *Environment, expr *ast.AssignmentExpression) (Value, error) {
+	if member, ok := expr.Left.(*ast.MemberExpression); ok {
+		return i.evalMemberAssignment(env, member, expr)
+	}
+
+	target, ok := expr.Left.(*ast.Identifier)
+	if !ok {
+		return Value{}, fmt.Errorf("runtime error: assignment target %T not supported", expr.Left)
+	}
+
+	if expr.Operator == "=" {
+		right, err := i.evalNamedExpression(env, expr.Right, target.Name)
+		if err != nil {
+			return Value{}, err
+		}
 		if err := env.Set(target.Name, right); err != nil {
 			return Value{}, err
 		}
 		return right, nil
-	case "+=", "-=", "*=", "/=", "%=":
+	}
+
+	if isLogicalAssignment(expr.Operator) {
 		current, err := env.Get(target.Name)
 		if err != nil {
 			return Value{}, err
 		}
-		op := expr.Operator[:len(expr.Operator)-1]
-		result, err := i.applyBinary(op, current, right)
+		if logicalAssignmentShortCircuits(expr.Operator, current) {
+			return current, nil
+		}
+		right, err := i.evalNamedExpression(env, expr.Right, target.Name)
 		if err != nil {
 			return Value{}, err
 		}
-		if err := env.Set(target.Name, result); err != nil {
+		if err := env.Set(target.Name, right); err != nil {
 			return Value{}, err
 		}
-		return result, nil
-	default:
+		return right, nil
+	}
+
+	// Compound assignments read the target before evaluating the right-hand
+	// side, so side effects there do not change the value being combined.
+	op, ok := compoundOperator(expr.Operator)
+	if !ok {
 		return Value{}, fmt.Errorf("runtime error: assignment operator %q not implemented", expr.Operator)
 	}
+	current, err := env.Get(target.Name)
+	if err != nil {
+		return Value{}, err
+	}
+	right, err := i.evalExpression(env, expr.Right)
+	if err != nil {
+		return Value{}, err
+	}
+	result, err := i.applyBinary(op, current, right)
+	if err != nil {
+		return Value{}, err
+	}
+	if err := env.Set(target.Name, result); err != nil {
+		return Value{}, err
+	}
+	return result, nil
+}
+
+// compoundOperator returns the binary operator applied by a compound
+// assignment operator such as += or **=.
+func compoundOperator(assign string) (string, bool) {
+	switch assign {
+	case "+=", "-=", "*=", "/=", "%=", "**=":
+		return assign[:len(assign)-1], true
+	default:
+		return "", false
+	}
+}
+
+// isLogicalAssignment reports whether assign is one of &&=, ||= and ??=.
+func isLogicalAssignment(assign string) bool {
+	return assign == "&&=" || assign == "||=" || assign == "??="
+}
+
+// logicalAssignmentShortCircuits reports whether the logical assignment
+// operator assign leaves a target holding current unchanged, without
+// evaluating its right-hand side.
+func logicalAssignmentShortCircuits(assign string, current Value) bool {
+	switch assign {
+	case "&&=":
+		return !ToBoolean(current)
+	case "||=":
+		return ToBoolean(current)
+	default:
+		return !isNullish(current)
+	}
+}
+
+func (i *Interpreter) evalMemberAssignment(env *Environment, member *ast.MemberExpression, expr *ast.AssignmentExpression) (Value, error) {
+	op, compound := compoundOperator(expr.Operator)
+	logical := isLogicalAssignment(expr.Operator)
+	if expr.Operator != "=" && !compound && !logical {
+		return Value{}, fmt.Errorf("runtime error: assignment operator %q on member targets not implemented", expr.Operator)
+	}
+	base, err := i.evalExpression(env, member.Object)
+	if err != nil {
+		return Value{}, err
+	}
+	key, err := i.evalPropertyKey(env, member)
+	if err != nil {
+		return Value{}, err
+	}
+	var current Value
+	if compound || logical {
+		if current, err = i.getProperty(base, key); err != nil {
+			return Value{}, err
+		}
+	}
+	if logical && logicalAssignmentShortCircuits(expr.Operator, current) {
+		return current, nil
+	}
+	right, err := i.evalExpression(env, expr.Right)
+	if err != nil {
+		return Value{}, err
+	}
+	if compound {
+		if right, err = i.applyBinary(op, current, right); err != nil {
+			return Value{}, err
+		}
+	}
+	if err := i.setProperty(base, key, right, env.isStrict()); err != nil {
+		return Value{}, err
+	}
+	return right, nil
 }
 
 func (i *Interpreter) evalLogicalExpression(env *Environment, expr *ast.LogicalExpression) (Value, error) {
@@ -439,7 +1516,7 @@ func (i *Interpreter) evalLogicalExpression(env *Environment, expr *ast.LogicalE
 		}
 		return i.evalExpression(env, expr.Right)
 	case "??":
-		if left.Kind() != UndefinedKind && left.Kind() != NullKind {
+		if !isNullish(left) {
 			return left, nil
 		}
 		return i.evalExpression(env, expr.Right)
@@ -449,6 +1526,18 @@ func (i *Interpreter) evalLogicalExpression(env *Environment, expr *ast.LogicalE
 }
 
 func (i *Interpreter) evalUnaryExpression(env *Environment, expr *ast.UnaryExpression) (Value, error) {
+	if expr.Operator == "delete" {
+		return i.evalDelete(env, expr.Argument)
+	}
+
+	// typeof tolerates unresolvable references, but only when the operand is a
+	// bare identifier; member accesses on undeclared names still throw.
+	if ident, ok := expr.Argument.(*ast.Identifier); ok && expr.Operator == "typeof" {
+		if _, found := env.Resolve(ident.Name); !found {
+			return NewString("undefined"), nil
+		}
+	}
+
 	arg, err := i.evalExpression(env, expr.Argument)
 	if err != nil {
 		return Value{}, err
@@ -458,9 +1547,15 @@ func (i *Interpreter) evalUnaryExpression(env *Environment, expr *ast.UnaryExpre
 	case "!":
 		return NewBoolean(!ToBoolean(arg)), nil
 	case "+":
+		if arg.Kind() == BigIntKind {
+			return Value{}, fmt.Errorf("TypeError: Cannot convert a BigInt value to a number")
+		}
 		n := ToNumber(arg)
 		return n, nil
 	case "-":
+		if arg.Kind() == BigIntKind {
+			return NewBigInt(new(big.Int).Neg(arg.BigInt())), nil
+		}
 		n := ToNumber(arg)
 		return NewNumber(-n.Number()), nil
 	case "typeof":
@@ -472,6 +1567,44 @@ func (i *Interpreter) evalUnaryExpression(env *Environment, expr *ast.UnaryExpre
 	}
 }
 
+func (i *Interpreter) evalDelete(env *Environment, target ast.Expression) (Value, error) {
+	switch t := target.(type) {
+	case *ast.MemberExpression:
+		base, err := i.evalExpression(env, t.Object)
+		if err != nil {
+			return Value{}, err
+		}
+		key, err := i.evalPropertyKey(env, t)
+		if err != nil {
+			return Value{}, err
+		}
+		switch base.Kind() {
+		case UndefinedKind, NullKind:
+			return Value{}, fmt.Errorf("TypeError: Cannot convert %s to object", base.Inspect())
+		case ObjectKind:
+			if base.Object().Delete(key) {
+				return NewBoolean(true), nil
+			}
+			if env.isStrict() {
+				return Value{}, fmt.Errorf("TypeError: Cannot delete property '%s' of %s", key, base.Inspect())
+			}
+			return NewBoolean(false), nil
+		default:
+			return NewBoolean(true), nil
+		}
+	case *ast.Identifier:
+		// Declared bindings cannot be deleted; strict code rejects this form
+		// at parse time.
+		_, found := env.Resolve(t.Name)
+		return NewBoolean(!found), nil
+	default:
+		if _, err := i.evalExpression(env, target); err != nil {
+			return Value{}, err
+		}
+		return NewBoolean(true), nil
+	}
+}
+
 func (i *Interpreter) evalUpdateExpression(env *Environment, expr *ast.UpdateExpression) (Value, error) {
 	target, ok := expr.Argument.(*ast.Identifier)
 	if !ok {
@@ -508,6 +1641,12 @@ func (i *Interpreter) evalUpdateExpression(env *Environment, expr *ast.UpdateExp
 }
 
 func (i *Interpreter) applyBinary(op string, left, right Value) (Value, error) {
+	if left.Kind() == BigIntKind || right.Kind() == BigIntKind {
+		if val, handled, err := applyBigIntBinary(op, left, right); handled {
+			return val, err
+		}
+	}
+
 	switch op {
 	case "+":
 		if left.Kind() == StringKind || right.Kind() == StringKind {
@@ -534,14 +1673,16 @@ func (i *Interpreter) applyBinary(op string, left, right Value) (Value, error) {
 		ln := ToNumber(left)
 		rn := ToNumber(right)
 		return NewNumber(math.Mod(ln.Number(), rn.Number())), nil
+	case "**":
+		return NewNumber(exponentiate(ToNumber(left).Number(), ToNumber(right).Number())), nil
 	case "===":
 		return NewBoolean(StrictEquals(left, right)), nil
 	case "!==":
 		return NewBoolean(!StrictEquals(left, right)), nil
 	case "==":
-		return NewBoolean(StrictEquals(left, right)), nil
+		return NewBoolean(LooseEquals(left, right)), nil
 	case "!=":
-		return NewBoolean(!StrictEquals(left, right)), nil
+		return NewBoolean(!LooseEquals(left, right)), nil
 	case "<":
 		ln := ToNumber(left)
 		rn := ToNumber(right)
@@ -575,6 +1716,73 @@ func (i *Interpreter) applyBinary(op string, left, right Value) (Value, error) {
 	}
 }
 
+// exponentiate implements Number::exponentiate. It differs from math.Pow
+// only in that a base of 1 or -1 raised to an infinite power is NaN.
+func exponentiate(base, exp float64) float64 {
+	if math.IsInf(exp, 0) && math.Abs(base) == 1 {
+		return math.NaN()
+	}
+	return math.Pow(base, exp)
+}
+
+// applyBigIntBinary evaluates arithmetic and relational operators when at least
+// one operand is a BigInt. Operators it does not recognise, and string
+// concatenation, are left to applyBinary.
+func applyBigIntBinary(op string, left, right Value) (Value, bool, error) {
+	bothBig := left.Kind() == BigIntKind && right.Kind() == BigIntKind
+	switch op {
+	case "+", "-", "*", "/", "%", "**":
+		if op == "+" && (left.Kind() == StringKind || right.Kind() == StringKind) {
+			return Value{}, false, nil
+		}
+		if !bothBig {
+			return Value{}, true, fmt.Errorf("TypeError: Cannot mix BigInt and other types, use explicit conversions")
+		}
+		l, r := left.BigInt(), right.BigInt()
+		result := new(big.Int)
+		switch op {
+		case "+":
+			result.Add(l, r)
+		case "-":
+			result.Sub(l, r)
+		case "*":
+			result.Mul(l, r)
+		case "/", "%":
+			if r.Sign() == 0 {
+				return Value{}, true, fmt.Errorf("RangeError: Division by zero")
+			}
+			if op == "/" {
+				result.Quo(l, r)
+			} else {
+				result.Rem(l, r)
+			}
+		case "**":
+			if r.Sign() < 0 {
+				return Value{}, true, fmt.Errorf("RangeError: Exponent must be non-negative")
+			}
+			result.Exp(l, r, nil)
+		}
+		return NewBigInt(result), true, nil
+	case "<", "<=", ">", ">=":
+		if !bothBig {
+			return Value{}, false, nil
+		}
+		cmp := left.BigInt().Cmp(right.BigInt())
+		switch op {
+		case "<":
+			return NewBoolean(cmp < 0), true, nil
+		case "<=":
+			return NewBoolean(cmp <= 0), true, nil
+		case ">":
+			return NewBoolean(cmp > 0), true, nil
+		default:
+			return NewBoolean(cmp >= 0), true, nil
+		}
+	default:
+		return Value{}, false, nil
+	}
+}
+
 func (i *Interpreter) typeOfValue(v Value) string {
 	switch v.Kind() {
 	case UndefinedKind:
@@ -587,6 +1795,13 @@ func (i *Interpreter) typeOfValue(v Value) string {
 		return "number"
 	case StringKind:
 		return "string"
+	case BigIntKind:
+		return "bigint"
+	case ObjectKind:
+		if v.IsCallable() {
+			return "function"
+		}
+		return "object"
 	default:
 		return "object"
 	}
@@ -604,38 +1819,3 @@ func (i *Interpreter) describeCompletion(c completion) string {
 		return "normal"
 	}
 }
-
-func parseNumericLiteral(raw string) (float64, error) {
-	s := strings.ReplaceAll(raw, "_", "")
-	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
-		v, err := strconv.ParseUint(s[2:], 16, 64)
-		if err != nil {
-			return 0, err
-		}
-		return float64(v), nil
-	}
-	if strings.HasPrefix(s, "0o") || strings.HasPrefix(s, "0O") {
-		v, err := strconv.ParseUint(s[2:], 8, 64)
-		if err != nil {
-			return 0, err
-		}
-		return float64(v), nil
-	}
-	if strings.HasPrefix(s, "0b") || strings.HasPrefix(s, "0B") {
-		v, err := strconv.ParseUint(s[2:], 2, 64)
-		if err != nil {
-			return 0, err
-		}
-		return float64(v), nil
-	}
-	if strings.HasPrefix(s, "0") && len(s) > 1 && s[1] >= '0' && s[1] <= '7' && !strings.ContainsAny(s, "89") {
-		v, err := strconv.ParseUint(s[1:], 8, 64)
-		if err == nil {
-			return float64(v), nil
-		}
-	}
-	if strings.HasSuffix(s, "n") {
-		return 0, fmt.Errorf("bigint literals are not supported")
-	}
-	return strconv.ParseFloat(s, 64)
-}
@@ -1,7 +1,15 @@
 package vm
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"es6-interpreter/parser"
 )
@@ -108,9 +116,2562 @@ answer = 7;
 }
 
 func TestInterpreterConstRequiresInitializer(t *testing.T) {
-	executeSnippetExpectError(t, `const missing;`)
+	// A const without an initializer is an early error, reported by the parser.
+	if _, err := parser.New(`const missing;`).ParseProgram(); err == nil {
+		t.Fatalf("expected a syntax error for const without an initializer")
+	}
 }
 
 func TestInterpreterUndefinedIdentifier(t *testing.T) {
 	executeSnippetExpectError(t, `unknown;`)
 }
+
+func TestInterpreterThrowCaughtByOuterHandler(t *testing.T) {
+	result := executeSnippet(t, `
+let caught = "none";
+let steps = 0;
+try {
+  let i = 0;
+  while (i < 3) {
+    {
+      if (i === 1) {
+        throw "boom";
+      }
+    }
+    steps = steps + 1;
+    i = i + 1;
+  }
+} catch (e) {
+  caught = e;
+} finally {
+  steps = steps + 10;
+}
+caught + steps;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "boom11" {
+		t.Fatalf("expected \"boom11\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterCatchRuntimeError(t *testing.T) {
+	result := executeSnippet(t, `
+let message = "";
+try {
+  missing;
+} catch (err) {
+  message = err;
+}
+message;
+`)
+	if result.Kind() != StringKind || !strings.HasPrefix(result.StringValue(), "ReferenceError") {
+		t.Fatalf("expected ReferenceError message, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterTryCatchCompletionValue(t *testing.T) {
+	cases := []struct {
+		src  string
+		want Value
+	}{
+		{"try { 1 } catch {}", NewNumber(1)},
+		{"try { throw 0 } catch { 2 }", NewNumber(2)},
+		{"try { throw 0 } catch (e) { e + 3 }", NewNumber(3)},
+		{"4; try { throw 0 } catch {}", Undefined},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if !StrictEquals(result, tc.want) {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want.Inspect(), result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterFunctionExpressions(t *testing.T) {
+	result := executeSnippet(t, `
+const id = function(x) { return x; };
+const fact = function loop(n) { return n <= 1 ? 1 : n * loop(n - 1); };
+const seven = (function() { return 7; })();
+id(2) + fact(4) + seven;
+`)
+	if result.Kind() != NumberKind || result.Number() != 33 {
+		t.Fatalf("expected 33, got %s", result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "const f = function g() {}; g;")
+	if !strings.Contains(err.Error(), "ReferenceError") {
+		t.Fatalf("expected ReferenceError for out-of-scope name, got %v", err)
+	}
+}
+
+func TestInterpreterFunctionLengthAndName(t *testing.T) {
+	result := executeSnippet(t, `
+function f(a, b, c) {}
+function g(a, b = 1, c) {}
+const h = (a, b) => a;
+var anon;
+anon = function() {};
+const obj = { method: () => 1 };
+const named = function inner() {};
+f.length + "," + g.length + "," + h.length + "," + f.name + "," + h.name + "," + anon.name + "," + obj.method.name + "," + named.name;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "3,1,2,f,h,anon,method,inner" {
+		t.Fatalf("expected function lengths and names, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterInfersFunctionNames(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"const f = () => {}; f.name;", "f"},
+		{"var g; g = function() {}; g.name;", "g"},
+		{"const o = {m: function() {}}; o.m.name;", "m"},
+		{`const o = {["computed" + 1]: () => 0}; o.computed1.name;`, "computed1"},
+		{"const {k = () => 1} = {}; k.name;", "k"},
+		{"const f = function own() {}; f.name;", "own"},
+		{"const o = {m: function own() {}}; o.m.name;", "own"},
+	}
+	for _, tt := range tests {
+		result := executeSnippet(t, tt.src)
+		if result.Kind() != StringKind || result.StringValue() != tt.want {
+			t.Fatalf("%s: expected name %q, got %s", tt.src, tt.want, result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterNullAndUndefinedComparisons(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		// Relational operators convert with ToNumber: null is 0, undefined NaN.
+		{"null < 1", true},
+		{"null >= 0", true},
+		{"void 0 < 1", false},
+		{"void 0 >= 0", false},
+		// Equality treats null and undefined as equal only to each other.
+		{"void 0 == null", true},
+		{"null != void 0", false},
+		{"null == 0", false},
+		{"void 0 == 0", false},
+		{"null === void 0", false},
+		{"'1' == 1", true},
+		{"true == 1", true},
+		{"[2] == 2", true},
+	}
+	for _, tt := range tests {
+		result := executeSnippet(t, tt.src+";")
+		if result.Kind() != BooleanKind || result.Bool() != tt.want {
+			t.Fatalf("%s: expected %t, got %s", tt.src, tt.want, result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterMixedDeclarators(t *testing.T) {
+	result := executeSnippet(t, `
+const arr = [2, 3];
+const obj = {d: 4};
+let a = 1, [b, c] = arr, {d} = obj, e;
+const f = a + b, {g = 5} = obj;
+a + b + c + d + f + g + (e === void 0 ? 100 : 0);
+`)
+	if result.Kind() != NumberKind || result.Number() != 118 {
+		t.Fatalf("expected 118, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterForLoopDeclarationScopes(t *testing.T) {
+	result := executeSnippet(t, `
+function count() {
+  for (var i = 0; i < 3; i++) {}
+  return i;
+}
+count();
+`)
+	if result.Kind() != NumberKind || result.Number() != 3 {
+		t.Fatalf("expected var i to be readable after the loop, got %s", result.Inspect())
+	}
+
+	for _, src := range []string{
+		"for (let i = 0; i < 3; i++) {} i;",
+		"for (const c = 0; false; ) {} c;",
+	} {
+		err := executeSnippetExpectError(t, src)
+		if !strings.Contains(err.Error(), "ReferenceError") {
+			t.Fatalf("%s: expected ReferenceError, got %v", src, err)
+		}
+	}
+
+	result = executeSnippet(t, `
+const fs = [];
+for (let i = 0; i < 3; i++) {
+  fs[i] = () => i;
+}
+"" + fs[0]() + fs[1]() + fs[2]();
+`)
+	if result.Kind() != StringKind || result.StringValue() != "012" {
+		t.Fatalf("expected each closure to capture its own iteration, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterForLoopAssignmentsInTestAndUpdate(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		// The test assigns before comparing, so the body sees i = 1..4.
+		{`
+let runs = 0;
+let i = 0;
+for (; (i = i + 1) < 5; ) {
+  runs = runs + 1;
+}
+runs + ":" + i;
+`, "4:5"},
+		// An empty body with all the work in the update clause.
+		{`
+let total = 0;
+let j = 0;
+for (; j < 4; j++, total = total + j) ;
+total + ":" + j;
+`, "10:4"},
+		// The loop-scoped binding is assigned by the test itself.
+		{`
+let seen = "";
+for (let k = 0; (k = k + 2) <= 6; ) {
+  seen = seen + k;
+}
+seen;
+`, "246"},
+	}
+	for _, tt := range tests {
+		result := executeSnippet(t, tt.src)
+		if result.Kind() != StringKind || result.StringValue() != tt.want {
+			t.Fatalf("expected %q, got %s for:%s", tt.want, result.Inspect(), tt.src)
+		}
+	}
+}
+
+func TestInterpreterUncaughtThrow(t *testing.T) {
+	err := executeSnippetExpectError(t, `
+{
+  let n = 0;
+  while (true) {
+    n = n + 1;
+    if (n > 2) {
+      throw "escaped " + n;
+    }
+  }
+}
+`)
+	var exc *Exception
+	if !errors.As(err, &exc) {
+		t.Fatalf("expected *Exception, got %T", err)
+	}
+	if !strings.Contains(err.Error(), "escaped 3") {
+		t.Fatalf("expected thrown value in error message, got %q", err.Error())
+	}
+}
+
+func TestInterpreterMemberCallEvaluationOrder(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+function mark(label, value) {
+  log = log + label + ";";
+  return value;
+}
+function c(x) {
+  log = log + "call c;";
+  return this === inner;
+}
+let inner = { c: c };
+let a = { b: inner };
+let sameReceiver = mark("a", a)[mark("b", "b")][mark("c", "c")](mark("arg", 1));
+log + sameReceiver;
+`)
+	want := "a;b;c;arg;call c;true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterChainedCallsBindImmediateReceiver(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+function c() {
+  log = log + "c;";
+  return this === second;
+}
+function b() {
+  log = log + "b:" + (this === a) + ";";
+  return second;
+}
+let second = { c: c };
+let a = { b: b };
+let ok = a.b().c();
+log + ok;
+`)
+	want := "b:true;c;true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterPlainCallThis(t *testing.T) {
+	result := executeSnippet(t, `
+function whoAmI() {
+  return this === globalThis ? "global" : typeof this;
+}
+function strictWhoAmI() {
+  "use strict";
+  return typeof this;
+}
+let obj = { whoAmI: whoAmI, strictWhoAmI: strictWhoAmI };
+let detached = obj.whoAmI;
+let strictDetached = obj.strictWhoAmI;
+detached() + "/" + obj.whoAmI() + "/" + strictDetached() + "/" + obj.strictWhoAmI() + "/" + (this === globalThis);
+`)
+	want := "global/object/undefined/object/true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterArrowFunctionsCaptureThis(t *testing.T) {
+	result := executeSnippet(t, `
+let counter = {
+  count: 0,
+  start: function() {
+    const bump = () => { this.count = this.count + 1; return this; };
+    bump();
+    return bump();
+  }
+};
+const returned = counter.start();
+const detached = { run: () => this };
+(returned === counter) + "," + counter.count + "," + (detached.run() === globalThis);
+`)
+	want := "true,2,true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterCallOnUndefinedMember(t *testing.T) {
+	err := executeSnippetExpectError(t, `
+let obj = {};
+obj.missing.call();
+`)
+	if !strings.HasPrefix(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterSwitchFallThrough(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+switch (2) {
+  case 1:
+    log = log + "one;";
+  case 2:
+    log = log + "two;";
+  case 3:
+    log = log + "three;";
+    break;
+  case 4:
+    log = log + "four;";
+}
+log;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "two;three;" {
+		t.Fatalf("expected \"two;three;\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterSwitchDefaultInMiddle(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+let checks = 0;
+function probe(v) {
+  checks = checks + 1;
+  return v;
+}
+switch ("x") {
+  case probe("a"):
+    log = log + "a;";
+  default:
+    log = log + "default;";
+  case probe("b"):
+    log = log + "b;";
+    break;
+  case probe("c"):
+    log = log + "c;";
+}
+log + checks;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "default;b;3" {
+		t.Fatalf("expected \"default;b;3\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterSwitchBreakAndScope(t *testing.T) {
+	result := executeSnippet(t, `
+let x = "outer";
+let hits = 0;
+switch (1) {
+  case 1:
+    let x = "inner";
+    hits = hits + 1;
+    break;
+  case 2:
+    hits = hits + 100;
+}
+x + hits;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "outer1" {
+		t.Fatalf("expected \"outer1\", got %s", result.Inspect())
+	}
+}
+
+func TestSandboxInterpreterOmitsHostBindings(t *testing.T) {
+	intr := NewSandboxInterpreter()
+	for _, name := range []string{"console", "require", "process", "Date"} {
+		program, err := parser.New(name + ";").ParseProgram()
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		_, err = intr.Execute(program)
+		if err == nil || !strings.HasPrefix(err.Error(), "ReferenceError") {
+			t.Fatalf("expected ReferenceError for %s, got %v", name, err)
+		}
+	}
+}
+
+func TestInterpreterConsoleWritesToOutput(t *testing.T) {
+	program, err := parser.New(`
+console.log("x", 1, true);
+console.warn([1, "two"], null);
+console.error();
+`).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	var out bytes.Buffer
+	intr := NewInterpreter()
+	intr.SetOutput(&out)
+	if _, err := intr.Execute(program); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	want := "x 1 true\n[ 1, \"two\" ] null\n\n"
+	if out.String() != want {
+		t.Fatalf("expected output %q, got %q", want, out.String())
+	}
+}
+
+func TestInterpreterMathObject(t *testing.T) {
+	result := executeSnippet(t, `Math.floor(3.7) + "," + Math.max(1, 2, 3) + "," + Math.sqrt(16) + "," + Math.abs(-2) + "," + Math.round(2.5);`)
+	if result.Kind() != StringKind || result.StringValue() != "3,3,4,2,3" {
+		t.Fatalf("expected Math results, got %s", result.Inspect())
+	}
+
+	program, err := parser.New("Math.random();").ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	draw := func(seed uint64) float64 {
+		intr := NewInterpreter()
+		intr.SeedRandom(seed)
+		v, err := intr.Execute(program)
+		if err != nil {
+			t.Fatalf("execute error: %v", err)
+		}
+		if v.Kind() != NumberKind || v.Number() < 0 || v.Number() >= 1 {
+			t.Fatalf("expected a number in [0, 1), got %s", v.Inspect())
+		}
+		return v.Number()
+	}
+	if draw(7) != draw(7) {
+		t.Fatalf("expected the same seed to give the same random number")
+	}
+}
+
+func TestInterpreterMathRound(t *testing.T) {
+	cases := []struct {
+		src  string
+		want float64
+	}{
+		{"Math.round(2.5);", 3},
+		{"Math.round(-2.5);", -2},
+		{"Math.round(-2.6);", -3},
+		{"Math.round(0.49999999999999994);", 0},
+		{"Math.round(9007199254740991);", 9007199254740991},
+		{"Math.round(-9007199254740991);", -9007199254740991},
+		{"Math.round(4503599627370495.5);", 4503599627370496},
+		{"Math.round(-0.4);", math.Copysign(0, -1)},
+		{"Math.round(-0.5);", math.Copysign(0, -1)},
+		{"Math.round(-0);", math.Copysign(0, -1)},
+		{"Math.round(0.4);", 0},
+		{"Math.round(-Infinity);", math.Inf(-1)},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != NumberKind || result.Number() != tc.want || math.Signbit(result.Number()) != math.Signbit(tc.want) {
+			t.Fatalf("%s: expected %v, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+	if got := executeSnippet(t, "1 / Math.round(-0.4);"); got.Number() != math.Inf(-1) {
+		t.Fatalf("expected -Infinity, got %s", got.Inspect())
+	}
+}
+
+func TestInterpreterAutomaticSemicolonInsertion(t *testing.T) {
+	result := executeSnippet(t, "function f() {\n  return\n  42\n}\nlet a = 1\nlet b = a\nb++\nf() + ',' + a + ',' + b")
+	want := "undefined,1,2"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterRunCompiled(t *testing.T) {
+	prog, err := Compile(`
+let total = 0;
+function scale(x) { return x * factor; }
+for (let i = 0; i < inputs.length; i++) {
+  total = total + scale(inputs[i]);
+}
+total;
+`)
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+
+	intr := NewInterpreter()
+	runs := []struct {
+		factor float64
+		inputs []Value
+		want   float64
+	}{
+		{2, []Value{NewNumber(1), NewNumber(2)}, 6},
+		{10, []Value{NewNumber(5)}, 50},
+	}
+	for _, run := range runs {
+		result, err := intr.RunCompiled(prog, map[string]Value{
+			"factor": NewNumber(run.factor),
+			"inputs": NewObjectValue(NewArray(run.inputs)),
+		})
+		if err != nil {
+			t.Fatalf("run error: %v", err)
+		}
+		if result.Kind() != NumberKind || result.Number() != run.want {
+			t.Fatalf("expected %v, got %s", run.want, result.Inspect())
+		}
+	}
+
+	if _, err := Compile("let = ;"); err == nil {
+		t.Fatalf("expected Compile to report syntax errors")
+	}
+}
+
+func TestInterpreterDefineFunction(t *testing.T) {
+	intr := NewInterpreter()
+	err := intr.DefineFunction("add", func(_ Value, args []Value) (Value, error) {
+		sum := 0.0
+		for _, arg := range args {
+			sum += ToNumber(arg).Number()
+		}
+		return NewNumber(sum), nil
+	})
+	if err != nil {
+		t.Fatalf("define error: %v", err)
+	}
+	program, err := parser.New(`typeof add + ":" + add.name + ":" + add(2, 3);`).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	result, err := intr.Execute(program)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if result.Kind() != StringKind || result.StringValue() != "function:add:5" {
+		t.Fatalf("expected \"function:add:5\", got %s", result.Inspect())
+	}
+
+	program, err = parser.New("const taken = 1;").ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if _, err := intr.Execute(program); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if err := intr.DefineFunction("taken", func(Value, []Value) (Value, error) { return Undefined, nil }); err == nil {
+		t.Fatalf("expected an error when redefining a const global")
+	}
+}
+
+func TestInterpreterObjectOwnPropertyKeys(t *testing.T) {
+	result := executeSnippet(t, `
+function list(keys) {
+  let out = "";
+  for (let i = 0; i < keys.length; i++) {
+    out = out + (i > 0 ? "," : "") + keys[i];
+  }
+  return out;
+}
+const arr = [1, 2];
+const fn = function named(a) {};
+const obj = {b: 1, a: 2, 1: 3};
+list(Object.keys(arr)) + " | " +
+  list(Object.getOwnPropertyNames(arr)) + " | " +
+  Object.keys(fn).length + " | " +
+  list(Object.getOwnPropertyNames(fn)) + " | " +
+  list(Object.keys(obj)) + " | " +
+  list(Object.getOwnPropertyNames("hi")) + " | " +
+  Object.getOwnPropertySymbols(obj).length;
+`)
+	want := "0,1 | 0,1,length | 0 | length,name,prototype | 1,b,a | 0,1,length | 0"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "Object.getOwnPropertyNames(null);")
+	if !strings.Contains(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError for null, got %v", err)
+	}
+}
+
+func TestSandboxInterpreterPureComputation(t *testing.T) {
+	program, err := parser.New(`
+function hypot(a, b) {
+  return Math.sqrt(Math.pow(a, 2) + Math.pow(b, 2));
+}
+Math.max(hypot(3, 4), Math.floor(4.7));
+`).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	result, err := NewSandboxInterpreter().Execute(program)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if result.Kind() != NumberKind || result.Number() != 5 {
+		t.Fatalf("expected 5, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterDoWhileRunsBodyOnce(t *testing.T) {
+	result := executeSnippet(t, `
+let runs = 0;
+do {
+  runs = runs + 1;
+} while (false);
+runs;
+`)
+	if result.Kind() != NumberKind || result.Number() != 1 {
+		t.Fatalf("expected body to run once, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterDoWhileContinueRetestsCondition(t *testing.T) {
+	result := executeSnippet(t, `
+let i = 0;
+let tests = 0;
+let odd = 0;
+do {
+  i = i + 1;
+  if (i === 2 || i === 4) {
+    continue;
+  }
+  odd = odd + 1;
+} while ((tests = tests + 1) < 5);
+"" + i + odd + tests;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "535" {
+		t.Fatalf("expected \"535\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterLabeledForContinueRunsUpdate(t *testing.T) {
+	result := executeSnippet(t, `
+let visited = "";
+let updates = 0;
+loop: for (let i = 0; i < 4; i = i + 1, updates = updates + 1) {
+  if (i === 1) {
+    continue loop;
+  }
+  visited = visited + i;
+}
+visited + "/" + updates;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "023/4" {
+		t.Fatalf("expected \"023/4\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterNestedLoopsBreakOuter(t *testing.T) {
+	result := executeSnippet(t, `
+let pairs = "";
+outer: for (let i = 0; i < 3; i = i + 1) {
+  let j = 0;
+  while (j < 3) {
+    if (i === 1 && j === 1) {
+      break outer;
+    }
+    pairs = pairs + i + j + ",";
+    j = j + 1;
+  }
+}
+pairs;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "00,01,02,10," {
+		t.Fatalf("expected \"00,01,02,10,\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterNestedLoopsContinueOuter(t *testing.T) {
+	result := executeSnippet(t, `
+let pairs = "";
+let i = 0;
+outer: do {
+  i = i + 1;
+  for (let j = 0; j < 3; j = j + 1) {
+    if (j > i) {
+      continue outer;
+    }
+    pairs = pairs + i + j + ",";
+  }
+} while (i < 3);
+pairs;
+`)
+	want := "10,11,20,21,22,30,31,32,"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterArrayLiteralSpreadAndHoles(t *testing.T) {
+	result := executeSnippet(t, `
+const a = [1, 2];
+const b = 3;
+const arr = [...a, , b, ...[, 5], ...""];
+let present = "";
+for (const key in arr) {
+  present = present + key + "=" + arr[key] + ";";
+}
+arr.length + ":" + present + arr[2] + arr[4];
+`)
+	want := "6:0=1;1=2;3=3;4=undefined;5=5;undefinedundefined"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "[...{}];")
+	if !strings.Contains(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError for non-iterable spread, got %v", err)
+	}
+}
+
+func TestInterpreterCallSpreadArguments(t *testing.T) {
+	result := executeSnippet(t, `
+function join(a, b, c, d) {
+  return "" + a + b + c + d;
+}
+class Pair {
+  constructor(a, b) { this.sum = a + b; }
+}
+Math.max(...[1, 2, 3]) + ":" + join(1, ...[2, 3], 4) + ":" + join(..."ab", ...[], "c", ...[void 0]) +
+  ":" + new Pair(...[5, 6]).sum;
+`)
+	want := "3:1234:abcundefined:11"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "Math.max(...{});")
+	if !strings.Contains(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError for non-iterable spread, got %v", err)
+	}
+}
+
+func TestInterpreterObjectLiteralSpreadPrecedence(t *testing.T) {
+	result := executeSnippet(t, `
+const a = { x: 1, y: 2 };
+const c = { y: 3, z: 4, get w() { return "got"; } };
+const obj = { ...a, y: 9, x: 0, ...c, ...null, ...void 0 };
+let out = "";
+for (const key in obj) {
+  out = out + key + "=" + obj[key] + ";";
+}
+out;
+`)
+	want := "x=0;y=3;z=4;w=got;"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterArrayDestructuringDeclarations(t *testing.T) {
+	result := executeSnippet(t, `
+const arr = [1, , 3, 4, 5];
+const [a, b = 2, c] = arr;
+let [first, ...rest] = arr;
+var [, , [nested = "deep"] = [], ...[x, y]] = ["skip", "skip"];
+var [p, q] = "hi";
+a + "," + b + "," + c + "|" + first + "," + rest.length + "," + rest[3] + "|" + nested + x + y + "|" + p + q;
+`)
+	want := "1,2,3|1,4,5|deepundefinedundefined|hi"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "const [z] = {};")
+	if !strings.Contains(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError destructuring a non-iterable, got %v", err)
+	}
+}
+
+func TestInterpreterObjectDestructuringDeclarations(t *testing.T) {
+	result := executeSnippet(t, `
+const key = "dyn";
+const obj = { x: 1, y: 2, dyn: 3, inner: { deep: 4 }, extra: 5 };
+const { x, y: renamed, [key]: computed, missing = "dflt", inner: { deep }, ...others } = obj;
+let keys = "";
+for (const k in others) {
+  keys = keys + k + ";";
+}
+x + "," + renamed + "," + computed + "," + missing + "," + deep + "|" + keys;
+`)
+	want := "1,2,3,dflt,4|extra;"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "const { a } = null;")
+	if !strings.Contains(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError destructuring null, got %v", err)
+	}
+}
+
+func TestInterpreterConditionalCoercesTest(t *testing.T) {
+	cases := []struct {
+		src  string
+		want float64
+	}{
+		{`"" ? 1 : 2`, 2},
+		{`"0" ? 1 : 2`, 1},
+		{`({}) ? 1 : 2`, 1},
+		{`[] ? 1 : 2`, 1},
+		{`(0 / 0) ? 1 : 2`, 2},
+		{`0 ? 1 : 2`, 2},
+		{`-0 ? 1 : 2`, 2},
+		{`null ? 1 : 2`, 2},
+		{`void 0 ? 1 : 2`, 2},
+		{`0n ? 1 : 2`, 2},
+		{`1n ? 1 : 2`, 1},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != NumberKind || result.Number() != tc.want {
+			t.Fatalf("%s: expected %v, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterEmptyDestructuringPatterns(t *testing.T) {
+	result := executeSnippet(t, `
+const [] = [1, 2];
+let [] = "text";
+var {} = 5;
+const {} = { a: 1 };
+"ok";
+`)
+	if result.Kind() != StringKind || result.StringValue() != "ok" {
+		t.Fatalf("expected \"ok\", got %s", result.Inspect())
+	}
+
+	for _, src := range []string{
+		"const {} = null;",
+		"const {} = void 0;",
+		"const [] = null;",
+		"let [] = void 0;",
+		"var [] = 5;",
+	} {
+		err := executeSnippetExpectError(t, src)
+		if !strings.HasPrefix(err.Error(), "TypeError") {
+			t.Fatalf("%s: expected TypeError, got %v", src, err)
+		}
+	}
+}
+
+func TestInterpreterArrayIterators(t *testing.T) {
+	result := executeSnippet(t, `
+const it = [10, 20].entries();
+const first = it.next();
+const second = it.next();
+const last = it.next();
+first.value[0] + ":" + first.value[1] + "," + second.value[0] + ":" + second.value[1] + "," + last.done + "," + last.value;
+`)
+	want := "0:10,1:20,true,undefined"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	result = executeSnippet(t, `
+const holey = [1, , 3];
+const keys = [...holey.keys()];
+const values = [...holey.values()];
+const [a, b, c] = holey.values();
+keys.length + ":" + keys[0] + keys[1] + keys[2] + "|" + values.length + ":" + values[0] + values[1] + values[2] + "|" + a + b + c;
+`)
+	want = "3:012|3:1undefined3|1undefined3"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterArrayMethods(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`[1, 2, 3].map(x => x * 2);`, `[ 2, 4, 6 ]`},
+		{`[1, 2, 3, 4].filter(x => x % 2 === 0);`, `[ 2, 4 ]`},
+		{`[1, 2, 3].reduce((a, b) => a + b, 0);`, `6`},
+		{`[1, 2, 3].reduce((a, b) => a + b);`, `6`},
+		{`[1, 2, 3, 4].slice(1, -1);`, `[ 2, 3 ]`},
+		{`[1, 2, 3].join("-") + [null, void 0, 4].join();`, `"1-2-3,,4"`},
+		{`[1, 2, 3].indexOf(3) + [1, 2, 3].indexOf("3");`, `1`},
+		{`[5, 6].map((x, i, arr) => x + i + arr.length);`, `[ 7, 9 ]`},
+		{`var a = []; a.push(a); a.join();`, `""`},
+		{`var a = [1, 2]; a.push(a, 3); a.join("-");`, `"1-2--3"`},
+		{`var a = [1]; a.push([2, a]); a.join(";");`, `"1;2,"`},
+	}
+	for _, tc := range cases {
+		if got := executeSnippet(t, tc.src).Inspect(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+
+	result := executeSnippet(t, `
+const arr = [2, 3];
+const pushed = arr.push(4, 5);
+const popped = arr.pop();
+const unshifted = arr.unshift(0, 1);
+const shifted = arr.shift();
+let seen = "";
+arr.forEach((x, i) => { seen = seen + i + "=" + x + ";"; });
+pushed + "," + popped + "," + unshifted + "," + shifted + "," + arr.length + "|" + seen;
+`)
+	want := "4,5,5,0,4|0=1;1=2;2=3;3=4;"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "[].reduce((a, b) => a + b);")
+	if !strings.Contains(err.Error(), "TypeError: Reduce of empty array") {
+		t.Fatalf("expected empty reduce TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterContinueLabelFromSwitch(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+let updates = 0;
+outer: for (let i = 0; i < 4; i = i + 1, updates = updates + 1) {
+  switch (i) {
+    case 1:
+      continue outer;
+    case 2:
+      log = log + "two;";
+      continue;
+    default:
+      log = log + i + ";";
+  }
+  log = log + "after" + i + ";";
+}
+log + updates;
+`)
+	want := "0;after0;two;3;after3;4"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterLabelSetsAndNonLoopLabels(t *testing.T) {
+	result := executeSnippet(t, `
+let log = "";
+a: b: for (let i = 0; i < 3; i = i + 1) {
+  if (i === 0) {
+    continue a;
+  }
+  if (i === 2) {
+    break b;
+  }
+  log = log + i;
+}
+block: {
+  log = log + "in;";
+  if (true) {
+    break block;
+  }
+  log = log + "skipped;";
+}
+log;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "1in;" {
+		t.Fatalf("expected \"1in;\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterTypeofUndeclaredIdentifier(t *testing.T) {
+	result := executeSnippet(t, `typeof undeclared;`)
+	if result.Kind() != StringKind || result.StringValue() != "undefined" {
+		t.Fatalf("expected \"undefined\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterTypeofMemberOfUndeclaredThrows(t *testing.T) {
+	err := executeSnippetExpectError(t, `typeof undeclared.x;`)
+	if !strings.HasPrefix(err.Error(), "ReferenceError") {
+		t.Fatalf("expected ReferenceError, got %v", err)
+	}
+}
+
+func TestInterpreterTypeofMissingProperty(t *testing.T) {
+	result := executeSnippet(t, `
+let definedObj = { present: 1 };
+typeof definedObj.missingProp;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "undefined" {
+		t.Fatalf("expected \"undefined\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterVoidEvaluatesOperand(t *testing.T) {
+	result := executeSnippet(t, `
+let x = 0;
+let calls = 0;
+const r = void (x = 1);
+void calls++;
+(r === void 0) + ":" + typeof void x + ":" + x + ":" + calls;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "true:undefined:1:1" {
+		t.Fatalf("expected \"true:undefined:1:1\", got %s", result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, `void undefinedVar;`)
+	if !strings.HasPrefix(err.Error(), "ReferenceError") {
+		t.Fatalf("expected ReferenceError, got %v", err)
+	}
+	err = executeSnippetExpectError(t, `void (function () { throw "boom"; })();`)
+	if !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the thrown value to propagate, got %v", err)
+	}
+}
+
+func TestInterpreterUnaryPlusMinusStringToNumber(t *testing.T) {
+	cases := []struct {
+		src  string
+		want float64
+	}{
+		{`+"0x10"`, 16},
+		{`+"0XfF"`, 255},
+		{`+"0o17"`, 15},
+		{`+"0b101"`, 5},
+		{`+"Infinity"`, math.Inf(1)},
+		{`+"-Infinity"`, math.Inf(-1)},
+		{`-"  5 "`, -5},
+		{`+"  42 "`, 42},
+		{`+"\t\n 1.5e3\u00a0"`, 1500},
+		{`+"007"`, 7},
+		{`+".5"`, 0.5},
+		{`+"5."`, 5},
+		{`+""`, 0},
+		{`+"   "`, 0},
+		{`+"12px"`, math.NaN()},
+		{`+"0x"`, math.NaN()},
+		{`+"-0x10"`, math.NaN()},
+		{`+"0b102"`, math.NaN()},
+		{`+"infinity"`, math.NaN()},
+		{`+"Inf"`, math.NaN()},
+		{`+"1e"`, math.NaN()},
+		{`+"."`, math.NaN()},
+		{`+"1_000"`, math.NaN()},
+		{`+"NaN"`, math.NaN()},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != NumberKind {
+			t.Fatalf("%s: expected a number, got %s", tc.src, result.Inspect())
+		}
+		got := result.Number()
+		if math.IsNaN(tc.want) != math.IsNaN(got) || !math.IsNaN(got) && got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", tc.src, tc.want, got)
+		}
+	}
+}
+
+func TestInterpreterNumberToString(t *testing.T) {
+	// Expected strings are what V8 produces.
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`"" + 0`, "0"},
+		{`"" + -0`, "0"},
+		{`"" + 100`, "100"},
+		{`"" + -1.5`, "-1.5"},
+		{`"" + 123.456`, "123.456"},
+		{`"" + 1 / 3`, "0.3333333333333333"},
+		{`"" + (0.1 + 0.2)`, "0.30000000000000004"},
+		{`"" + 9007199254740992`, "9007199254740992"},
+		{`"" + 100000000000000000000`, "100000000000000000000"},
+		{`"" + 123456789012345680000`, "123456789012345680000"},
+		{`"" + 1e21`, "1e+21"},
+		{`"" + 12e20`, "1.2e+21"},
+		{`"" + 1.7976931348623157e308`, "1.7976931348623157e+308"},
+		{`"" + 0.000001`, "0.000001"},
+		{`"" + 0.0000001`, "1e-7"},
+		{`"" + -1.5e-7`, "-1.5e-7"},
+		{`"" + 5e-324`, "5e-324"},
+		{`"" + 0 / 0`, "NaN"},
+		{`"" + -1 / 0`, "-Infinity"},
+		{`JSON.stringify([1e21, -0, 0.0000001])`, "[1e+21,0,1e-7]"},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != StringKind || result.StringValue() != tc.want {
+			t.Fatalf("%s: expected %q, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterNumberPrototypeMethods(t *testing.T) {
+	// Expected strings are what V8 produces.
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`(255).toString(16)`, "ff"},
+		{`(0).toString(2)`, "0"},
+		{`(-255).toString(36)`, "-73"},
+		{`(0.5).toString(2)`, "0.1"},
+		{`(0.1).toString(2)`, "0.0001100110011001100110011001100110011001100110011001101"},
+		{`(3.75).toString(8)`, "3.6"},
+		{`(2 ** 60).toString(16)`, "1000000000000000"},
+		{`(12.5).toString()`, "12.5"},
+		{`(1e21).toString(10)`, "1e+21"},
+		{`(3.14159).toFixed(2)`, "3.14"},
+		{`(2.5).toFixed(0)`, "3"},
+		{`(1.005).toFixed(2)`, "1.00"},
+		{`(9.995).toFixed(2)`, "9.99"},
+		{`(9.99).toFixed(1)`, "10.0"},
+		{`(0.05).toFixed(1)`, "0.1"},
+		{`(-1.5).toFixed(0)`, "-2"},
+		{`(-0.0001).toFixed(2)`, "-0.00"},
+		{`(0).toFixed(2)`, "0.00"},
+		{`(123).toFixed()`, "123"},
+		{`(1e21).toFixed(2)`, "1e+21"},
+		{`(123.456).toPrecision(4)`, "123.5"},
+		{`(123.456).toPrecision(2)`, "1.2e+2"},
+		{`(0.00001234).toPrecision(2)`, "0.000012"},
+		{`(0.0000001234).toPrecision(2)`, "1.2e-7"},
+		{`(99.99).toPrecision(3)`, "100"},
+		{`(0).toPrecision(3)`, "0.00"},
+		{`(5).toPrecision(1)`, "5"},
+		{`(1.5).toPrecision()`, "1.5"},
+		{`typeof (5).valueOf()`, "number"},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != StringKind || result.StringValue() != tc.want {
+			t.Fatalf("%s: expected %q, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+
+	for _, src := range []string{`(1).toString(1)`, `(1).toString(37)`, `(1).toFixed(101)`, `(1).toPrecision(0)`} {
+		err := executeSnippetExpectError(t, src)
+		if !strings.HasPrefix(err.Error(), "RangeError") {
+			t.Fatalf("%s: expected RangeError, got %v", src, err)
+		}
+	}
+}
+
+func TestInterpreterGlobalUndefinedNaNInfinity(t *testing.T) {
+	result := executeSnippet(t, `
+const checks = [
+  NaN !== NaN,
+  typeof undefined === "undefined",
+  undefined === void 0,
+  1 / 0 === Infinity,
+  -Infinity < 0,
+  isNaN(NaN),
+];
+undefined = 1;
+NaN = 2;
+Infinity = 3;
+var undefined;
+checks.push(undefined === void 0, NaN !== NaN, Infinity === 1 / 0);
+function shadow(undefined) { return undefined; }
+checks.push(shadow(4) === 4);
+checks.join(",");
+`)
+	want := "true,true,true,true,true,true,true,true,true,true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, `"use strict";
+undefined = 1;`)
+	if !strings.HasPrefix(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError assigning undefined in strict code, got %v", err)
+	}
+	result = executeSnippet(t, `
+function f() { "use strict"; try { Infinity = 0; } catch (e) { return "caught"; } return "ignored"; }
+f();
+`)
+	if result.Kind() != StringKind || result.StringValue() != "caught" {
+		t.Fatalf("expected the strict assignment to throw, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterNestedConditionals(t *testing.T) {
+	result := executeSnippet(t, `
+function grade(n) { return n >= 90 ? "A" : n >= 80 ? "B" : n >= 70 ? "C" : "F"; }
+function sign(n) { return n >= 0 ? n > 0 ? "+" : "0" : "-"; }
+let x = 0;
+true ? (x = 1) : (x = 2);
+let y;
+false ? y = 1 : y = 2;
+const untaken = true ? "ok" : missing.property;
+const thrown = false ? (function () { throw "boom"; })() : "safe";
+[grade(95), grade(85), grade(75), grade(10), sign(3), sign(0), sign(-3), x, y, untaken, thrown].join(",");
+`)
+	want := "A,B,C,F,+,0,-,1,2,ok,safe"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, `false ? 1 : missing;`)
+	if !strings.HasPrefix(err.Error(), "ReferenceError") {
+		t.Fatalf("expected ReferenceError from the taken branch, got %v", err)
+	}
+}
+
+func TestInterpreterNewExpressionCallees(t *testing.T) {
+	result := executeSnippet(t, `
+class Point {
+  constructor(x) { this.x = x; this.c = "point"; }
+}
+const a = { b: { c: Point } };
+const table = { p: Point };
+function getCtor() { return Point; }
+function Factory() { this.make = function () { return "made"; }; }
+const results = [
+  new a.b.c(1).x,
+  new table["p"](2).x,
+  new (getCtor())(3).x,
+  new a.b.c(4).c,
+  new Factory().make(),
+  Object.getPrototypeOf(new Point(5)) === Point.prototype,
+];
+results.join(",");
+`)
+	want := "1,2,3,point,made,true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterDeleteMemberProperties(t *testing.T) {
+	result := executeSnippet(t, `
+let o = { a: 1, b: 2 };
+let removed = delete o.a;
+let missing = delete o.missing;
+"" + removed + missing + typeof o.a + o.b;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "truetrueundefined2" {
+		t.Fatalf("expected \"truetrueundefined2\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterDeleteArrayElementLeavesHole(t *testing.T) {
+	result := executeSnippet(t, `
+let arr = [1, 2, 3];
+delete arr[1];
+arr;
+`)
+	if got := result.Inspect(); got != "[ 1, <1 empty item>, 3 ]" {
+		t.Fatalf("expected hole at index 1, got %s", got)
+	}
+	result = executeSnippet(t, `
+let arr = [1, 2, 3];
+delete arr[1];
+"" + arr.length + arr[2];
+`)
+	if result.Kind() != StringKind || result.StringValue() != "33" {
+		t.Fatalf("expected length 3 with arr[2] unchanged, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterDeleteNonConfigurable(t *testing.T) {
+	result := executeSnippet(t, `
+let arr = [1];
+var declared = 1;
+"" + (delete arr.length) + (delete declared) + (delete undeclared);
+`)
+	if result.Kind() != StringKind || result.StringValue() != "falsefalsetrue" {
+		t.Fatalf("expected \"falsefalsetrue\", got %s", result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, `"use strict"; let arr = [1]; delete arr.length;`)
+	if !strings.HasPrefix(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError in strict mode, got %v", err)
+	}
+}
+
+func TestInterpreterNullishCoalescing(t *testing.T) {
+	result := executeSnippet(t, `
+let calls = 0;
+function fallback() {
+  calls = calls + 1;
+  return "fallback";
+}
+let zero = 0 ?? fallback();
+let empty = null ?? fallback();
+"" + zero + empty + calls;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "0fallback1" {
+		t.Fatalf("expected \"0fallback1\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterSequenceExpressionStatement(t *testing.T) {
+	result := executeSnippet(t, `
+let x = 0;
+(x = 1, x = 2);
+x;
+`)
+	if result.Kind() != NumberKind || result.Number() != 2 {
+		t.Fatalf("expected 2, got %s", result.Inspect())
+	}
+
+	result = executeSnippet(t, `
+let log = "";
+function a() { log = log + "a"; return 1; }
+function b() { log = log + "b"; return 2; }
+let last = (a(), b());
+log + last;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "ab2" {
+		t.Fatalf("expected both calls in order and the last value, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterOptionalChainShortCircuits(t *testing.T) {
+	result := executeSnippet(t, `
+let a = { b: void 0 };
+let calls = 0;
+function count() {
+  calls = calls + 1;
+  return "k";
+}
+let first = a?.b?.c;
+let whole = a.b?.c.d[count()]();
+let missing = void 0;
+let call = missing?.();
+"" + first + whole + call + calls;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "undefinedundefinedundefined0" {
+		t.Fatalf("expected the chains to short-circuit, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterOptionalCallKeepsReceiver(t *testing.T) {
+	result := executeSnippet(t, `
+function self() {
+  return this;
+}
+let obj = { self: self, nothing: null };
+"" + (obj.self?.() === obj) + (obj?.self() === obj) + obj.nothing?.();
+`)
+	if result.Kind() != StringKind || result.StringValue() != "truetrueundefined" {
+		t.Fatalf("expected \"truetrueundefined\", got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterParenthesizedChainDoesNotShortCircuit(t *testing.T) {
+	err := executeSnippetExpectError(t, `
+let a = {};
+(a?.b).c;
+`)
+	if !strings.HasPrefix(err.Error(), "TypeError") {
+		t.Fatalf("expected TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterVoidYieldsUndefined(t *testing.T) {
+	result := executeSnippet(t, `void 0;`)
+	if result.Kind() != UndefinedKind || !StrictEquals(result, Undefined) {
+		t.Fatalf("expected undefined, got %s", result.Inspect())
+	}
+
+	result = executeSnippet(t, `"" + (void 0 === void "anything") + typeof void 0;`)
+	if result.Kind() != StringKind || result.StringValue() != "trueundefined" {
+		t.Fatalf("expected \"trueundefined\", got %s", result.Inspect())
+	}
+
+	result = executeSnippet(t, `
+let x = 1;
+let v = void (x++);
+"" + typeof v + x;
+`)
+	if result.Kind() != StringKind || result.StringValue() != "undefined2" {
+		t.Fatalf("expected operand side effects to run, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterBigIntArithmetic(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"2n + 3n;", "5"},
+		{"10n * 10n;", "100"},
+		{"0xFFn - 0b101n;", "250"},
+		{"7n / 2n;", "3"},
+		{"-123456789012345678901234567890n * 2n;", "-246913578024691357802469135780"},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != BigIntKind {
+			t.Fatalf("%s: expected BigInt result, got %v", tc.src, result.Inspect())
+		}
+		if got := result.BigInt().String(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+
+	if got := executeSnippet(t, "typeof 1n;").StringValue(); got != "bigint" {
+		t.Fatalf("expected typeof 1n to be bigint, got %q", got)
+	}
+	if !executeSnippet(t, "2n > 1n && 1n === 1n;").Bool() {
+		t.Fatalf("expected BigInt comparisons to hold")
+	}
+
+	err := executeSnippetExpectError(t, "1n + 1;")
+	if !strings.Contains(err.Error(), "TypeError: Cannot mix BigInt") {
+		t.Fatalf("expected mixing TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterExponentiation(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`2 ** 10;`, `1024`},
+		{`2 ** 3 ** 2;`, `512`},
+		{`(-2) ** 2;`, `4`},
+		{`1 ** (1 / 0);`, `NaN`},
+		{`2n ** 64n;`, `18446744073709551616n`},
+		{`let a = 2; a **= 3; a;`, `8`},
+		{`let b = 2; b **= (b = 10, 3); b;`, `8`},
+		{`let c = 2; c **= 1 + 1; c;`, `4`},
+	}
+	for _, tc := range cases {
+		if got := executeSnippet(t, tc.src).Inspect(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+
+	result := executeSnippet(t, `
+let reads = 0;
+let writes = 0;
+const obj = {
+  v: 3,
+  get n() { reads = reads + 1; return this.v; },
+  set n(x) { writes = writes + 1; this.v = x; }
+};
+const out = (obj.n **= 2);
+out + "," + obj.v + "," + reads + "," + writes;
+`)
+	want := "9,9,1,1"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	err := executeSnippetExpectError(t, "2n ** -1n;")
+	if !strings.Contains(err.Error(), "RangeError") {
+		t.Fatalf("expected RangeError for a negative BigInt exponent, got %v", err)
+	}
+}
+
+func TestInterpreterStringReplace(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`"a-b-c".replace("-", "+");`, "a+b-c"},
+		{`"a-b-c".replaceAll("-", "+");`, "a+b+c"},
+		{`"a1b22c333".replaceAll(/\d+/g, "#");`, "a#b#c#"},
+		{`"John Smith".replace(/(\w+)\s(\w+)/, "$2, $1");`, "Smith, John"},
+		{`"price: 10".replace(/\d+/, "[$&] $$");`, "price: [10] $"},
+		{`"ab".replaceAll("", "-");`, "-a-b-"},
+		{`function shout(match, word, offset) { return word + offset; }
+		  "hi there".replace(/(t)h/, shout);`, "hi t3ere"},
+		{`function upper(match) { return "<" + match + ">"; }
+		  "x y z".replaceAll(/[a-z]/g, upper);`, "<x> <y> <z>"},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != StringKind || result.StringValue() != tc.want {
+			t.Fatalf("%s: expected %q, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+
+	err := executeSnippetExpectError(t, `"aaa".replaceAll(/a/, "b");`)
+	if !strings.Contains(err.Error(), "TypeError: replaceAll must be called with a global RegExp") {
+		t.Fatalf("expected non-global replaceAll TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterStringMatchAndSearch(t *testing.T) {
+	result := executeSnippet(t, `let m = "2024-06-15".match(/(\d+)-(\d+)/); m[0] + "|" + m[1] + "|" + m[2] + "|" + m.index;`)
+	if got := result.StringValue(); got != "2024-06|2024|06|0" {
+		t.Fatalf("unexpected non-global match result %q", got)
+	}
+
+	result = executeSnippet(t, `"a1b22c333".match(/\d+/g);`)
+	if got := result.Inspect(); got != `[ "1", "22", "333" ]` {
+		t.Fatalf("unexpected global match result %s", got)
+	}
+
+	if got := executeSnippet(t, `"abc".match(/x/);`); got.Kind() != NullKind {
+		t.Fatalf("expected null for a failed match, got %s", got.Inspect())
+	}
+
+	result = executeSnippet(t, `
+		let it = "k1=v1;k2=v2".matchAll(/(\w+)=(\w+)/g);
+		let out = "";
+		let step = it.next();
+		while (!step.done) {
+			out = out + step.value[1] + ":" + step.value[2] + "@" + step.value.index + " ";
+			step = it.next();
+		}
+		out;
+	`)
+	if got := result.StringValue(); got != "k1:v1@0 k2:v2@6 " {
+		t.Fatalf("unexpected matchAll iteration %q", got)
+	}
+
+	if got := executeSnippet(t, `"hello world".search(/o\s/);`).Number(); got != 4 {
+		t.Fatalf("expected search index 4, got %v", got)
+	}
+	if got := executeSnippet(t, `"hello".search("z");`).Number(); got != -1 {
+		t.Fatalf("expected search miss to return -1, got %v", got)
+	}
+
+	err := executeSnippetExpectError(t, `"aaa".matchAll(/a/);`)
+	if !strings.Contains(err.Error(), "TypeError: matchAll must be called with a global RegExp") {
+		t.Fatalf("expected non-global matchAll TypeError, got %v", err)
+	}
+}
+
+func TestInterpreterStringMethods(t *testing.T) {
+	if got := executeSnippet(t, `"hello".length;`).Number(); got != 5 {
+		t.Fatalf("expected length 5, got %v", got)
+	}
+
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`"hello".slice(1, 3);`, "el"},
+		{`"hello".slice(-3);`, "llo"},
+		{`"hello".substring(4, 1);`, "ell"},
+		{`"hello".charAt(1) + "hello".charAt(9);`, "e"},
+		{`"" + "hello".charCodeAt(0) + "," + "hello".indexOf("l") + "," + "hello".indexOf("l", 3) + "," + "hello".indexOf("z");`, "104,2,3,-1"},
+		{`"" + "hello".includes("ell") + "," + "hello".includes("h", 1);`, "true,false"},
+		{`"Hello".toUpperCase() + "Hello".toLowerCase();`, "HELLOhello"},
+		{"\" \\t hi\\n\\u00a0\".trim();", "hi"},
+		{`"😀x".length + "," + "😀x".charCodeAt(1) + "," + "😀x".slice(2);`, "3,56832,x"},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if result.Kind() != StringKind || result.StringValue() != tc.want {
+			t.Fatalf("%s: expected %q, got %s", tc.src, tc.want, result.Inspect())
+		}
+	}
+
+	splits := []struct {
+		src  string
+		want string
+	}{
+		{`"a,b,c".split(",");`, `[ "a", "b", "c" ]`},
+		{`"abc".split("");`, `[ "a", "b", "c" ]`},
+		{`"a,b,c".split(",", 2);`, `[ "a", "b" ]`},
+		{`"abc".split();`, `[ "abc" ]`},
+		{`",a,".split(",");`, `[ "", "a", "" ]`},
+	}
+	for _, tc := range splits {
+		if got := executeSnippet(t, tc.src).Inspect(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+}
+
+func TestInterpreterGetterOnlyAndSetterOnlyProperties(t *testing.T) {
+	result := executeSnippet(t, `
+let obj = {
+  base: 20,
+  get total() { return this.base + 1; },
+  set sink(v) { this.base = v; }
+};
+obj.total = 99;
+let before = obj.total;
+obj.sink = 5;
+before + "," + obj.total + "," + obj.sink;
+`)
+	if got := result.StringValue(); got != "21,6,undefined" {
+		t.Fatalf("expected \"21,6,undefined\", got %q", got)
+	}
+
+	err := executeSnippetExpectError(t, `
+"use strict";
+let obj = { get total() { return 1; } };
+obj.total = 2;
+`)
+	if !strings.Contains(err.Error(), "TypeError: Cannot set property total") {
+		t.Fatalf("expected strict getter-only assignment to throw, got %v", err)
+	}
+
+	result = executeSnippet(t, `
+let obj = { get x() { return "g"; }, set x(v) { this.last = v; } };
+obj.x = "s";
+obj.x + obj.last;
+`)
+	if got := result.StringValue(); got != "gs" {
+		t.Fatalf("expected paired accessor to keep both halves, got %q", got)
+	}
+}
+
+func TestInterpreterForInSkipsKeysDeletedDuringIteration(t *testing.T) {
+	result := executeSnippet(t, `
+let obj = { a: 1, b: 2, c: 3, d: 4 };
+let visited = "";
+for (let key in obj) {
+  visited = visited + key;
+  if (key === "a") {
+    delete obj.c;
+    obj.e = 5;
+  }
+}
+visited;
+`)
+	if got := result.StringValue(); got != "abd" {
+		t.Fatalf("expected deleted key c and added key e to be skipped, got %q", got)
+	}
+
+	result = executeSnippet(t, `
+var seen = "";
+for (var k in { x: 1, y: 2 }) {
+  if (k === "x") {
+    continue;
+  }
+  seen = seen + k;
+}
+for (k in null) {
+  seen = seen + "!";
+}
+seen + k;
+`)
+	if got := result.StringValue(); got != "yy" {
+		t.Fatalf("expected \"yy\", got %q", got)
+	}
+}
+
+func executeModule(t *testing.T, src string, modules map[string]string) (Value, error) {
+	t.Helper()
+	program, err := parser.New(src).ParseModule()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	intr := NewInterpreter()
+	intr.SetModuleLoader(func(specifier string) (string, error) {
+		src, ok := modules[specifier]
+		if !ok {
+			return "", errors.New("Error: Cannot find module '" + specifier + "'")
+		}
+		return src, nil
+	})
+	return intr.ExecuteModule(program)
+}
+
+func TestInterpreterModules(t *testing.T) {
+	modules := map[string]string{
+		"counter": `
+export let count = 0;
+export function increment() { count += 1; }
+let secret = 42;
+export { secret as answer };
+export default function () { return "default"; }
+`,
+		"reexport": `
+export { count as current, answer } from "counter";
+export * from "extra";
+export * as counterNS from "counter";
+`,
+		"extra": `export const extra = "extra"; export default "ignored";`,
+	}
+
+	result, err := executeModule(t, `
+import makeDefault, { increment, count as c } from "counter";
+import * as ns from "reexport";
+increment();
+increment();
+[c, ns.current, ns.answer, ns.extra, makeDefault(), Object.keys(ns).join(","), ns.counterNS.count].join(" ");
+`, modules)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	want := "2 2 42 extra default answer,counterNS,current,extra 2"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	failures := []struct {
+		src  string
+		want string
+	}{
+		{`import { missing } from "counter";`, "does not provide an export named 'missing'"},
+		{`import { count } from "counter"; count = 1;`, "Assignment to constant variable"},
+		{`import * as ns from "counter"; ns.count = 1;`, "TypeError"},
+		{`import * as ns from "counter"; ns.added = 1;`, "TypeError"},
+		{`import "nowhere";`, "Cannot find module 'nowhere'"},
+	}
+	for _, tc := range failures {
+		_, err := executeModule(t, tc.src, modules)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.src, tc.want, err)
+		}
+	}
+}
+
+func TestInterpreterModuleCycle(t *testing.T) {
+	modules := map[string]string{
+		"a": `import { b } from "b"; export function a() { return "a"; } export const fromB = b();`,
+		"b": `import { a } from "a"; export function b() { return a() + "b"; }`,
+	}
+	result, err := executeModule(t, `import { fromB } from "a"; fromB;`, modules)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if result.StringValue() != "ab" {
+		t.Fatalf("expected ab, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterNewExpression(t *testing.T) {
+	result := executeSnippet(t, `
+function Point(x, y) {
+  this.x = x;
+  this.y = y;
+}
+Point.prototype.sum = function () { return this.x + this.y; };
+const p = new Point(3, 4);
+function Other() {
+  this.ignored = true;
+  return { replaced: "yes" };
+}
+function Primitive() {
+  this.kept = "kept";
+  return 1;
+}
+[p.x, p.y, p.sum(), p.constructor === Point, new Other().replaced, new Other().ignored === void 0, new Primitive().kept].join(",");
+`)
+	want := "3,4,7,true,yes,true,kept"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	for _, src := range []string{
+		"const f = () => {}; new f();",
+		"const notFn = 1; new notFn();",
+	} {
+		err := executeSnippetExpectError(t, src)
+		if !strings.Contains(err.Error(), "is not a constructor") {
+			t.Errorf("%s: unexpected error %v", src, err)
+		}
+	}
+}
+
+func TestInterpreterPrototypeChainLookup(t *testing.T) {
+	result := executeSnippet(t, `
+const base = { greet: "hello", shared: "base" };
+const middle = Object.setPrototypeOf({ shared: "middle" }, base);
+const child = Object.setPrototypeOf({}, middle);
+child.own = 1;
+[child.greet, child.shared, child.missing === void 0, Object.keys(child).join(","),
+  Object.getPrototypeOf(child) === middle, Object.getPrototypeOf(base) === null,
+  Object.getPrototypeOf(Object.setPrototypeOf(child, null)) === null].join(" ");
+`)
+	want := "hello middle true own true true true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	failures := []struct {
+		src  string
+		want string
+	}{
+		{"const a = {}; const b = Object.setPrototypeOf({}, a); Object.setPrototypeOf(a, b);", "Cannot set prototype"},
+		{"Object.setPrototypeOf({}, 1);", "may only be an Object or null"},
+		{"Object.getPrototypeOf(null);", "Cannot convert undefined or null"},
+	}
+	for _, tc := range failures {
+		err := executeSnippetExpectError(t, tc.src)
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.src, tc.want, err)
+		}
+	}
+}
+
+func TestInterpreterAccessorProperties(t *testing.T) {
+	result := executeSnippet(t, `
+const temp = {
+  _celsius: 0,
+  get fahrenheit() { return this._celsius * 9 / 5 + 32; },
+  set fahrenheit(v) { this._celsius = (v - 32) * 5 / 9; }
+};
+temp.fahrenheit = 212;
+const inherited = Object.setPrototypeOf({ _celsius: 10 }, temp);
+inherited.fahrenheit = 32;
+[temp._celsius, temp.fahrenheit, inherited._celsius, inherited.fahrenheit, temp._celsius].join(",");
+`)
+	if got := result.StringValue(); got != "100,212,0,32,100" {
+		t.Fatalf("expected \"100,212,0,32,100\", got %q", got)
+	}
+}
+
+func TestInterpreterClasses(t *testing.T) {
+	result := executeSnippet(t, `
+class Shape {
+  constructor(name) { this.name = name; }
+  describe() { return this.name + " with area " + this.area; }
+  static create(name) { return new this(name); }
+}
+class Rect extends Shape {
+  constructor(w, h) {
+    super("rect");
+    this.w = w;
+    this.h = h;
+  }
+  get area() { return this.w * this.h; }
+  set width(v) { this.w = v; }
+  describe() { return "a " + super.describe(); }
+}
+class Plain extends Shape {}
+const r = new Rect(2, 3);
+r.width = 4;
+const Anon = class {};
+[r.describe(), Plain.create("plain").describe(), Object.keys(Object.getPrototypeOf(r)).length,
+  r.constructor === Rect, Rect.name, Anon.name].join("|");
+`)
+	want := "a rect with area 12|plain with area undefined|0|true|Rect|Anon"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	failures := []struct {
+		src  string
+		want string
+	}{
+		{"class A {} A();", "cannot be invoked without 'new'"},
+		{"class A { m() {} } new (new A().m)();", "is not a constructor"},
+		{"class A extends 1 {}", "is not a constructor or null"},
+		{`class A { get x() { return 1; } m() { this.x = 2; } } new A().m();`, "Cannot set property x"},
+	}
+	for _, tc := range failures {
+		err := executeSnippetExpectError(t, tc.src)
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.src, tc.want, err)
+		}
+	}
+}
+
+func TestInterpreterGenerators(t *testing.T) {
+	result := executeSnippet(t, `
+function* count(limit) {
+  for (let n = 1; n <= limit; n++) {
+    yield n;
+  }
+  return "done";
+}
+const gen = count(3);
+const seen = [gen.next().value, gen.next().value, gen.next().value];
+const last = gen.next();
+seen.push(last.value, last.done, gen.next().done);
+
+function* echo() {
+  const got = yield "first";
+  yield got * 2;
+}
+const e = echo();
+seen.push(e.next("ignored").value, e.next(21).value);
+
+function* outer() {
+  yield 0;
+  yield* count(2);
+  yield* "ab";
+}
+const all = [];
+const o = outer();
+for (let r = o.next(); !r.done; r = o.next()) {
+  all.push(r.value);
+}
+seen.push(all.join(""));
+
+const obj = { *items() { yield this.x; }, x: "method" };
+seen.push(obj.items().next().value);
+seen.join(",");
+`)
+	want := "1,2,3,done,true,true,first,42,012ab,method"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	result = executeSnippet(t, `
+const log = [];
+function* guarded() {
+  try {
+    yield 1;
+    yield 2;
+  } catch (e) {
+    log.push("caught " + e);
+    yield 3;
+  } finally {
+    log.push("cleanup");
+  }
+}
+let g = guarded();
+g.next();
+log.push(g.throw("boom").value);
+log.push(g.next().done);
+g = guarded();
+g.next();
+const closed = g.return("early");
+log.push(closed.value, closed.done, g.next().done);
+log.join(",");
+`)
+	want = "caught boom,3,cleanup,true,cleanup,early,true,true"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	err := executeSnippetExpectError(t, "function* g() { yield 1; } new g();")
+	if !strings.Contains(err.Error(), "is not a constructor") {
+		t.Fatalf("expected generator construction to fail, got %v", err)
+	}
+	err = executeSnippetExpectError(t, "function* g() { gen.next(); yield 1; } const gen = g(); gen.next();")
+	if !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("expected reentrant next to fail, got %v", err)
+	}
+}
+
+func TestInterpreterForOf(t *testing.T) {
+	result := executeSnippet(t, `
+const out = [];
+for (const n of [1, 2, 3, 4]) {
+  if (n === 2) continue;
+  if (n === 4) break;
+  out.push(n);
+}
+for (let ch of "a😀b") out.push(ch);
+var last;
+for (last of [5, 6]);
+out.push(last);
+for (const [k, v] of [["x", 1], ["y", 2]]) out.push(k + v);
+const fns = [];
+for (let n of [7, 8]) fns.push(() => n);
+out.push(fns[0]() + fns[1]());
+function firstOver(list, limit) {
+  for (const n of list) {
+    if (n > limit) return n;
+  }
+}
+out.push(firstOver([1, 9, 12], 8));
+outer: for (const a of [1, 2]) {
+  for (const b of [1, 2]) {
+    if (b === 2) continue outer;
+    out.push("" + a + b);
+  }
+}
+out.join(",");
+`)
+	want := "1,3,a,😀,b,6,x1,y2,15,9,11,21"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	result = executeSnippet(t, `
+const log = [];
+function* numbers() {
+  try {
+    yield 1;
+    yield 2;
+    yield 3;
+  } finally {
+    log.push("closed");
+  }
+}
+for (const n of numbers()) {
+  log.push(n);
+  if (n === 2) break;
+}
+log.join(",");
+`)
+	if got := result.StringValue(); got != "1,2,closed" {
+		t.Fatalf("expected breaking out of a generator to close it, got %q", got)
+	}
+
+	err := executeSnippetExpectError(t, "for (const x of 5) {}")
+	if !strings.Contains(err.Error(), "is not iterable") {
+		t.Fatalf("expected non-iterable error, got %v", err)
+	}
+}
+
+func TestInterpreterForInEnumeratesOwnAndInheritedKeys(t *testing.T) {
+	result := executeSnippet(t, `
+const out = [];
+for (const k in { a: 1, b: 2 }) out.push(k);
+out.push("|");
+for (const idx in [10, , 30]) out.push(typeof idx + idx);
+out.push("|");
+const base = { shared: 1, inherited: 2 };
+const child = Object.setPrototypeOf({ own: 3, shared: 4 }, base);
+for (const k in child) out.push(k);
+out.push("|");
+class Point { constructor() { this.x = 1; } norm() {} }
+for (const k in new Point()) out.push(k);
+out.join(" ");
+`)
+	want := "a b | string0 string2 | own shared inherited | x"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterObjectStaticMethods(t *testing.T) {
+	result := executeSnippet(t, `
+const src = { a: 1, b: "two" };
+const withGetter = { get g() { return "got"; } };
+const target = { a: 0, keep: true };
+const assigned = Object.assign(target, src, null, void 0, withGetter);
+[
+  Object.keys(src).join(","),
+  Object.values(src).join(","),
+  Object.entries(src).map(function (e) { return e[0] + "=" + e[1]; }).join(","),
+  Object.values("hi").join(","),
+  assigned === target,
+  Object.entries(target).join(";")
+].join(" | ");
+`)
+	want := "a,b | 1,two | a=1,b=two | h,i | true | a,1;keep,true;b,two;g,got"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterObjectFreeze(t *testing.T) {
+	result := executeSnippet(t, `
+const frozen = Object.freeze({ x: 1, nested: { y: 2 } });
+frozen.x = 99;
+frozen.added = true;
+delete frozen.x;
+frozen.nested.y = 3;
+let strictError = "";
+(function () {
+  "use strict";
+  try { frozen.x = 5; } catch (e) { strictError = e; }
+})();
+[frozen.x, frozen.added === void 0, frozen.nested.y, strictError, Object.freeze(7)].join(" | ");
+`)
+	want := "1 | true | 3 | TypeError: Cannot assign to read only property 'x' of { x: 1, nested: { y: 3 } } | 7"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterJSONStringify(t *testing.T) {
+	result := executeSnippet(t, `
+const parts = [
+  JSON.stringify({ a: [1, 2], b: "x" }),
+  JSON.stringify({ f: function () {}, u: void 0, n: null, s: "q\"\n" }),
+  JSON.stringify([function () {}, void 0, 0 / 0, true]),
+  JSON.stringify({ a: 1, b: [2] }, null, 2),
+  JSON.stringify({ a: 1, b: 2, c: 3 }, ["c", "a"]),
+  JSON.stringify({ a: 1, b: "drop" }, function (k, v) { return typeof v === "string" ? void 0 : v; }),
+  JSON.stringify({ toJSON: function (key) { return "custom" + key; } }),
+  typeof JSON.stringify(function () {})
+];
+const cyclic = {};
+cyclic.self = cyclic;
+try { JSON.stringify(cyclic); } catch (e) { parts.push(e); }
+parts.join("\n");
+`)
+	want := `{"a":[1,2],"b":"x"}
+{"n":null,"s":"q\"\n"}
+[null,null,null,true]
+{
+  "a": 1,
+  "b": [
+    2
+  ]
+}
+{"c":3,"a":1}
+{"a":1}
+"custom"
+undefined
+TypeError: Converting circular structure to JSON`
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestInterpreterJSONParse(t *testing.T) {
+	result := executeSnippet(t, `
+const text = '{"name":"widget","tags":["a","b"],"size":{"w":1.5,"h":-20},"ok":true,"none":null,"esc":"\\u0041\\n"}';
+const value = JSON.parse(text);
+const exponent = JSON.parse("[-2e1, 0.5E+1]");
+const revived = JSON.parse('{"a":1,"b":{"c":2}}', function (k, v) { return typeof v === "number" ? v * 10 : v; });
+const errors = [];
+for (const bad of ['{"a":1,}', "[1 2]", "'x'", "", "01"]) {
+  try { JSON.parse(bad); errors.push("parsed " + bad); } catch (e) { errors.push(e.slice(0, 11)); }
+}
+[
+  value.name, value.tags.length, value.size.w + value.size.h, value.ok, value.none, value.esc,
+  JSON.stringify(value) === text.replace("\\u0041", "A"),
+  revived.a + revived.b.c,
+  exponent.join(","),
+  errors.join(",")
+].join(" | ");
+`)
+	want := "widget | 2 | -18.5 | true |  | A\n | true | 30 | -20,5 | SyntaxError,SyntaxError,SyntaxError,SyntaxError,SyntaxError"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterNumberParsingGlobals(t *testing.T) {
+	result := executeSnippet(t, `
+[
+  parseInt("0xFF"), parseInt("10", 2), parseInt("  -42px"), parseInt("z", 36), parseInt("0x1A", 16),
+  parseInt("12", 1), parseInt("abc"), parseInt("08"), parseInt(""),
+  parseFloat("3.14abc"), parseFloat("  -.5e1x"), parseFloat("1e"), parseFloat("-Infinityx"), parseFloat("."),
+  isNaN("x"), isNaN("12"), isNaN(void 0), isFinite("1e3"), isFinite(1 / 0), isFinite(null)
+].join(",");
+`)
+	want := "255,2,-42,35,26,NaN,NaN,8,NaN,3.14,-5,1,-Infinity,NaN,true,false,true,true,false,true"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterArrayTrailingCommaLength(t *testing.T) {
+	result := executeSnippet(t, `[[1, 2,].length, [1, 2,,].length, [,].length, [1, 2,,].join("-")].join(" ");`)
+	if got := result.StringValue(); got != "2 3 1 1-2-" {
+		t.Fatalf("expected %q, got %q", "2 3 1 1-2-", got)
+	}
+}
+
+func TestInterpreterHashbangScript(t *testing.T) {
+	result := executeSnippet(t, "#!/usr/bin/env node\nlet x = 20;\nx + 1;")
+	if result.Kind() != NumberKind || result.Number() != 21 {
+		t.Fatalf("expected 21, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterLogicalAndMemberCompoundAssignment(t *testing.T) {
+	result := executeSnippet(t, `
+let calls = 0;
+function next() { calls++; return "rhs"; }
+let a = 0, b = 1, c = null, d = 0;
+const results = [a ||= next(), b ||= next(), a &&= next(), d &&= next(), c ??= next(), d ??= next()];
+const o = { x: 1, s: "a", n: null };
+o.x += 1;
+o.s += "b";
+o["x"] *= 5;
+o.n ??= "filled";
+o.x ||= next();
+const named = {};
+named.fn ||= function () {};
+let f;
+f ??= () => 1;
+[results.join(","), calls, o.x, o.s, o.n, typeof named.fn, f.name].join(" | ");
+`)
+	want := "rhs,1,rhs,0,rhs,0 | 3 | 10 | ab | filled | function | f"
+	if got := result.StringValue(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestInterpreterCallDepthLimit(t *testing.T) {
+	err := executeSnippetExpectError(t, "function f(n) { return f(n + 1); }\nf(0);")
+	if !strings.Contains(err.Error(), "RangeError: Maximum call stack size exceeded") {
+		t.Fatalf("expected RangeError for unbounded recursion, got %v", err)
+	}
+
+	result := executeSnippet(t, `
+let depth = 0;
+function g() { depth++; g(); }
+let caught;
+try { g(); } catch (e) { caught = e; }
+function count(n) { return n === 0 ? 0 : 1 + count(n - 1); }
+typeof caught + ":" + caught + ":" + (depth > 0) + ":" + count(100);
+`)
+	want := "string:RangeError: Maximum call stack size exceeded:true:100"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+
+	program, err := parser.New("function h(n) { return n === 0 ? 0 : h(n - 1); }\nh(5);").ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	intr := NewInterpreter()
+	intr.MaxCallDepth = 5
+	if _, err := intr.Execute(program); err == nil || !strings.Contains(err.Error(), "RangeError") {
+		t.Fatalf("expected RangeError past MaxCallDepth, got %v", err)
+	}
+	intr.MaxCallDepth = 6
+	if result, err := intr.Execute(program); err != nil || result.Number() != 0 {
+		t.Fatalf("expected h(5) to fit in 6 calls, got %v, %v", result.Inspect(), err)
+	}
+
+	prog, err := Compile("function h(n) { return n === 0 ? 0 : h(n - 1); }\nh(5);")
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+	intr.MaxCallDepth = 5
+	if _, err := intr.RunCompiled(prog, nil); err == nil || !strings.Contains(err.Error(), "RangeError") {
+		t.Fatalf("expected RangeError past MaxCallDepth in a compiled run, got %v", err)
+	}
+	intr.MaxCallDepth = 6
+	if result, err := intr.RunCompiled(prog, nil); err != nil || result.Number() != 0 {
+		t.Fatalf("expected compiled h(5) to fit in 6 calls, got %v, %v", result.Inspect(), err)
+	}
+}
+
+func TestInterpreterExecuteContextAbortsInfiniteLoop(t *testing.T) {
+	for _, src := range []string{
+		"while (true) {}",
+		"for (;;);",
+		"let n = 0;\nwhile (true) { try { n++; } catch (e) {} finally { n--; } }",
+		"function spin() { do { try { throw 1; } catch (e) {} } while (true); }\nspin();",
+		"while (true) { try { while (true) {} } finally { continue; } }",
+		"outer: for (;;) { try { for (;;) {} } catch (e) {} finally { break outer; } }\nwhile (true) {}",
+		"function f() { try { while (true) {} } finally { return 1; } }\nwhile (true) { f(); }",
+	} {
+		program, err := parser.New(src).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = NewInterpreter().ExecuteContext(ctx, program)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("%q: expected the deadline to abort execution, got %v", src, err)
+		}
+	}
+
+	program, err := parser.New("let total = 0;\nfor (let k = 0; k < 5000; k++) { total += k; }\ntotal;").ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	result, err := NewInterpreter().ExecuteContext(context.Background(), program)
+	if err != nil || result.Number() != 12497500 {
+		t.Fatalf("expected 12497500, got %s, %v", result.Inspect(), err)
+	}
+
+	prog, err := Compile("while (spin) {}")
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	intr := NewInterpreter()
+	_, err = intr.RunCompiledContext(ctx, prog, map[string]Value{"spin": NewBoolean(true)})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to abort the compiled run, got %v", err)
+	}
+	result, err = intr.RunCompiledContext(context.Background(), prog, map[string]Value{"spin": NewBoolean(false)})
+	if err != nil || result.Kind() != UndefinedKind {
+		t.Fatalf("expected the compiled run to finish, got %s, %v", result.Inspect(), err)
+	}
+}
+
+func TestInterpreterRuntimeErrorPositions(t *testing.T) {
+	cases := []struct {
+		src          string
+		message      string
+		line, column int
+	}{
+		{"let a = 1;\nlet b = a +\n    missing * 2;", "ReferenceError: missing is not defined", 3, 4},
+		{"const c = 1;\nfunction f() {\n  c = 2;\n}\nf();", `TypeError: Assignment to constant variable "c"`, 3, 2},
+		{"let o = null;\n\no.x;", "TypeError: Cannot read properties of null (reading 'x')", 3, 0},
+	}
+	for _, tc := range cases {
+		err := executeSnippetExpectError(t, tc.src)
+		var rtErr *RuntimeError
+		if !errors.As(err, &rtErr) {
+			t.Fatalf("%q: expected a *RuntimeError, got %T: %v", tc.src, err, err)
+		}
+		if rtErr.Err.Error() != tc.message {
+			t.Fatalf("%q: expected message %q, got %q", tc.src, tc.message, rtErr.Err.Error())
+		}
+		if rtErr.Position.Line != tc.line || rtErr.Position.Column != tc.column {
+			t.Fatalf("%q: expected position %d:%d, got %d:%d", tc.src, tc.line, tc.column, rtErr.Position.Line, rtErr.Position.Column)
+		}
+		want := fmt.Sprintf("%s (%d:%d)", tc.message, tc.line, tc.column+1)
+		if err.Error() != want {
+			t.Fatalf("%q: expected %q, got %q", tc.src, want, err.Error())
+		}
+	}
+
+	// Scripts catch the message alone, and thrown values are not wrapped.
+	result := executeSnippet(t, `let caught; try { missing; } catch (e) { caught = e; } caught;`)
+	if result.Kind() != StringKind || result.StringValue() != "ReferenceError: missing is not defined" {
+		t.Fatalf("expected the bare message to be caught, got %s", result.Inspect())
+	}
+	err := executeSnippetExpectError(t, `throw "plain";`)
+	var exc *Exception
+	if !errors.As(err, &exc) || err.Error() != "Uncaught plain" {
+		t.Fatalf("expected an unwrapped *Exception, got %T: %v", err, err)
+	}
+}
+
+func TestInterpreterStrictnessFollowsParsedDirectives(t *testing.T) {
+	result := executeSnippet(t, `
+const escaped = (function () { 'use\x20strict'; return this === undefined; })();
+const plain = (function () { 'use strict'; return this === undefined; })();
+const arrowEscaped = (() => { "use\x20strict"; return (function () { return this === undefined; })(); })();
+const arrowPlain = (() => { "use strict"; return (function () { return this === undefined; })(); })();
+[escaped, plain, arrowEscaped, arrowPlain].join(",");
+`)
+	if result.Kind() != StringKind || result.StringValue() != "false,true,false,true" {
+		t.Fatalf("expected only the unescaped directives to enable strict mode, got %s", result.Inspect())
+	}
+
+	result = executeSnippet(t, `'use\x20strict';
+undefined = 1;
+"sloppy";`)
+	if result.Kind() != StringKind || result.StringValue() != "sloppy" {
+		t.Fatalf("expected an escaped program directive to stay sloppy, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterBindsParameterAndCatchPatterns(t *testing.T) {
+	cases := []struct {
+		src  string
+		want Value
+	}{
+		{`function h(a, ...r) { return r.length; } h(1, 2, 3);`, NewNumber(2)},
+		{`function h(a, ...r) { return r.length; } h();`, NewNumber(0)},
+		{`function h(...r) { r.push(4); return r.join(","); } h(1, 2, 3);`, NewString("1,2,3,4")},
+		{`function k([a, b]) { return a + b; } k([1, 2]);`, NewNumber(3)},
+		{`function k({ x }) { return x; } k({ x: 7 });`, NewNumber(7)},
+		{`function k({ x = 5 } = {}, [y] = [6]) { return x + y; } k();`, NewNumber(11)},
+		{`const f = ([a], { b: [c] }) => a + c; f([1], { b: [2] });`, NewNumber(3)},
+		{`let r; try { throw [1, 2]; } catch ([a, b]) { r = a + b; } r;`, NewNumber(3)},
+		{`let r; try { throw { message: "m" }; } catch ({ message }) { r = message; } r;`, NewString("m")},
+	}
+	for _, tc := range cases {
+		result := executeSnippet(t, tc.src)
+		if !StrictEquals(result, tc.want) {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want.Inspect(), result.Inspect())
+		}
+	}
+}
+
+func TestInterpreterYieldDelegationForwardsToInnerIterator(t *testing.T) {
+	result := executeSnippet(t, `
+const log = [];
+function* inner() {
+  try {
+    const got = yield "a";
+    log.push("got " + got);
+    yield "b";
+  } catch (e) {
+    log.push("caught " + e);
+    yield "recovered";
+  } finally {
+    log.push("finally");
+  }
+  return "result";
+}
+function* outer() {
+  const r = yield* inner();
+  log.push("returned " + r);
+  yield "after";
+}
+
+let g = outer();
+log.push(g.next().value, g.next(7).value, g.next().value, g.next().done);
+
+g = outer();
+g.next();
+log.push(g.throw("boom").value);
+
+g = outer();
+g.next();
+const closed = g.return("stop");
+log.push(closed.value, closed.done, g.next().done);
+
+const plain = {
+  next() { return { value: 1, done: false }; },
+  return() { log.push("closed"); return { done: true }; },
+};
+function* wrap() { yield* plain; }
+g = wrap();
+g.next();
+try {
+  g.throw("x");
+} catch (e) {
+  log.push(e.indexOf("TypeError:") === 0);
+}
+log.join(",");
+`)
+	want := "got 7,finally,returned result,a,b,after,true," +
+		"caught boom,recovered," +
+		"finally,stop,true,true," +
+		"closed,true"
+	if result.Kind() != StringKind || result.StringValue() != want {
+		t.Fatalf("expected %q, got %s", want, result.Inspect())
+	}
+}
+
+func TestInterpreterResumesGeneratorsAcrossExecuteCalls(t *testing.T) {
+	intr := NewInterpreter()
+	defer intr.Close()
+	run := func(src string) Value {
+		t.Helper()
+		program, err := parser.New(src).ParseProgram()
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		result, err := intr.Execute(program)
+		if err != nil {
+			t.Fatalf("execute error: %v", err)
+		}
+		return result
+	}
+	if got := run("function* g() { yield 1; yield 2; }\nvar it = g();\nit.next().value;"); !StrictEquals(got, NewNumber(1)) {
+		t.Fatalf("expected 1 from the first input, got %s", got.Inspect())
+	}
+	if got := run("it.next().value;"); !StrictEquals(got, NewNumber(2)) {
+		t.Fatalf("expected 2 when resuming in a later Execute call, got %s", got.Inspect())
+	}
+}
+
+func TestInterpreterCloseReleasesSuspendedGenerators(t *testing.T) {
+	program, err := parser.New(`
+function* parked() {
+  try {
+    try {
+      yield 1;
+    } catch (e) {
+      console.log("catch ran");
+    } finally {
+      console.log("finally ran");
+    }
+  } finally {
+    console.log("outer finally ran");
+  }
+}
+function* delegating() {
+  for (const v of parked()) {
+    yield* parked();
+  }
+}
+var gens = [];
+for (let n = 0; n < 50; n++) {
+  const g = parked();
+  g.next();
+  gens.push(g);
+  const d = delegating();
+  d.next();
+  gens.push(d);
+}
+`).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	before := runtime.NumGoroutine()
+	var out bytes.Buffer
+	intr := NewInterpreter()
+	intr.SetOutput(&out)
+	if _, err := intr.Execute(program); err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	intr.Close()
+	if out.Len() != 0 {
+		t.Fatalf("expected no script code to run while closing generators, got %q", out.String())
+	}
+
+	// Closed bodies hand back control just before their goroutines exit.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("expected generator goroutines to exit, %d remain", n-before)
+	}
+
+	resumed, err := parser.New(`gens[0].next().done && gens[1].next().done;`).ParseProgram()
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	result, err := intr.Execute(resumed)
+	if err != nil {
+		t.Fatalf("execute error: %v", err)
+	}
+	if result.Kind() != BooleanKind || !result.Bool() {
+		t.Fatalf("expected closed generators to report done, got %s", result.Inspect())
+	}
+}
+
+func TestInterpreterInspectsCircularObjects(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`var a = {}; a.self = a; a;`, "{ self: [Circular] }"},
+		{`var a = { x: 1 }; a.inner = { back: a }; a;`, "{ x: 1, inner: { back: [Circular] } }"},
+		{`var shared = { v: 1 }; ({ p: shared, q: shared });`, "{ p: { v: 1 }, q: { v: 1 } }"},
+	}
+	for _, tc := range cases {
+		if got := executeSnippet(t, tc.src).Inspect(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+}
+
+func TestInterpreterCircularArraysInspectAndConvert(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{`var a = []; a.push(a); "" + a;`, `""`},
+		{`var a = [1, 2]; a.push(a); "" + a;`, `"1,2,"`},
+		{`var a = [1]; var b = [a, 2]; a.push(b); "" + a;`, `"1,,2"`},
+		{`var shared = [1]; "" + [shared, shared];`, `"1,1"`},
+		{`var a = [1]; a.push(a); a;`, "[ 1, [Circular] ]"},
+		{`var a = [1]; a.push({ back: a }); a;`, "[ 1, { back: [Circular] } ]"},
+	}
+	for _, tc := range cases {
+		if got := executeSnippet(t, tc.src).Inspect(); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.src, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// newIterator creates an iterator object whose next method reports the values
+// produced by step, following the iterator protocol. step is called once per
+// next call and reports done once the sequence is exhausted; it must keep
+// doing so on later calls. class names the iterator, as in "Array Iterator".
+func (i *Interpreter) newIterator(class string, step func() (Value, bool, error)) *Object {
+	iter := NewObject(nil)
+	iter.class = class
+	i.defineMethod(iter, "next", func(_ Value, _ []Value) (Value, error) {
+		v, done, err := step()
+		if err != nil {
+			return Value{}, err
+		}
+		return i.iteratorResult(v, done), nil
+	})
+	return iter
+}
+
+// iteratorResult creates an object of the form { value, done }.
+func (i *Interpreter) iteratorResult(v Value, done bool) Value {
+	result := NewObject(nil)
+	result.Set("value", v)
+	result.Set("done", NewBoolean(done))
+	return NewObjectValue(result)
+}
+
+// iterableValues collects the values produced by iterating v.
+func (i *Interpreter) iterableValues(v Value) ([]Value, error) {
+	step, err := i.iterate(v)
+	if err != nil {
+		return nil, err
+	}
+	var values []Value
+	for {
+		val, done, err := step()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return values, nil
+		}
+		values = append(values, val)
+	}
+}
+
+// iterate returns a function producing the values of v one at a time, which
+// reports done once they are exhausted. Without symbols there is no
+// Symbol.iterator lookup yet: arrays yield their elements with holes read as
+// undefined, strings yield their code points, and objects with a next
+// method, such as generators and the iterators returned by built-ins, are
+// driven through the iterator protocol.
+func (i *Interpreter) iterate(v Value) (func() (Value, bool, error), error) {
+	switch {
+	case v.Kind() == StringKind:
+		runes := []rune(v.StringValue())
+		idx := 0
+		return func() (Value, bool, error) {
+			if idx >= len(runes) {
+				return Undefined, true, nil
+			}
+			idx++
+			return NewString(string(runes[idx-1])), false, nil
+		}, nil
+	case v.Kind() == ObjectKind && v.Object().class == "Array":
+		arr := v.Object()
+		var idx uint32
+		return func() (Value, bool, error) {
+			if idx >= arr.Length() {
+				return Undefined, true, nil
+			}
+			idx++
+			val, err := i.getProperty(v, strconv.FormatUint(uint64(idx-1), 10))
+			return val, false, err
+		}, nil
+	case v.Kind() == ObjectKind:
+		next, err := i.getProperty(v, "next")
+		if err != nil {
+			return nil, err
+		}
+		if !next.IsCallable() {
+			break
+		}
+		return func() (Value, bool, error) {
+			result, err := i.callFunction(next, v, nil)
+			if err != nil {
+				return Value{}, false, err
+			}
+			if result.Kind() != ObjectKind {
+				return Value{}, false, fmt.Errorf("TypeError: Iterator result %s is not an object", result.Inspect())
+			}
+			done, err := i.getProperty(result, "done")
+			if err != nil {
+				return Value{}, false, err
+			}
+			if ToBoolean(done) {
+				return Undefined, true, nil
+			}
+			val, err := i.getProperty(result, "value")
+			return val, false, err
+		}, nil
+	}
+	return nil, fmt.Errorf("TypeError: %s is not iterable", v.Inspect())
+}
+
+// closeIterator tells an iterator that v is no longer being consumed by
+// calling its return method, as generators provide. Arrays and strings are
+// iterated directly and need no closing.
+func (i *Interpreter) closeIterator(v Value) error {
+	if v.Kind() != ObjectKind || v.Object().class == "Array" {
+		return nil
+	}
+	ret, err := i.getProperty(v, "return")
+	if err != nil || !ret.IsCallable() {
+		return err
+	}
+	_, err = i.callFunction(ret, v, nil)
+	return err
+}
@@ -0,0 +1,537 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// newJSONObject builds the JSON global with stringify and parse.
+func (i *Interpreter) newJSONObject() *Object {
+	obj := NewObject(nil)
+	obj.class = "JSON"
+	i.defineMethod(obj, "stringify", func(_ Value, args []Value) (Value, error) {
+		return i.jsonStringify(argAt(args, 0), argAt(args, 1), argAt(args, 2))
+	})
+	i.defineMethod(obj, "parse", func(_ Value, args []Value) (Value, error) {
+		p := &jsonParser{i: i, src: stringArg(args, 0)}
+		v, err := p.parse()
+		if err != nil {
+			return Value{}, err
+		}
+		if reviver := argAt(args, 1); reviver.IsCallable() {
+			root := NewObject(nil)
+			root.DefineProperty("", v, true, true, true)
+			return i.jsonRevive(root, "", reviver)
+		}
+		return v, nil
+	})
+	return obj
+}
+
+// jsonSerializer carries the state of one JSON.stringify call.
+type jsonSerializer struct {
+	i            *Interpreter
+	replacer     Value    // replacer function, if one was given
+	propertyList []string // keys selected by an array replacer, or nil
+	gap          string
+	indent       string
+	stack        []*Object // objects being serialized, to detect cycles
+}
+
+func (i *Interpreter) jsonStringify(v, replacer, space Value) (Value, error) {
+	s := &jsonSerializer{i: i}
+	if replacer.IsCallable() {
+		s.replacer = replacer
+	} else if replacer.Kind() == ObjectKind && replacer.Object().class == "Array" {
+		list := replacer.Object()
+		s.propertyList = []string{}
+		seen := map[string]bool{}
+		for idx := uint32(0); idx < list.Length(); idx++ {
+			item := list.Get(indexKey(idx))
+			if item.Kind() != StringKind && item.Kind() != NumberKind {
+				continue
+			}
+			key := ToString(item).StringValue()
+			if !seen[key] {
+				seen[key] = true
+				s.propertyList = append(s.propertyList, key)
+			}
+		}
+	}
+	switch space.Kind() {
+	case NumberKind:
+		n := min(toIntegerOrInfinity(space), 10)
+		if n >= 1 {
+			s.gap = strings.Repeat(" ", int(n))
+		}
+	case StringKind:
+		s.gap = space.StringValue()
+		if units := utf16.Encode([]rune(s.gap)); len(units) > 10 {
+			s.gap = string(utf16.Decode(units[:10]))
+		}
+	}
+
+	holder := NewObject(nil)
+	holder.DefineProperty("", v, true, true, true)
+	out, ok, err := s.serializeProperty(holder, "", v)
+	if err != nil || !ok {
+		return Undefined, err
+	}
+	return NewString(out), nil
+}
+
+// serializeProperty renders the value v stored under key in holder. It
+// reports false for values JSON has no representation for, which objects
+// omit and arrays render as null.
+func (s *jsonSerializer) serializeProperty(holder *Object, key string, v Value) (string, bool, error) {
+	if v.Kind() == ObjectKind || v.Kind() == BigIntKind {
+		toJSON, err := s.i.getProperty(v, "toJSON")
+		if err != nil {
+			return "", false, err
+		}
+		if toJSON.IsCallable() {
+			if v, err = s.i.callFunction(toJSON, v, []Value{NewString(key)}); err != nil {
+				return "", false, err
+			}
+		}
+	}
+	if s.replacer.IsCallable() {
+		var err error
+		if v, err = s.i.callFunction(s.replacer, NewObjectValue(holder), []Value{NewString(key), v}); err != nil {
+			return "", false, err
+		}
+	}
+
+	switch v.Kind() {
+	case NullKind:
+		return "null", true, nil
+	case BooleanKind:
+		return ToString(v).StringValue(), true, nil
+	case StringKind:
+		return quoteJSONString(v.StringValue()), true, nil
+	case NumberKind:
+		if math.IsNaN(v.Number()) || math.IsInf(v.Number(), 0) {
+			return "null", true, nil
+		}
+		return ToString(v).StringValue(), true, nil
+	case BigIntKind:
+		return "", false, fmt.Errorf("TypeError: Do not know how to serialize a BigInt")
+	case ObjectKind:
+		if v.IsCallable() {
+			return "", false, nil
+		}
+		if v.Object().class == "Array" {
+			out, err := s.serializeArray(v.Object())
+			return out, true, err
+		}
+		out, err := s.serializeObject(v.Object())
+		return out, true, err
+	default:
+		return "", false, nil
+	}
+}
+
+// enter records obj as being serialized and returns the indentation of its
+// members, or fails when obj is already on the stack.
+func (s *jsonSerializer) enter(obj *Object) (string, error) {
+	for _, seen := range s.stack {
+		if seen == obj {
+			return "", fmt.Errorf("TypeError: Converting circular structure to JSON")
+		}
+	}
+	s.stack = append(s.stack, obj)
+	stepback := s.indent
+	s.indent += s.gap
+	return stepback, nil
+}
+
+func (s *jsonSerializer) leave(stepback string) {
+	s.stack = s.stack[:len(s.stack)-1]
+	s.indent = stepback
+}
+
+// wrap joins the rendered members of an object or array between open and
+// close, one per line when an indentation gap is in effect.
+func (s *jsonSerializer) wrap(open, close string, members []string, stepback string) string {
+	if len(members) == 0 {
+		return open + close
+	}
+	if s.gap == "" {
+		return open + strings.Join(members, ",") + close
+	}
+	sep := ",\n" + s.indent
+	return open + "\n" + s.indent + strings.Join(members, sep) + "\n" + stepback + close
+}
+
+func (s *jsonSerializer) serializeObject(obj *Object) (string, error) {
+	stepback, err := s.enter(obj)
+	if err != nil {
+		return "", err
+	}
+	defer s.leave(stepback)
+
+	keys := s.propertyList
+	if keys == nil {
+		if keys, err = ownKeysOf([]Value{NewObjectValue(obj)}, true); err != nil {
+			return "", err
+		}
+	}
+	colon := ":"
+	if s.gap != "" {
+		colon = ": "
+	}
+	var members []string
+	for _, key := range keys {
+		v, err := s.i.getObjectProperty(obj, NewObjectValue(obj), key)
+		if err != nil {
+			return "", err
+		}
+		out, ok, err := s.serializeProperty(obj, key, v)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			members = append(members, quoteJSONString(key)+colon+out)
+		}
+	}
+	return s.wrap("{", "}", members, stepback), nil
+}
+
+func (s *jsonSerializer) serializeArray(arr *Object) (string, error) {
+	stepback, err := s.enter(arr)
+	if err != nil {
+		return "", err
+	}
+	defer s.leave(stepback)
+
+	members := make([]string, 0, arr.Length())
+	for idx := uint32(0); idx < arr.Length(); idx++ {
+		key := indexKey(idx)
+		v, err := s.i.getObjectProperty(arr, NewObjectValue(arr), key)
+		if err != nil {
+			return "", err
+		}
+		out, ok, err := s.serializeProperty(arr, key, v)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			out = "null"
+		}
+		members = append(members, out)
+	}
+	return s.wrap("[", "]", members, stepback), nil
+}
+
+// quoteJSONString renders s as a JSON string literal.
+func quoteJSONString(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&out, `\u%04x`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// jsonRevive walks the value stored under key in holder bottom-up, replacing
+// each property with the result of calling reviver on it. Properties the
+// reviver maps to undefined are deleted.
+func (i *Interpreter) jsonRevive(holder *Object, key string, reviver Value) (Value, error) {
+	v, err := i.getObjectProperty(holder, NewObjectValue(holder), key)
+	if err != nil {
+		return Value{}, err
+	}
+	if v.Kind() == ObjectKind {
+		obj := v.Object()
+		var keys []string
+		if obj.class == "Array" {
+			for idx := uint32(0); idx < obj.Length(); idx++ {
+				keys = append(keys, indexKey(idx))
+			}
+		} else if keys, err = ownKeysOf([]Value{v}, true); err != nil {
+			return Value{}, err
+		}
+		for _, k := range keys {
+			revived, err := i.jsonRevive(obj, k, reviver)
+			if err != nil {
+				return Value{}, err
+			}
+			if revived.Kind() == UndefinedKind {
+				obj.Delete(k)
+			} else {
+				obj.DefineProperty(k, revived, true, true, true)
+			}
+		}
+	}
+	return i.callFunction(reviver, NewObjectValue(holder), []Value{NewString(key), v})
+}
+
+// jsonParser is a recursive-descent parser for the JSON text grammar.
+type jsonParser struct {
+	i   *Interpreter
+	src string
+	pos int
+}
+
+func (p *jsonParser) parse() (Value, error) {
+	v, err := p.parseValue()
+	if err != nil {
+		return Value{}, err
+	}
+	p.skipWhitespace()
+	if p.pos < len(p.src) {
+		return Value{}, p.unexpected()
+	}
+	return v, nil
+}
+
+func (p *jsonParser) skipWhitespace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// unexpected reports the character at the current position, or the end of
+// the input.
+func (p *jsonParser) unexpected() error {
+	if p.pos >= len(p.src) {
+		return fmt.Errorf("SyntaxError: Unexpected end of JSON input")
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return fmt.Errorf("SyntaxError: Unexpected token %q in JSON at position %d", r, p.pos)
+}
+
+func (p *jsonParser) parseValue() (Value, error) {
+	p.skipWhitespace()
+	if p.pos >= len(p.src) {
+		return Value{}, p.unexpected()
+	}
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return Value{}, err
+		}
+		return NewString(s), nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += len("true")
+		return NewBoolean(true), nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += len("false")
+		return NewBoolean(false), nil
+	case strings.HasPrefix(p.src[p.pos:], "null"):
+		p.pos += len("null")
+		return Null, nil
+	default:
+		return Value{}, p.unexpected()
+	}
+}
+
+// expect consumes c after any whitespace.
+func (p *jsonParser) expect(c byte) error {
+	p.skipWhitespace()
+	if p.pos >= len(p.src) || p.src[p.pos] != c {
+		return p.unexpected()
+	}
+	p.pos++
+	return nil
+}
+
+// peek reports whether the next non-whitespace character is c, consuming it
+// if so.
+func (p *jsonParser) peek(c byte) bool {
+	p.skipWhitespace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *jsonParser) parseObject() (Value, error) {
+	p.pos++ // {
+	obj := NewObject(nil)
+	if p.peek('}') {
+		return NewObjectValue(obj), nil
+	}
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			return Value{}, p.unexpected()
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return Value{}, err
+		}
+		if err := p.expect(':'); err != nil {
+			return Value{}, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return Value{}, err
+		}
+		obj.DefineProperty(key, v, true, true, true)
+		if p.peek('}') {
+			return NewObjectValue(obj), nil
+		}
+		if err := p.expect(','); err != nil {
+			return Value{}, err
+		}
+	}
+}
+
+func (p *jsonParser) parseArray() (Value, error) {
+	p.pos++ // [
+	var elems []Value
+	if p.peek(']') {
+		return NewObjectValue(p.i.newArray(elems)), nil
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return Value{}, err
+		}
+		elems = append(elems, v)
+		if p.peek(']') {
+			return NewObjectValue(p.i.newArray(elems)), nil
+		}
+		if err := p.expect(','); err != nil {
+			return Value{}, err
+		}
+	}
+}
+
+// parseString reads a string literal starting at its opening quote.
+func (p *jsonParser) parseString() (string, error) {
+	p.pos++ // "
+	var units []uint16
+	for {
+		if p.pos >= len(p.src) {
+			return "", fmt.Errorf("SyntaxError: Unterminated string in JSON at position %d", p.pos)
+		}
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		switch {
+		case r == '"':
+			p.pos++
+			return string(utf16.Decode(units)), nil
+		case r < 0x20:
+			return "", p.unexpected()
+		case r != '\\':
+			units = utf16.AppendRune(units, r)
+			p.pos += size
+			continue
+		}
+
+		p.pos++ // backslash
+		if p.pos >= len(p.src) {
+			return "", p.unexpected()
+		}
+		esc := p.src[p.pos]
+		p.pos++
+		switch esc {
+		case '"', '\\', '/':
+			units = append(units, uint16(esc))
+		case 'b':
+			units = append(units, '\b')
+		case 'f':
+			units = append(units, '\f')
+		case 'n':
+			units = append(units, '\n')
+		case 'r':
+			units = append(units, '\r')
+		case 't':
+			units = append(units, '\t')
+		case 'u':
+			if p.pos+4 > len(p.src) {
+				return "", fmt.Errorf("SyntaxError: Bad Unicode escape in JSON at position %d", p.pos-2)
+			}
+			n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("SyntaxError: Bad Unicode escape in JSON at position %d", p.pos-2)
+			}
+			units = append(units, uint16(n))
+			p.pos += 4
+		default:
+			p.pos--
+			return "", fmt.Errorf("SyntaxError: Bad escaped character in JSON at position %d", p.pos)
+		}
+	}
+}
+
+// parseNumber reads -?(0|[1-9][0-9]*)(.[0-9]+)?([eE][+-]?[0-9]+)?.
+func (p *jsonParser) parseNumber() (Value, error) {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '0' {
+		p.pos++
+	} else if digits() == 0 {
+		return Value{}, p.unexpected()
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		if digits() == 0 {
+			return Value{}, p.unexpected()
+		}
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return Value{}, p.unexpected()
+		}
+	}
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil && !math.IsInf(f, 0) {
+		return Value{}, fmt.Errorf("SyntaxError: Invalid number in JSON at position %d", start)
+	}
+	return NewNumber(f), nil
+}
@@ -0,0 +1,455 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"es6-interpreter/ast"
+	"es6-interpreter/parser"
+)
+
+// ModuleLoader returns the source text of the module named by specifier, the
+// string following from in an import or export declaration.
+type ModuleLoader func(specifier string) (string, error)
+
+// SetModuleLoader installs the function used to fetch the modules imported by
+// ExecuteModule. Each specifier is loaded and evaluated at most once per
+// interpreter.
+func (i *Interpreter) SetModuleLoader(load ModuleLoader) {
+	i.moduleLoader = load
+}
+
+type moduleStatus int
+
+const (
+	moduleUnlinked moduleStatus = iota
+	moduleLinking
+	moduleLinked
+	moduleEvaluating
+	moduleEvaluated
+)
+
+// module is the record of a parsed module: its environment, what it exports
+// and the modules it depends on.
+type module struct {
+	specifier string
+	program   *ast.Program
+	env       *Environment
+	status    moduleStatus
+
+	exports     map[string]exportEntry // by exported name
+	starExports []string               // specifiers of export * from
+	requested   []string               // specifiers in source order
+	namespace   *Object                // created on first use
+}
+
+// exportEntry describes one export. A local export names a binding of the
+// module itself; an indirect export names export name of module from, where
+// name "*" stands for that module's namespace object.
+type exportEntry struct {
+	local string
+	from  string
+	name  string
+}
+
+// defaultBinding holds the value of export default followed by an expression.
+const defaultBinding = "*default*"
+
+// ExecuteModule runs program, which must have been parsed with ParseModule,
+// as the root of a module graph. Imported modules are fetched through the
+// loader installed with SetModuleLoader. It returns the completion value of
+// the module body.
+func (i *Interpreter) ExecuteModule(program *ast.Program) (Value, error) {
+	m := i.newModule("", program)
+	if err := i.loadRequested(m); err != nil {
+		return Value{}, err
+	}
+	if err := i.linkModule(m); err != nil {
+		return Value{}, err
+	}
+	return i.evaluateModule(m)
+}
+
+// ExecuteModuleContext is like ExecuteModule but stops running the module
+// graph once ctx is done, as ExecuteContext does.
+func (i *Interpreter) ExecuteModuleContext(ctx context.Context, program *ast.Program) (Value, error) {
+	saved := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = saved }()
+	return i.ExecuteModule(program)
+}
+
+func (i *Interpreter) newModule(specifier string, program *ast.Program) *module {
+	env := NewVariableEnvironment(i.global)
+	env.strict = true
+	env.bindThis(Undefined)
+	m := &module{
+		specifier: specifier,
+		program:   program,
+		env:       env,
+		exports:   make(map[string]exportEntry),
+	}
+
+	// Exporting an imported binding re-exports it from its own module.
+	imported := make(map[string]exportEntry)
+	for _, stmt := range program.Body {
+		decl, ok := stmt.(*ast.ImportDeclaration)
+		if !ok {
+			continue
+		}
+		m.request(decl.Source.Value)
+		for _, spec := range decl.Specifiers {
+			switch s := spec.(type) {
+			case *ast.ImportSpecifier:
+				imported[s.Local.Name] = exportEntry{from: decl.Source.Value, name: s.Imported.Name}
+			case *ast.ImportDefaultSpecifier:
+				imported[s.Local.Name] = exportEntry{from: decl.Source.Value, name: "default"}
+			case *ast.ImportNamespaceSpecifier:
+				imported[s.Local.Name] = exportEntry{from: decl.Source.Value, name: "*"}
+			}
+		}
+	}
+
+	for _, stmt := range program.Body {
+		switch s := stmt.(type) {
+		case *ast.ExportNamedDeclaration:
+			if s.Declaration != nil {
+				for _, name := range declarationNames(s.Declaration) {
+					m.exports[name] = exportEntry{local: name}
+				}
+				continue
+			}
+			if s.Source != nil {
+				m.request(s.Source.Value)
+			}
+			for _, spec := range s.Specifiers {
+				switch {
+				case s.Source != nil:
+					m.exports[spec.Exported.Name] = exportEntry{from: s.Source.Value, name: spec.Local.Name}
+				case imported[spec.Local.Name].from != "":
+					m.exports[spec.Exported.Name] = imported[spec.Local.Name]
+				default:
+					m.exports[spec.Exported.Name] = exportEntry{local: spec.Local.Name}
+				}
+			}
+		case *ast.ExportDefaultDeclaration:
+			local := defaultBinding
+			if decl, ok := s.Declaration.(ast.Declaration); ok {
+				if names := declarationNames(decl); len(names) == 1 {
+					local = names[0]
+				}
+			}
+			m.exports["default"] = exportEntry{local: local}
+		case *ast.ExportAllDeclaration:
+			m.request(s.Source.Value)
+			if s.Exported != nil {
+				m.exports[s.Exported.Name] = exportEntry{from: s.Source.Value, name: "*"}
+			} else {
+				m.starExports = append(m.starExports, s.Source.Value)
+			}
+		}
+	}
+	return m
+}
+
+func (m *module) request(specifier string) {
+	for _, r := range m.requested {
+		if r == specifier {
+			return
+		}
+	}
+	m.requested = append(m.requested, specifier)
+}
+
+// declarationNames lists the names bound by an exported declaration.
+func declarationNames(decl ast.Declaration) []string {
+	switch d := decl.(type) {
+	case *ast.VariableDeclaration:
+		var names []string
+		for _, declarator := range d.Declarations {
+			names = append(names, patternNames(declarator.ID)...)
+		}
+		return names
+	case *ast.FunctionDeclaration:
+		return []string{d.ID.Name}
+	case *ast.ClassDeclaration:
+		return []string{d.ID.Name}
+	}
+	return nil
+}
+
+// loadModule returns the module named by specifier, fetching and parsing it
+// along with its dependencies the first time it is requested.
+func (i *Interpreter) loadModule(specifier string) (*module, error) {
+	if m, ok := i.modules[specifier]; ok {
+		return m, nil
+	}
+	if i.moduleLoader == nil {
+		return nil, fmt.Errorf("Error: Cannot find module '%s'", specifier)
+	}
+	src, err := i.moduleLoader(specifier)
+	if err != nil {
+		return nil, err
+	}
+	program, err := parser.New(src).ParseModule()
+	if err != nil {
+		return nil, err
+	}
+	m := i.newModule(specifier, program)
+	// Record the module before loading its dependencies so that cycles
+	// find it.
+	if i.modules == nil {
+		i.modules = make(map[string]*module)
+	}
+	i.modules[specifier] = m
+	if err := i.loadRequested(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (i *Interpreter) loadRequested(m *module) error {
+	for _, specifier := range m.requested {
+		if _, err := i.loadModule(specifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkModule creates the import bindings and hoisted declarations of m and
+// of every module it depends on, before any of them runs.
+func (i *Interpreter) linkModule(m *module) error {
+	if m.status != moduleUnlinked {
+		return nil
+	}
+	m.status = moduleLinking
+	for _, specifier := range m.requested {
+		if err := i.linkModule(i.modules[specifier]); err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range m.program.Body {
+		decl, ok := stmt.(*ast.ImportDeclaration)
+		if !ok {
+			continue
+		}
+		from := i.modules[decl.Source.Value]
+		for _, spec := range decl.Specifiers {
+			var local, name string
+			switch s := spec.(type) {
+			case *ast.ImportSpecifier:
+				local, name = s.Local.Name, s.Imported.Name
+			case *ast.ImportDefaultSpecifier:
+				local, name = s.Local.Name, "default"
+			case *ast.ImportNamespaceSpecifier:
+				local, name = s.Local.Name, "*"
+			}
+			if err := i.bindImport(m, local, from, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	body := moduleStatements(m.program)
+	i.hoistVarDeclarations(m.env, body)
+	if err := i.instantiateFunctionDeclarations(m.env, body); err != nil {
+		return err
+	}
+	m.status = moduleLinked
+	return nil
+}
+
+// bindImport declares local in m as the export name of from.
+func (i *Interpreter) bindImport(m *module, local string, from *module, name string) error {
+	if name == "*" {
+		if err := m.env.Declare(local, BindingConst); err != nil {
+			return err
+		}
+		return m.env.Initialize(local, NewObjectValue(i.moduleNamespace(from)))
+	}
+	target, binding, ok := i.resolveExport(from, name, nil)
+	if !ok {
+		return fmt.Errorf("SyntaxError: The requested module '%s' does not provide an export named '%s'", from.specifier, name)
+	}
+	if binding == "*" {
+		if err := m.env.Declare(local, BindingConst); err != nil {
+			return err
+		}
+		return m.env.Initialize(local, NewObjectValue(i.moduleNamespace(target)))
+	}
+	return m.env.declareImport(local, target.env, binding)
+}
+
+// resolveExport finds the module and binding that provide export name of m.
+// A binding of "*" stands for that module's namespace object. Names exported
+// by more than one export * are ambiguous and do not resolve.
+func (i *Interpreter) resolveExport(m *module, name string, visited map[*module]map[string]bool) (*module, string, bool) {
+	if visited == nil {
+		visited = make(map[*module]map[string]bool)
+	}
+	if visited[m][name] {
+		// A circular re-export.
+		return nil, "", false
+	}
+	if visited[m] == nil {
+		visited[m] = make(map[string]bool)
+	}
+	visited[m][name] = true
+
+	if entry, ok := m.exports[name]; ok {
+		if entry.from == "" {
+			return m, entry.local, true
+		}
+		from := i.modules[entry.from]
+		if entry.name == "*" {
+			return from, "*", true
+		}
+		return i.resolveExport(from, entry.name, visited)
+	}
+	if name == "default" {
+		return nil, "", false
+	}
+
+	var found *module
+	var foundBinding string
+	for _, specifier := range m.starExports {
+		target, binding, ok := i.resolveExport(i.modules[specifier], name, visited)
+		if !ok {
+			continue
+		}
+		if found != nil && (found != target || foundBinding != binding) {
+			return nil, "", false
+		}
+		found, foundBinding = target, binding
+	}
+	return found, foundBinding, found != nil
+}
+
+// exportedNames lists every name m exports, including those gathered by
+// export * declarations.
+func (i *Interpreter) exportedNames(m *module, visited map[*module]bool) []string {
+	if visited[m] {
+		return nil
+	}
+	visited[m] = true
+	var names []string
+	for name := range m.exports {
+		names = append(names, name)
+	}
+	for _, specifier := range m.starExports {
+		for _, name := range i.exportedNames(i.modules[specifier], visited) {
+			if name != "default" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// moduleNamespace returns the namespace object of m, which exposes its
+// exports as read-only properties that reflect the current value of each
+// exported binding.
+func (i *Interpreter) moduleNamespace(m *module) *Object {
+	if m.namespace != nil {
+		return m.namespace
+	}
+	ns := NewObject(nil)
+	ns.class = "Module"
+	m.namespace = ns
+
+	names := i.exportedNames(m, make(map[*module]bool))
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := ns.properties[name]; ok {
+			continue
+		}
+		target, binding, ok := i.resolveExport(m, name, nil)
+		if !ok {
+			continue
+		}
+		getter := i.newNativeFunction(name, func(Value, []Value) (Value, error) {
+			if binding == "*" {
+				return NewObjectValue(i.moduleNamespace(target)), nil
+			}
+			b, ok := target.env.own(binding)
+			if !ok || !b.initialized {
+				return Value{}, fmt.Errorf("ReferenceError: Cannot access '%s' before initialization", name)
+			}
+			return b.value, nil
+		})
+		ns.DefineAccessor(name, getter.Object(), nil, true, false)
+	}
+	ns.extensible = false
+	return ns
+}
+
+// evaluateModule runs the bodies of the modules m depends on and then the
+// body of m, each at most once.
+func (i *Interpreter) evaluateModule(m *module) (Value, error) {
+	if m.status != moduleLinked {
+		return Undefined, nil
+	}
+	m.status = moduleEvaluating
+	for _, specifier := range m.requested {
+		if _, err := i.evaluateModule(i.modules[specifier]); err != nil {
+			return Value{}, err
+		}
+	}
+
+	var last Value = Undefined
+	for _, stmt := range moduleStatements(m.program) {
+		if def, ok := stmt.(*ast.ExportDefaultDeclaration); ok {
+			val, err := i.evalExpression(m.env, def.Declaration.(ast.Expression))
+			if err != nil {
+				return Value{}, err
+			}
+			if err := m.env.Declare(defaultBinding, BindingConst); err != nil {
+				return Value{}, err
+			}
+			if err := m.env.Initialize(defaultBinding, val); err != nil {
+				return Value{}, err
+			}
+			continue
+		}
+		comp, err := i.evalStatement(m.env, stmt)
+		if err != nil {
+			return Value{}, err
+		}
+		switch comp.kind {
+		case completionNormal:
+			last = comp.value
+		default:
+			return Value{}, fmt.Errorf("runtime error: unexpected %s in module body", i.describeCompletion(comp))
+		}
+	}
+	m.status = moduleEvaluated
+	return last, nil
+}
+
+// moduleStatements returns the body of a module with import declarations
+// removed and exported declarations unwrapped, so it can be hoisted and run
+// like a script. export default of an expression is kept as is.
+func moduleStatements(program *ast.Program) []ast.Statement {
+	var stmts []ast.Statement
+	for _, stmt := range program.Body {
+		switch s := stmt.(type) {
+		case *ast.ImportDeclaration, *ast.ExportAllDeclaration:
+		case *ast.ExportNamedDeclaration:
+			if s.Declaration != nil {
+				stmts = append(stmts, s.Declaration)
+			}
+		case *ast.ExportDefaultDeclaration:
+			if decl, ok := s.Declaration.(ast.Declaration); ok {
+				stmts = append(stmts, decl)
+			} else {
+				stmts = append(stmts, s)
+			}
+		default:
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
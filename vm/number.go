@@ -0,0 +1,491 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// installNumberFunctions defines the global parseInt, parseFloat, isNaN and
+// isFinite functions.
+func (i *Interpreter) installNumberFunctions() {
+	i.defineGlobal("parseInt", i.newNativeFunction("parseInt", func(_ Value, args []Value) (Value, error) {
+		return NewNumber(parseIntPrefix(stringArg(args, 0), int32(toUint32(argAt(args, 1))))), nil
+	}))
+	i.defineGlobal("parseFloat", i.newNativeFunction("parseFloat", func(_ Value, args []Value) (Value, error) {
+		return NewNumber(parseFloatPrefix(stringArg(args, 0))), nil
+	}))
+	i.defineGlobal("isNaN", i.newNativeFunction("isNaN", func(_ Value, args []Value) (Value, error) {
+		return NewBoolean(math.IsNaN(numberArg(args, 0))), nil
+	}))
+	i.defineGlobal("isFinite", i.newNativeFunction("isFinite", func(_ Value, args []Value) (Value, error) {
+		n := numberArg(args, 0)
+		return NewBoolean(!math.IsNaN(n) && !math.IsInf(n, 0)), nil
+	}))
+}
+
+// newNumberPrototype builds the prototype that number primitives read their
+// methods from.
+func (i *Interpreter) newNumberPrototype() *Object {
+	proto := NewObject(nil)
+	i.defineMethod(proto, "toString", func(this Value, args []Value) (Value, error) {
+		x, err := thisNumber(this, "toString")
+		if err != nil {
+			return Value{}, err
+		}
+		radix := 10.0
+		if r := argAt(args, 0); r.Kind() != UndefinedKind {
+			radix = toIntegerOrInfinity(r)
+		}
+		if radix < 2 || radix > 36 {
+			return Value{}, fmt.Errorf("RangeError: toString() radix must be between 2 and 36")
+		}
+		if radix == 10 {
+			return NewString(numberToString(x)), nil
+		}
+		return NewString(numberToRadixString(x, int(radix))), nil
+	})
+	i.defineMethod(proto, "toFixed", func(this Value, args []Value) (Value, error) {
+		x, err := thisNumber(this, "toFixed")
+		if err != nil {
+			return Value{}, err
+		}
+		digits := toIntegerOrInfinity(argAt(args, 0))
+		if digits < 0 || digits > 100 {
+			return Value{}, fmt.Errorf("RangeError: toFixed() digits argument must be between 0 and 100")
+		}
+		if math.IsNaN(x) || math.Abs(x) >= 1e21 {
+			return NewString(numberToString(x)), nil
+		}
+		return NewString(formatFixed(x, int(digits))), nil
+	})
+	i.defineMethod(proto, "toPrecision", func(this Value, args []Value) (Value, error) {
+		x, err := thisNumber(this, "toPrecision")
+		if err != nil {
+			return Value{}, err
+		}
+		if argAt(args, 0).Kind() == UndefinedKind {
+			return NewString(numberToString(x)), nil
+		}
+		precision := toIntegerOrInfinity(argAt(args, 0))
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return NewString(numberToString(x)), nil
+		}
+		if precision < 1 || precision > 100 {
+			return Value{}, fmt.Errorf("RangeError: toPrecision() argument must be between 1 and 100")
+		}
+		return NewString(formatPrecision(x, int(precision))), nil
+	})
+	i.defineMethod(proto, "valueOf", func(this Value, _ []Value) (Value, error) {
+		x, err := thisNumber(this, "valueOf")
+		if err != nil {
+			return Value{}, err
+		}
+		return NewNumber(x), nil
+	})
+	return proto
+}
+
+func thisNumber(this Value, method string) (float64, error) {
+	if this.Kind() != NumberKind {
+		return 0, fmt.Errorf("TypeError: Number.prototype.%s requires that 'this' be a Number", method)
+	}
+	return this.Number(), nil
+}
+
+// parseIntPrefix parses the longest run of digits in radix at the start of s,
+// after leading white space and an optional sign. A radix of 0 means 10, or
+// 16 when s starts with 0x or 0X. It returns NaN when there are no digits.
+func parseIntPrefix(s string, radix int32) float64 {
+	s = strings.TrimLeftFunc(s, isStringWhiteSpace)
+	sign := 1.0
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	stripPrefix := true
+	if radix != 0 {
+		if radix < 2 || radix > 36 {
+			return math.NaN()
+		}
+		stripPrefix = radix == 16
+	} else {
+		radix = 10
+	}
+	if stripPrefix && len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+		radix = 16
+	}
+
+	end := 0
+	for end < len(s) && digitValue(s[end]) < int(radix) {
+		end++
+	}
+	if end == 0 {
+		return math.NaN()
+	}
+	if radix == 10 {
+		// Decimal digits convert exactly rounded, however many there are.
+		n, _ := strconv.ParseFloat(s[:end], 64)
+		return sign * n
+	}
+	n := 0.0
+	for _, c := range []byte(s[:end]) {
+		n = n*float64(radix) + float64(digitValue(c))
+	}
+	return sign * n
+}
+
+// digitValue returns the value of c as a digit in radix 36, or 36 when c is
+// not a digit at all.
+func digitValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
+
+// parseFloatPrefix parses the longest prefix of s, after leading white space,
+// that is a decimal literal or Infinity. It returns NaN when there is none.
+func parseFloatPrefix(s string) float64 {
+	s = strings.TrimLeftFunc(s, isStringWhiteSpace)
+	pos := 0
+	if pos < len(s) && (s[pos] == '+' || s[pos] == '-') {
+		pos++
+	}
+	if strings.HasPrefix(s[pos:], "Infinity") {
+		if s[0] == '-' {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	digits := func() int {
+		start := pos
+		for pos < len(s) && s[pos] >= '0' && s[pos] <= '9' {
+			pos++
+		}
+		return pos - start
+	}
+
+	mantissa := digits()
+	if pos < len(s) && s[pos] == '.' {
+		pos++
+		if fraction := digits(); mantissa == 0 && fraction == 0 {
+			return math.NaN()
+		}
+	} else if mantissa == 0 {
+		return math.NaN()
+	}
+	end := pos
+	if pos < len(s) && (s[pos] == 'e' || s[pos] == 'E') {
+		pos++
+		if pos < len(s) && (s[pos] == '+' || s[pos] == '-') {
+			pos++
+		}
+		if digits() > 0 {
+			end = pos
+		}
+	}
+	// ParseFloat reports overflow as an error alongside ±Inf, which is the
+	// value wanted here.
+	n, _ := strconv.ParseFloat(s[:end], 64)
+	return n
+}
+
+// stringToNumber converts s by the StringToNumber rules: surrounding white
+// space is ignored, an empty string is 0, and otherwise the whole string must
+// be a decimal literal, Infinity with an optional sign, or an unsigned 0x, 0o
+// or 0b integer. Anything else is NaN.
+func stringToNumber(s string) float64 {
+	s = strings.TrimFunc(s, isStringWhiteSpace)
+	if s == "" {
+		return 0
+	}
+	if len(s) > 2 && s[0] == '0' {
+		radix := 0
+		switch s[1] {
+		case 'x', 'X':
+			radix = 16
+		case 'o', 'O':
+			radix = 8
+		case 'b', 'B':
+			radix = 2
+		}
+		if radix != 0 {
+			n := 0.0
+			for _, c := range []byte(s[2:]) {
+				d := digitValue(c)
+				if d >= radix {
+					return math.NaN()
+				}
+				n = n*float64(radix) + float64(d)
+			}
+			return n
+		}
+	}
+	switch s {
+	case "Infinity", "+Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+	if !isDecimalLiteral(s) {
+		return math.NaN()
+	}
+	// As in parseFloatPrefix, an overflow error comes with the wanted ±Inf.
+	n, _ := strconv.ParseFloat(s, 64)
+	return n
+}
+
+// isDecimalLiteral reports whether s is entirely a StrDecimalLiteral other
+// than Infinity: an optional sign, digits with an optional fraction, and an
+// optional exponent.
+func isDecimalLiteral(s string) bool {
+	pos := 0
+	if s[pos] == '+' || s[pos] == '-' {
+		pos++
+	}
+	digits := func() int {
+		start := pos
+		for pos < len(s) && s[pos] >= '0' && s[pos] <= '9' {
+			pos++
+		}
+		return pos - start
+	}
+	mantissa := digits()
+	if pos < len(s) && s[pos] == '.' {
+		pos++
+		mantissa += digits()
+	}
+	if mantissa == 0 {
+		return false
+	}
+	if pos < len(s) && (s[pos] == 'e' || s[pos] == 'E') {
+		pos++
+		if pos < len(s) && (s[pos] == '+' || s[pos] == '-') {
+			pos++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return pos == len(s)
+}
+
+// numberToString formats x as Number::toString does: the shortest digits
+// that round-trip, in plain notation for exponents from -7 to 20 and in
+// exponential notation such as 1e+21 or 1.5e-7 outside that range. Negative
+// zero is "0".
+func numberToString(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "Infinity"
+	case math.IsInf(x, -1):
+		return "-Infinity"
+	case x == 0:
+		return "0"
+	case x < 0:
+		return "-" + numberToString(-x)
+	}
+
+	// FormatFloat gives the shortest digits as d.ddde±xx; x is then
+	// 0.digits × 10^n.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(x, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	n, k := e+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return "0." + strings.Repeat("0", -n) + digits
+	}
+	sign := "+"
+	if e < 0 {
+		sign = "-"
+		e = -e
+	}
+	if k == 1 {
+		return digits + "e" + sign + strconv.Itoa(e)
+	}
+	return digits[:1] + "." + digits[1:] + "e" + sign + strconv.Itoa(e)
+}
+
+// numberToRadixString formats x in radix, which is not 10. The integer part
+// is exact; the fraction has just enough digits to tell x apart from its
+// neighbouring doubles, as V8 produces.
+func numberToRadixString(x float64, radix int) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "Infinity"
+	case math.IsInf(x, -1):
+		return "-Infinity"
+	case x < 0:
+		return "-" + numberToRadixString(-x, radix)
+	}
+
+	const chars = "0123456789abcdefghijklmnopqrstuvwxyz"
+	integer := math.Floor(x)
+	fraction := x - integer
+	// delta is half the distance to the next double, the precision that
+	// the digits need to reach.
+	delta := math.Max(0.5*(math.Nextafter(x, math.Inf(1))-x), math.Nextafter(0, 1))
+	var frac []byte
+	if fraction >= delta {
+		for {
+			fraction *= float64(radix)
+			delta *= float64(radix)
+			digit := int(fraction)
+			frac = append(frac, chars[digit])
+			fraction -= float64(digit)
+			if fraction > 0.5 || fraction == 0.5 && digit&1 == 1 {
+				if fraction+delta > 1 {
+					// Round up, carrying into the integer part when every
+					// digit was the largest one.
+					for {
+						if len(frac) == 0 {
+							integer++
+							break
+						}
+						last := digitValue(frac[len(frac)-1])
+						frac = frac[:len(frac)-1]
+						if last+1 < radix {
+							frac = append(frac, chars[last+1])
+							break
+						}
+					}
+					break
+				}
+			}
+			if fraction < delta {
+				break
+			}
+		}
+	}
+
+	n, _ := new(big.Float).SetFloat64(integer).Int(nil)
+	s := n.Text(radix)
+	if len(frac) > 0 {
+		s += "." + string(frac)
+	}
+	return s
+}
+
+// exactDecimal returns the digits of the exact decimal value of x, which is
+// positive and finite, with the decimal point after the first point digits.
+// Doubles have at most 1074 fractional decimal digits.
+func exactDecimal(x float64) (digits string, point int) {
+	whole, frac, _ := strings.Cut(strconv.FormatFloat(x, 'f', 1074, 64), ".")
+	return whole + frac, len(whole)
+}
+
+// roundDigits rounds the decimal digits to their first n, rounding halves
+// up as toFixed and toPrecision do. It reports whether rounding carried into
+// a new leading digit, in which case the result holds n+1 digits.
+func roundDigits(digits string, n int) (string, bool) {
+	if n >= len(digits) {
+		return digits + strings.Repeat("0", n-len(digits)), false
+	}
+	kept := []byte(digits[:n])
+	if digits[n] < '5' {
+		return string(kept), false
+	}
+	for idx := len(kept) - 1; idx >= 0; idx-- {
+		if kept[idx] < '9' {
+			kept[idx]++
+			return string(kept), false
+		}
+		kept[idx] = '0'
+	}
+	return "1" + string(kept), true
+}
+
+// formatFixed formats x, whose magnitude is below 1e21, with digits
+// fractional digits, as Number.prototype.toFixed.
+func formatFixed(x float64, digits int) string {
+	sign := ""
+	if x < 0 {
+		sign = "-"
+		x = -x
+	}
+	s := strings.Repeat("0", digits+1)
+	if x != 0 {
+		all, point := exactDecimal(x)
+		var carried bool
+		s, carried = roundDigits(all, point+digits)
+		if carried {
+			point++
+		}
+		s = strings.TrimLeft(s[:point], "0") + s[point:]
+		if len(s) == digits {
+			s = "0" + s
+		}
+	}
+	if digits == 0 {
+		return sign + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// formatPrecision formats the finite x with precision significant digits, as
+// Number.prototype.toPrecision.
+func formatPrecision(x float64, precision int) string {
+	sign := ""
+	if x < 0 {
+		sign = "-"
+		x = -x
+	}
+	if x == 0 {
+		if precision == 1 {
+			return sign + "0"
+		}
+		return sign + "0." + strings.Repeat("0", precision-1)
+	}
+
+	all, point := exactDecimal(x)
+	lead := strings.IndexFunc(all, func(r rune) bool { return r != '0' })
+	// x is digits × 10^(e-precision+1) with e the exponent of the first
+	// significant digit.
+	e := point - lead - 1
+	digits, carried := roundDigits(all[lead:], precision)
+	if carried {
+		digits = digits[:precision]
+		e++
+	}
+
+	switch {
+	case e < -6 || e >= precision:
+		s := digits[:1]
+		if precision > 1 {
+			s += "." + digits[1:]
+		}
+		expSign := "+"
+		if e < 0 {
+			expSign = "-"
+			e = -e
+		}
+		return sign + s + "e" + expSign + strconv.Itoa(e)
+	case e == precision-1:
+		return sign + digits
+	case e >= 0:
+		return sign + digits[:e+1] + "." + digits[e+1:]
+	default:
+		return sign + "0." + strings.Repeat("0", -(e+1)) + digits
+	}
+}
@@ -0,0 +1,266 @@
+package vm
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Object is the runtime representation of every non-primitive value.
+type Object struct {
+	class      string
+	prototype  *Object
+	properties map[string]*property
+	keys       []string // insertion order of own property keys
+	extensible bool
+
+	function  *function  // set for script-defined callables
+	regexp    *regExp    // set for RegExp objects
+	generator *generator // set for generator objects
+}
+
+type property struct {
+	value        Value
+	writable     bool
+	enumerable   bool
+	configurable bool
+
+	// Accessor properties have no value; getter and setter are the
+	// functions invoked on read and write, either of which may be nil.
+	accessor bool
+	getter   *Object
+	setter   *Object
+}
+
+// NewObject allocates an ordinary object with the supplied prototype.
+func NewObject(prototype *Object) *Object {
+	return &Object{
+		class:      "Object",
+		prototype:  prototype,
+		properties: make(map[string]*property),
+		extensible: true,
+	}
+}
+
+// NewArray allocates an array object holding elements at consecutive indices.
+func NewArray(elements []Value) *Object {
+	arr := NewObject(nil)
+	arr.class = "Array"
+	arr.DefineProperty("length", NewNumber(0), true, false, false)
+	for idx, elem := range elements {
+		arr.Set(strconv.Itoa(idx), elem)
+	}
+	return arr
+}
+
+// Class reports the internal classification of the object (e.g. "Object", "Function").
+func (o *Object) Class() string { return o.class }
+
+// Prototype returns the object's prototype, or nil at the end of the chain.
+func (o *Object) Prototype() *Object { return o.prototype }
+
+// SetPrototype replaces the object's prototype. It reports false, leaving the
+// prototype unchanged, when the object is not extensible or proto would make
+// the chain circular.
+func (o *Object) SetPrototype(proto *Object) bool {
+	if proto == o.prototype {
+		return true
+	}
+	if !o.extensible {
+		return false
+	}
+	for cur := proto; cur != nil; cur = cur.prototype {
+		if cur == o {
+			return false
+		}
+	}
+	o.prototype = proto
+	return true
+}
+
+// Freeze makes the object non-extensible and all of its own properties
+// non-configurable, and its data properties read-only as well.
+func (o *Object) Freeze() {
+	o.extensible = false
+	for _, prop := range o.properties {
+		prop.configurable = false
+		if !prop.accessor {
+			prop.writable = false
+		}
+	}
+}
+
+// IsCallable reports whether the object can be invoked.
+func (o *Object) IsCallable() bool { return o.function != nil }
+
+// GetOwn returns the own property stored under key.
+func (o *Object) GetOwn(key string) (Value, bool) {
+	prop, ok := o.properties[key]
+	if !ok {
+		return Undefined, false
+	}
+	return prop.value, true
+}
+
+// Get looks key up on the object and then along its prototype chain.
+// Accessor properties read as undefined here; calling their getter requires
+// the interpreter.
+func (o *Object) Get(key string) Value {
+	if prop, ok := o.lookup(key); ok {
+		return prop.value
+	}
+	return Undefined
+}
+
+// lookup finds the property stored under key on the object or the nearest
+// prototype that has it.
+func (o *Object) lookup(key string) (*property, bool) {
+	for cur := o; cur != nil; cur = cur.prototype {
+		if prop, ok := cur.properties[key]; ok {
+			return prop, true
+		}
+	}
+	return nil, false
+}
+
+// Set assigns key on the object itself, creating a writable, enumerable and
+// configurable data property when none exists. It reports whether the
+// assignment took effect.
+func (o *Object) Set(key string, v Value) bool {
+	if prop, ok := o.properties[key]; ok {
+		if prop.accessor || !prop.writable {
+			return false
+		}
+		prop.value = v
+		return true
+	}
+	if !o.extensible {
+		return false
+	}
+	o.DefineProperty(key, v, true, true, true)
+	return true
+}
+
+// DefineProperty creates or replaces an own data property with explicit attributes.
+func (o *Object) DefineProperty(key string, v Value, writable, enumerable, configurable bool) {
+	if _, ok := o.properties[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.properties[key] = &property{value: v, writable: writable, enumerable: enumerable, configurable: configurable}
+	o.growLength(key)
+}
+
+// DefineAccessor installs getter and/or setter for key. Defining one half of
+// an existing accessor keeps the other, as happens when an object literal
+// declares both get and set for the same name; a nil argument leaves that
+// half unchanged.
+func (o *Object) DefineAccessor(key string, getter, setter *Object, enumerable, configurable bool) {
+	prop, ok := o.properties[key]
+	if !ok {
+		o.keys = append(o.keys, key)
+	}
+	if !ok || !prop.accessor {
+		prop = &property{accessor: true}
+		o.properties[key] = prop
+	}
+	if getter != nil {
+		prop.getter = getter
+	}
+	if setter != nil {
+		prop.setter = setter
+	}
+	prop.enumerable = enumerable
+	prop.configurable = configurable
+}
+
+// Delete removes an own property. It reports false when the property exists
+// but is not configurable, and true otherwise.
+func (o *Object) Delete(key string) bool {
+	prop, ok := o.properties[key]
+	if !ok {
+		return true
+	}
+	if !prop.configurable {
+		return false
+	}
+	delete(o.properties, key)
+	for idx, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:idx], o.keys[idx+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Length returns the length of an array object.
+func (o *Object) Length() uint32 {
+	prop, ok := o.properties["length"]
+	if !ok || prop.value.kind != NumberKind {
+		return 0
+	}
+	return uint32(prop.value.num)
+}
+
+// growLength keeps an array's length one past its highest index.
+func (o *Object) growLength(key string) {
+	if o.class != "Array" {
+		return
+	}
+	idx, ok := arrayIndex(key)
+	if !ok || idx < o.Length() {
+		return
+	}
+	o.setLength(idx + 1)
+}
+
+func (o *Object) setLength(n uint32) {
+	o.properties["length"].value = NewNumber(float64(n))
+}
+
+// HasOwn reports whether key is an own property of the object.
+func (o *Object) HasOwn(key string) bool {
+	_, ok := o.properties[key]
+	return ok
+}
+
+// Has reports whether key is present on the object or its prototype chain.
+func (o *Object) Has(key string) bool {
+	for cur := o; cur != nil; cur = cur.prototype {
+		if _, ok := cur.properties[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnKeys returns the own property keys in ECMAScript order: array indices
+// ascending, followed by the remaining keys in insertion order.
+func (o *Object) OwnKeys() []string {
+	var indices []string
+	var names []string
+	for _, key := range o.keys {
+		if _, ok := arrayIndex(key); ok {
+			indices = append(indices, key)
+		} else {
+			names = append(names, key)
+		}
+	}
+	sort.Slice(indices, func(a, b int) bool {
+		x, _ := arrayIndex(indices[a])
+		y, _ := arrayIndex(indices[b])
+		return x < y
+	})
+	return append(indices, names...)
+}
+
+// arrayIndex reports whether key is the canonical form of an array index.
+func arrayIndex(key string) (uint32, bool) {
+	if key == "" || (len(key) > 1 && key[0] == '0') {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(key, 10, 32)
+	if err != nil || n == 1<<32-1 {
+		return 0, false
+	}
+	return uint32(n), true
+}
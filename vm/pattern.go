@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"fmt"
+
+	"es6-interpreter/ast"
+)
+
+// bindPattern matches pattern against v and binds every identifier it names
+// in env. The bindings must already be declared: var bindings are assigned,
+// while let and const bindings are initialized. Default values and computed
+// keys are evaluated in env, left to right.
+func (i *Interpreter) bindPattern(env *Environment, pattern ast.Pattern, v Value, kind BindingKind) error {
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		if kind == BindingVar {
+			return env.Set(p.Name, v)
+		}
+		return env.Initialize(p.Name, v)
+	case *ast.AssignmentPattern:
+		if v.Kind() == UndefinedKind {
+			name := ""
+			if ident, ok := p.Left.(*ast.Identifier); ok {
+				name = ident.Name
+			}
+			def, err := i.evalNamedExpression(env, p.Right, name)
+			if err != nil {
+				return err
+			}
+			v = def
+		}
+		return i.bindPattern(env, p.Left, v, kind)
+	case *ast.ArrayPattern:
+		return i.bindArrayPattern(env, p, v, kind)
+	case *ast.ObjectPattern:
+		return i.bindObjectPattern(env, p, v, kind)
+	default:
+		return fmt.Errorf("runtime error: binding pattern %T not supported", pattern)
+	}
+}
+
+func (i *Interpreter) bindArrayPattern(env *Environment, p *ast.ArrayPattern, v Value, kind BindingKind) error {
+	values, err := i.iterableValues(v)
+	if err != nil {
+		return err
+	}
+	for idx, elem := range p.Elements {
+		if elem == nil {
+			continue
+		}
+		if err := i.bindPattern(env, elem, argAt(values, idx), kind); err != nil {
+			return err
+		}
+	}
+	if p.Rest != nil {
+		var rest []Value
+		if len(p.Elements) < len(values) {
+			rest = values[len(p.Elements):]
+		}
+		return i.bindPattern(env, p.Rest.Argument, NewObjectValue(i.newArray(rest)), kind)
+	}
+	return nil
+}
+
+func (i *Interpreter) bindObjectPattern(env *Environment, p *ast.ObjectPattern, v Value, kind BindingKind) error {
+	if isNullish(v) {
+		return fmt.Errorf("TypeError: Cannot destructure '%s' as it is %s", v.Inspect(), v.Inspect())
+	}
+	used := make(map[string]bool, len(p.Properties))
+	for _, prop := range p.Properties {
+		key, err := i.evalPropertyName(env, prop.Key, prop.Computed)
+		if err != nil {
+			return err
+		}
+		used[key] = true
+		val, err := i.getProperty(v, key)
+		if err != nil {
+			return err
+		}
+		if err := i.bindPattern(env, prop.Value, val, kind); err != nil {
+			return err
+		}
+	}
+	if p.Rest != nil {
+		rest := NewObject(nil)
+		if err := i.copyDataProperties(rest, v, used); err != nil {
+			return err
+		}
+		return i.bindPattern(env, p.Rest.Argument, NewObjectValue(rest), kind)
+	}
+	return nil
+}
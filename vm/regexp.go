@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regExp holds the compiled form of a RegExp object. Patterns are executed by
+// Go's RE2 engine, so features it lacks (backreferences, lookaround) are
+// reported as syntax errors when the object is created.
+type regExp struct {
+	source string
+	flags  string
+	re     *regexp.Regexp
+}
+
+func (r *regExp) global() bool { return strings.ContainsRune(r.flags, 'g') }
+
+// newRegExp compiles pattern with the given flags and wraps it in a RegExp
+// object.
+func (i *Interpreter) newRegExp(pattern, flags string) (Value, error) {
+	var prefix strings.Builder
+	for idx, f := range flags {
+		if strings.ContainsRune(flags[idx+1:], f) {
+			return Value{}, fmt.Errorf("SyntaxError: Invalid regular expression flags '%s'", flags)
+		}
+		switch f {
+		case 'g', 'u':
+		case 'i', 'm', 's':
+			prefix.WriteRune(f)
+		default:
+			return Value{}, fmt.Errorf("SyntaxError: Invalid regular expression flags '%s'", flags)
+		}
+	}
+
+	expr := pattern
+	if prefix.Len() > 0 {
+		expr = "(?" + prefix.String() + ")" + pattern
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Value{}, fmt.Errorf("SyntaxError: Invalid regular expression: /%s/: %v", pattern, err)
+	}
+
+	obj := NewObject(i.regexpPrototype)
+	obj.class = "RegExp"
+	obj.regexp = &regExp{source: pattern, flags: flags, re: re}
+	obj.DefineProperty("lastIndex", NewNumber(0), true, false, false)
+	obj.DefineProperty("source", NewString(pattern), false, false, true)
+	obj.DefineProperty("flags", NewString(flags), false, false, true)
+	obj.DefineProperty("global", NewBoolean(obj.regexp.global()), false, false, true)
+	return NewObjectValue(obj), nil
+}
+
+func (i *Interpreter) newRegExpPrototype() *Object {
+	proto := NewObject(nil)
+	i.defineMethod(proto, "test", func(this Value, args []Value) (Value, error) {
+		r, err := thisRegExp(this, "test")
+		if err != nil {
+			return Value{}, err
+		}
+		return NewBoolean(r.re.MatchString(stringArg(args, 0))), nil
+	})
+	i.defineMethod(proto, "toString", func(this Value, _ []Value) (Value, error) {
+		if _, err := thisRegExp(this, "toString"); err != nil {
+			return Value{}, err
+		}
+		return ToString(this), nil
+	})
+	return proto
+}
+
+func thisRegExp(this Value, method string) (*regExp, error) {
+	if this.Kind() != ObjectKind || this.Object().regexp == nil {
+		return nil, fmt.Errorf("TypeError: RegExp.prototype.%s called on incompatible receiver %s", method, this.Inspect())
+	}
+	return this.Object().regexp, nil
+}
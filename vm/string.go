@@ -0,0 +1,515 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// getStringProperty reads key from a string primitive: its length, the code
+// unit at an index, or a method inherited from String.prototype.
+func (i *Interpreter) getStringProperty(s string, key string) Value {
+	units := utf16.Encode([]rune(s))
+	if key == "length" {
+		return NewNumber(float64(len(units)))
+	}
+	if idx, ok := arrayIndex(key); ok {
+		if int(idx) < len(units) {
+			return NewString(string(utf16.Decode(units[idx : idx+1])))
+		}
+		return Undefined
+	}
+	return i.stringPrototype.Get(key)
+}
+
+func (i *Interpreter) newStringPrototype() *Object {
+	proto := NewObject(nil)
+	i.defineMethod(proto, "replace", func(this Value, args []Value) (Value, error) {
+		return i.stringReplace(this, args, false)
+	})
+	i.defineMethod(proto, "replaceAll", func(this Value, args []Value) (Value, error) {
+		return i.stringReplace(this, args, true)
+	})
+	i.defineMethod(proto, "match", func(this Value, args []Value) (Value, error) {
+		s, r, err := i.regExpMethodArgs(this, args, "match", "")
+		if err != nil {
+			return Value{}, err
+		}
+		if !r.global() {
+			m := r.re.FindStringSubmatchIndex(s)
+			if m == nil {
+				return Null, nil
+			}
+			return NewObjectValue(i.matchArray(s, m)), nil
+		}
+		found := r.re.FindAllString(s, -1)
+		if found == nil {
+			return Null, nil
+		}
+		elems := make([]Value, len(found))
+		for idx, match := range found {
+			elems[idx] = NewString(match)
+		}
+		return NewObjectValue(i.newArray(elems)), nil
+	})
+	i.defineMethod(proto, "matchAll", func(this Value, args []Value) (Value, error) {
+		if pattern := argAt(args, 0); pattern.Kind() == ObjectKind && pattern.Object().regexp != nil && !pattern.Object().regexp.global() {
+			return Value{}, fmt.Errorf("TypeError: matchAll must be called with a global RegExp")
+		}
+		s, r, err := i.regExpMethodArgs(this, args, "matchAll", "g")
+		if err != nil {
+			return Value{}, err
+		}
+		matches := r.re.FindAllStringSubmatchIndex(s, -1)
+		iter := i.newIterator("RegExp String Iterator", func() (Value, bool, error) {
+			if len(matches) == 0 {
+				return Undefined, true, nil
+			}
+			m := matches[0]
+			matches = matches[1:]
+			return NewObjectValue(i.matchArray(s, m)), false, nil
+		})
+		return NewObjectValue(iter), nil
+	})
+	i.defineMethod(proto, "search", func(this Value, args []Value) (Value, error) {
+		s, r, err := i.regExpMethodArgs(this, args, "search", "")
+		if err != nil {
+			return Value{}, err
+		}
+		m := r.re.FindStringIndex(s)
+		if m == nil {
+			return NewNumber(-1), nil
+		}
+		return NewNumber(float64(utf16Length(s[:m[0]]))), nil
+	})
+	i.defineMethod(proto, "charAt", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "charAt")
+		if err != nil {
+			return Value{}, err
+		}
+		pos := toIntegerOrInfinity(argAt(args, 0))
+		if pos < 0 || pos >= float64(len(units)) {
+			return NewString(""), nil
+		}
+		return NewString(string(utf16.Decode(units[int(pos) : int(pos)+1]))), nil
+	})
+	i.defineMethod(proto, "charCodeAt", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "charCodeAt")
+		if err != nil {
+			return Value{}, err
+		}
+		pos := toIntegerOrInfinity(argAt(args, 0))
+		if pos < 0 || pos >= float64(len(units)) {
+			return NewNumber(math.NaN()), nil
+		}
+		return NewNumber(float64(units[int(pos)])), nil
+	})
+	i.defineMethod(proto, "indexOf", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "indexOf")
+		if err != nil {
+			return Value{}, err
+		}
+		search := utf16.Encode([]rune(stringArg(args, 0)))
+		from := clampIndex(toIntegerOrInfinity(argAt(args, 1)), len(units))
+		return NewNumber(float64(indexUnits(units, search, from))), nil
+	})
+	i.defineMethod(proto, "includes", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "includes")
+		if err != nil {
+			return Value{}, err
+		}
+		if pattern := argAt(args, 0); pattern.Kind() == ObjectKind && pattern.Object().regexp != nil {
+			return Value{}, fmt.Errorf("TypeError: First argument to String.prototype.includes must not be a regular expression")
+		}
+		search := utf16.Encode([]rune(stringArg(args, 0)))
+		from := clampIndex(toIntegerOrInfinity(argAt(args, 1)), len(units))
+		return NewBoolean(indexUnits(units, search, from) >= 0), nil
+	})
+	i.defineMethod(proto, "slice", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "slice")
+		if err != nil {
+			return Value{}, err
+		}
+		start := relativeIndex(argAt(args, 0), len(units), 0)
+		end := relativeIndex(argAt(args, 1), len(units), len(units))
+		if start >= end {
+			return NewString(""), nil
+		}
+		return NewString(string(utf16.Decode(units[start:end]))), nil
+	})
+	i.defineMethod(proto, "substring", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "substring")
+		if err != nil {
+			return Value{}, err
+		}
+		start := clampIndex(toIntegerOrInfinity(argAt(args, 0)), len(units))
+		end := len(units)
+		if v := argAt(args, 1); v.Kind() != UndefinedKind {
+			end = clampIndex(toIntegerOrInfinity(v), len(units))
+		}
+		if start > end {
+			start, end = end, start
+		}
+		return NewString(string(utf16.Decode(units[start:end]))), nil
+	})
+	i.defineMethod(proto, "toUpperCase", func(this Value, args []Value) (Value, error) {
+		s, err := thisString(this, "toUpperCase")
+		if err != nil {
+			return Value{}, err
+		}
+		return NewString(strings.ToUpper(s)), nil
+	})
+	i.defineMethod(proto, "toLowerCase", func(this Value, args []Value) (Value, error) {
+		s, err := thisString(this, "toLowerCase")
+		if err != nil {
+			return Value{}, err
+		}
+		return NewString(strings.ToLower(s)), nil
+	})
+	i.defineMethod(proto, "trim", func(this Value, args []Value) (Value, error) {
+		s, err := thisString(this, "trim")
+		if err != nil {
+			return Value{}, err
+		}
+		return NewString(strings.TrimFunc(s, isStringWhiteSpace)), nil
+	})
+	i.defineMethod(proto, "split", func(this Value, args []Value) (Value, error) {
+		units, err := thisStringUnits(this, "split")
+		if err != nil {
+			return Value{}, err
+		}
+		limit := uint32(math.MaxUint32)
+		if v := argAt(args, 1); v.Kind() != UndefinedKind {
+			limit = toUint32(v)
+		}
+		return NewObjectValue(i.newArray(splitUnits(units, argAt(args, 0), limit))), nil
+	})
+	return proto
+}
+
+// thisString resolves the receiver of a String.prototype method, rejecting
+// null and undefined as the specification requires.
+func thisString(this Value, method string) (string, error) {
+	if isNullish(this) {
+		return "", fmt.Errorf("TypeError: String.prototype.%s called on %s", method, this.Inspect())
+	}
+	return ToString(this).StringValue(), nil
+}
+
+// thisStringUnits is thisString for methods that index the receiver, which
+// ECMAScript does in UTF-16 code units.
+func thisStringUnits(this Value, method string) ([]uint16, error) {
+	s, err := thisString(this, method)
+	if err != nil {
+		return nil, err
+	}
+	return utf16.Encode([]rune(s)), nil
+}
+
+// splitUnits implements the string separator form of String.prototype.split.
+// An undefined separator yields the whole string and an empty one yields each
+// code unit.
+func splitUnits(units []uint16, separator Value, limit uint32) []Value {
+	if limit == 0 {
+		return nil
+	}
+	if separator.Kind() == UndefinedKind {
+		return []Value{NewString(string(utf16.Decode(units)))}
+	}
+	sep := utf16.Encode([]rune(ToString(separator).StringValue()))
+	var parts []Value
+	if len(sep) == 0 {
+		for idx := 0; idx < len(units) && uint32(len(parts)) < limit; idx++ {
+			parts = append(parts, NewString(string(utf16.Decode(units[idx:idx+1]))))
+		}
+		return parts
+	}
+	start := 0
+	for {
+		idx := indexUnits(units, sep, start)
+		if idx < 0 {
+			break
+		}
+		parts = append(parts, NewString(string(utf16.Decode(units[start:idx]))))
+		if uint32(len(parts)) == limit {
+			return parts
+		}
+		start = idx + len(sep)
+	}
+	return append(parts, NewString(string(utf16.Decode(units[start:]))))
+}
+
+// indexUnits returns the first index at or after from where search occurs in
+// units, or -1.
+func indexUnits(units, search []uint16, from int) int {
+	for idx := from; idx+len(search) <= len(units); idx++ {
+		if slices.Equal(units[idx:idx+len(search)], search) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// toIntegerOrInfinity truncates v toward zero after numeric conversion,
+// mapping NaN to 0.
+func toIntegerOrInfinity(v Value) float64 {
+	n := ToNumber(v).Number()
+	if math.IsNaN(n) {
+		return 0
+	}
+	return math.Trunc(n)
+}
+
+// toUint32 converts v to an integer modulo 2^32, as ECMAScript's ToUint32.
+func toUint32(v Value) uint32 {
+	n := ToNumber(v).Number()
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0
+	}
+	n = math.Mod(math.Trunc(n), 1<<32)
+	if n < 0 {
+		n += 1 << 32
+	}
+	return uint32(n)
+}
+
+// clampIndex limits the integer n to the range [0, length].
+func clampIndex(n float64, length int) int {
+	return int(math.Max(0, math.Min(n, float64(length))))
+}
+
+// relativeIndex resolves a slice bound where negative values count back from
+// length and undefined selects def.
+func relativeIndex(v Value, length, def int) int {
+	if v.Kind() == UndefinedKind {
+		return def
+	}
+	n := toIntegerOrInfinity(v)
+	if n < 0 {
+		n += float64(length)
+	}
+	return clampIndex(n, length)
+}
+
+// isStringWhiteSpace reports whether r is trimmed by String.prototype.trim:
+// ECMAScript white space or a line terminator.
+func isStringWhiteSpace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00a0', '\u1680', '\u2028', '\u2029', '\u202f', '\u205f', '\u3000', '\ufeff':
+		return true
+	}
+	return r >= '\u2000' && r <= '\u200a'
+}
+
+// regExpMethodArgs resolves the receiver and pattern of the RegExp-based
+// string methods. A pattern that is not a RegExp is compiled with flags, as
+// if passed to the RegExp constructor.
+func (i *Interpreter) regExpMethodArgs(this Value, args []Value, method, flags string) (string, *regExp, error) {
+	if isNullish(this) {
+		return "", nil, fmt.Errorf("TypeError: String.prototype.%s called on %s", method, this.Inspect())
+	}
+	s := ToString(this).StringValue()
+	pattern := argAt(args, 0)
+	if pattern.Kind() == ObjectKind && pattern.Object().regexp != nil {
+		if pattern.Object().regexp.global() {
+			pattern.Object().Set("lastIndex", NewNumber(0))
+		}
+		return s, pattern.Object().regexp, nil
+	}
+	source := ""
+	if pattern.Kind() != UndefinedKind {
+		source = ToString(pattern).StringValue()
+	}
+	compiled, err := i.newRegExp(source, flags)
+	if err != nil {
+		return "", nil, err
+	}
+	return s, compiled.Object().regexp, nil
+}
+
+// matchArray builds the result array for a single match: the matched text
+// followed by each capture group, plus index and input properties.
+func (i *Interpreter) matchArray(s string, m []int) *Object {
+	elems := make([]Value, 0, len(m)/2)
+	for g := 0; g < len(m); g += 2 {
+		if m[g] < 0 {
+			elems = append(elems, Undefined)
+		} else {
+			elems = append(elems, NewString(s[m[g]:m[g+1]]))
+		}
+	}
+	arr := i.newArray(elems)
+	arr.Set("index", NewNumber(float64(utf16Length(s[:m[0]]))))
+	arr.Set("input", NewString(s))
+	return arr
+}
+
+// stringReplace implements String.prototype.replace and replaceAll. Each match
+// is described by submatch byte offsets in the style of regexp's Index
+// methods; unmatched groups are -1.
+func (i *Interpreter) stringReplace(this Value, args []Value, all bool) (Value, error) {
+	method := "replace"
+	if all {
+		method = "replaceAll"
+	}
+	if isNullish(this) {
+		return Value{}, fmt.Errorf("TypeError: String.prototype.%s called on %s", method, this.Inspect())
+	}
+	s := ToString(this).StringValue()
+
+	var matches [][]int
+	pattern := argAt(args, 0)
+	if pattern.Kind() == ObjectKind && pattern.Object().regexp != nil {
+		r := pattern.Object().regexp
+		switch {
+		case r.global():
+			matches = r.re.FindAllStringSubmatchIndex(s, -1)
+			pattern.Object().Set("lastIndex", NewNumber(0))
+		case all:
+			return Value{}, fmt.Errorf("TypeError: replaceAll must be called with a global RegExp")
+		default:
+			if m := r.re.FindStringSubmatchIndex(s); m != nil {
+				matches = [][]int{m}
+			}
+		}
+	} else {
+		matches = findSubstrings(s, ToString(pattern).StringValue(), all)
+	}
+
+	replacement := argAt(args, 1)
+	var template string
+	if !replacement.IsCallable() {
+		template = ToString(replacement).StringValue()
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		out.WriteString(s[last:m[0]])
+		if replacement.IsCallable() {
+			callArgs := []Value{NewString(s[m[0]:m[1]])}
+			for g := 2; g < len(m); g += 2 {
+				if m[g] < 0 {
+					callArgs = append(callArgs, Undefined)
+				} else {
+					callArgs = append(callArgs, NewString(s[m[g]:m[g+1]]))
+				}
+			}
+			callArgs = append(callArgs, NewNumber(float64(utf16Length(s[:m[0]]))), NewString(s))
+			result, err := i.callFunction(replacement, Undefined, callArgs)
+			if err != nil {
+				return Value{}, err
+			}
+			out.WriteString(ToString(result).StringValue())
+		} else {
+			out.WriteString(expandReplacement(template, s, m))
+		}
+		last = m[1]
+	}
+	out.WriteString(s[last:])
+	return NewString(out.String()), nil
+}
+
+// findSubstrings returns the byte ranges where pattern occurs in s, only the
+// first one unless all is set. An empty pattern matches between every
+// character.
+func findSubstrings(s, pattern string, all bool) [][]int {
+	var matches [][]int
+	for pos := 0; pos <= len(s); {
+		idx := strings.Index(s[pos:], pattern)
+		if idx < 0 {
+			break
+		}
+		start := pos + idx
+		matches = append(matches, []int{start, start + len(pattern)})
+		if !all {
+			break
+		}
+		if pattern == "" {
+			if start == len(s) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(s[start:])
+			pos = start + size
+			continue
+		}
+		pos = start + len(pattern)
+	}
+	return matches
+}
+
+// expandReplacement applies the $ substitutions of a replacement template to
+// the match m found in s.
+func expandReplacement(template, s string, m []int) string {
+	if !strings.Contains(template, "$") {
+		return template
+	}
+	groups := len(m)/2 - 1
+	var out strings.Builder
+	for idx := 0; idx < len(template); idx++ {
+		c := template[idx]
+		if c != '$' || idx+1 >= len(template) {
+			out.WriteByte(c)
+			continue
+		}
+		switch next := template[idx+1]; {
+		case next == '$':
+			out.WriteByte('$')
+			idx++
+		case next == '&':
+			out.WriteString(s[m[0]:m[1]])
+			idx++
+		case next == '`':
+			out.WriteString(s[:m[0]])
+			idx++
+		case next == '\'':
+			out.WriteString(s[m[1]:])
+			idx++
+		case next >= '0' && next <= '9':
+			// Prefer a two-digit group reference when that group exists.
+			n, width := int(next-'0'), 1
+			if idx+2 < len(template) && template[idx+2] >= '0' && template[idx+2] <= '9' {
+				if two := n*10 + int(template[idx+2]-'0'); two >= 1 && two <= groups {
+					n, width = two, 2
+				}
+			}
+			if n < 1 || n > groups {
+				out.WriteByte(c)
+				continue
+			}
+			if m[2*n] >= 0 {
+				out.WriteString(s[m[2*n]:m[2*n+1]])
+			}
+			idx += width
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// utf16Length reports the length of s in UTF-16 code units, the unit used for
+// string indices and lengths.
+func utf16Length(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// argAt returns the argument at idx, or undefined when it was not supplied.
+func argAt(args []Value, idx int) Value {
+	if idx >= len(args) {
+		return Undefined
+	}
+	return args[idx]
+}
+
+// stringArg converts the argument at idx to a Go string.
+func stringArg(args []Value, idx int) string {
+	return ToString(argAt(args, idx)).StringValue()
+}
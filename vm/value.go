@@ -3,6 +3,7 @@ package vm
 import (
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
 	"strings"
 )
@@ -16,15 +17,19 @@ const (
 	BooleanKind
 	NumberKind
 	StringKind
+	BigIntKind
+	ObjectKind
 )
 
-// Value holds one ECMAScript value. Non-primitive forms (objects, functions,
-// arrays) will be modeled in future iterations.
+// Value holds one ECMAScript value. Objects, including functions, are held by
+// reference so copies of a Value share the same underlying object.
 type Value struct {
 	kind ValueKind
 	num  float64
 	str  string
 	bool bool
+	big  *big.Int
+	obj  *Object
 }
 
 // Common singleton values reused across the VM.
@@ -53,6 +58,16 @@ func NewString(s string) Value {
 	return Value{kind: StringKind, str: s}
 }
 
+// NewBigInt returns a BigInt value. The VM never mutates b after wrapping it.
+func NewBigInt(b *big.Int) Value {
+	return Value{kind: BigIntKind, big: b}
+}
+
+// NewObjectValue wraps an object reference.
+func NewObjectValue(o *Object) Value {
+	return Value{kind: ObjectKind, obj: o}
+}
+
 // Kind exposes the underlying ValueKind.
 func (v Value) Kind() ValueKind { return v.kind }
 
@@ -80,6 +95,27 @@ func (v Value) StringValue() string {
 	return v.str
 }
 
+// BigInt retrieves the BigInt payload, panicking if the kind mismatches.
+func (v Value) BigInt() *big.Int {
+	if v.kind != BigIntKind {
+		panic(fmt.Sprintf("vm: BigInt() on non-BigInt value %s", v.Inspect()))
+	}
+	return v.big
+}
+
+// Object retrieves the object payload, panicking if the kind mismatches.
+func (v Value) Object() *Object {
+	if v.kind != ObjectKind {
+		panic(fmt.Sprintf("vm: Object() on non-object value %s", v.Inspect()))
+	}
+	return v.obj
+}
+
+// IsCallable reports whether the value can be invoked as a function.
+func (v Value) IsCallable() bool {
+	return v.kind == ObjectKind && v.obj.IsCallable()
+}
+
 // String implements fmt.Stringer and returns a descriptive representation.
 func (v Value) String() string { return v.Inspect() }
 
@@ -96,23 +132,142 @@ func (v Value) Inspect() string {
 		}
 		return "false"
 	case NumberKind:
-		if math.IsNaN(v.num) {
-			return "NaN"
-		}
-		if math.IsInf(v.num, 1) {
-			return "Infinity"
+		if v.num == 0 && math.Signbit(v.num) {
+			// Unlike ToString, inspection tells negative zero apart.
+			return "-0"
 		}
-		if math.IsInf(v.num, -1) {
-			return "-Infinity"
-		}
-		return strconv.FormatFloat(v.num, 'g', -1, 64)
+		return numberToString(v.num)
 	case StringKind:
 		return strconv.Quote(v.str)
+	case BigIntKind:
+		return v.big.String() + "n"
+	case ObjectKind:
+		return inspectObject(v.obj, 0, nil)
 	default:
 		return "<unknown>"
 	}
 }
 
+// inspectValue renders v nested depth levels inside the objects in seen,
+// which are being inspected further out.
+func inspectValue(v Value, depth int, seen map[*Object]bool) string {
+	if v.kind == ObjectKind {
+		return inspectObject(v.obj, depth, seen)
+	}
+	return v.Inspect()
+}
+
+// inspectObject renders o at the given nesting depth. seen holds the objects
+// being inspected further out, so an object containing itself prints
+// [Circular] instead of recursing.
+func inspectObject(o *Object, depth int, seen map[*Object]bool) string {
+	if o.function != nil {
+		if o.function.name == "" {
+			return "[Function (anonymous)]"
+		}
+		return "[Function: " + o.function.name + "]"
+	}
+	if o.regexp != nil {
+		return "/" + o.regexp.source + "/" + o.regexp.flags
+	}
+	if seen[o] {
+		return "[Circular]"
+	}
+	if depth > 1 {
+		return "[" + o.class + "]"
+	}
+	if seen == nil {
+		seen = make(map[*Object]bool)
+	}
+	seen[o] = true
+	defer delete(seen, o)
+	if o.class == "Array" {
+		return inspectArray(o, depth, seen)
+	}
+	keys := o.OwnKeys()
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prop := o.properties[key]
+		if !prop.enumerable {
+			continue
+		}
+		if prop.accessor {
+			parts = append(parts, key+": "+inspectAccessor(prop))
+			continue
+		}
+		parts = append(parts, key+": "+inspectValue(prop.value, depth+1, seen))
+	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+func inspectAccessor(prop *property) string {
+	switch {
+	case prop.getter != nil && prop.setter != nil:
+		return "[Getter/Setter]"
+	case prop.getter != nil:
+		return "[Getter]"
+	default:
+		return "[Setter]"
+	}
+}
+
+func inspectArray(o *Object, depth int, seen map[*Object]bool) string {
+	var parts []string
+	holes := 0
+	flushHoles := func() {
+		switch {
+		case holes == 1:
+			parts = append(parts, "<1 empty item>")
+		case holes > 1:
+			parts = append(parts, fmt.Sprintf("<%d empty items>", holes))
+		}
+		holes = 0
+	}
+	length := o.Length()
+	for idx := uint32(0); idx < length; idx++ {
+		prop, ok := o.properties[strconv.FormatUint(uint64(idx), 10)]
+		if !ok {
+			holes++
+			continue
+		}
+		flushHoles()
+		parts = append(parts, inspectValue(prop.value, depth+1, seen))
+	}
+	flushHoles()
+	if len(parts) == 0 {
+		return "[]"
+	}
+	return "[ " + strings.Join(parts, ", ") + " ]"
+}
+
+// joinArray concatenates the string forms of an array's elements, rendering
+// holes, undefined and null as empty strings. joining holds the arrays whose
+// elements are being converted further out; an array met again joins to the
+// empty string, as in engines, instead of recursing.
+func joinArray(o *Object, sep string, joining map[*Object]bool) string {
+	if joining[o] {
+		return ""
+	}
+	if joining == nil {
+		joining = make(map[*Object]bool)
+	}
+	joining[o] = true
+	defer delete(joining, o)
+	length := o.Length()
+	parts := make([]string, length)
+	for idx := uint32(0); idx < length; idx++ {
+		elem := o.Get(strconv.FormatUint(uint64(idx), 10))
+		if elem.kind == UndefinedKind || elem.kind == NullKind {
+			continue
+		}
+		parts[idx] = toString(elem, joining).StringValue()
+	}
+	return strings.Join(parts, sep)
+}
+
 // StrictEquals implements the === operator for the supported types.
 func StrictEquals(a, b Value) bool {
 	if a.kind != b.kind {
@@ -130,9 +285,57 @@ func StrictEquals(a, b Value) bool {
 		return a.num == b.num
 	case StringKind:
 		return a.str == b.str
+	case BigIntKind:
+		return a.big.Cmp(b.big) == 0
+	case ObjectKind:
+		return a.obj == b.obj
+	default:
+		return false
+	}
+}
+
+// LooseEquals implements the == operator: null and undefined equal only each
+// other, and otherwise mismatched kinds are compared after converting
+// booleans and strings to numbers and objects to primitives.
+func LooseEquals(a, b Value) bool {
+	if a.kind == b.kind {
+		return StrictEquals(a, b)
+	}
+	aNullish := a.kind == UndefinedKind || a.kind == NullKind
+	bNullish := b.kind == UndefinedKind || b.kind == NullKind
+	if aNullish || bNullish {
+		return aNullish && bNullish
+	}
+	switch {
+	case a.kind == BooleanKind:
+		return LooseEquals(ToNumber(a), b)
+	case b.kind == BooleanKind:
+		return LooseEquals(a, ToNumber(b))
+	case a.kind == ObjectKind:
+		return LooseEquals(ToString(a), b)
+	case b.kind == ObjectKind:
+		return LooseEquals(a, ToString(b))
+	case a.kind == BigIntKind:
+		return bigIntEqualsNumeric(a.big, b)
+	case b.kind == BigIntKind:
+		return bigIntEqualsNumeric(b.big, a)
 	default:
+		// One string and one number.
+		return StrictEquals(ToNumber(a), ToNumber(b))
+	}
+}
+
+// bigIntEqualsNumeric compares a BigInt with a number or numeric string.
+func bigIntEqualsNumeric(n *big.Int, v Value) bool {
+	if v.kind == StringKind {
+		parsed, ok := new(big.Int).SetString(strings.TrimSpace(v.str), 0)
+		return ok && parsed.Cmp(n) == 0
+	}
+	f := ToNumber(v).num
+	if math.IsNaN(f) || math.IsInf(f, 0) {
 		return false
 	}
+	return new(big.Float).SetInt(n).Cmp(big.NewFloat(f)) == 0
 }
 
 // ToBoolean performs JavaScript truthiness conversion for the supported types.
@@ -149,6 +352,10 @@ func ToBoolean(v Value) bool {
 		return true
 	case StringKind:
 		return len(v.str) > 0
+	case BigIntKind:
+		return v.big.Sign() != 0
+	case ObjectKind:
+		return true
 	default:
 		return false
 	}
@@ -169,17 +376,9 @@ func ToNumber(v Value) Value {
 	case NumberKind:
 		return v
 	case StringKind:
-		s := strings.TrimSpace(v.str)
-		if s == "" {
-			return NewNumber(0)
-		}
-		if strings.EqualFold(s, "NaN") {
-			return NewNumber(math.NaN())
-		}
-		f, err := strconv.ParseFloat(s, 64)
-		if err != nil {
-			return NewNumber(math.NaN())
-		}
+		return NewNumber(stringToNumber(v.str))
+	case BigIntKind:
+		f, _ := new(big.Float).SetInt(v.big).Float64()
 		return NewNumber(f)
 	default:
 		return NewNumber(math.NaN())
@@ -188,6 +387,11 @@ func ToNumber(v Value) Value {
 
 // ToString converts a value to a string value.
 func ToString(v Value) Value {
+	return toString(v, nil)
+}
+
+// toString is ToString for a value met while joining the arrays in joining.
+func toString(v Value, joining map[*Object]bool) Value {
 	switch v.kind {
 	case UndefinedKind:
 		return NewString("undefined")
@@ -199,18 +403,22 @@ func ToString(v Value) Value {
 		}
 		return NewString("false")
 	case NumberKind:
-		if math.IsNaN(v.num) {
-			return NewString("NaN")
+		return NewString(numberToString(v.num))
+	case StringKind:
+		return v
+	case BigIntKind:
+		return NewString(v.big.String())
+	case ObjectKind:
+		if v.obj.function != nil {
+			return NewString("function " + v.obj.function.name + "() { [code] }")
 		}
-		if math.IsInf(v.num, 1) {
-			return NewString("Infinity")
+		if v.obj.class == "Array" {
+			return NewString(joinArray(v.obj, ",", joining))
 		}
-		if math.IsInf(v.num, -1) {
-			return NewString("-Infinity")
+		if v.obj.regexp != nil {
+			return NewString("/" + v.obj.regexp.source + "/" + v.obj.regexp.flags)
 		}
-		return NewString(strconv.FormatFloat(v.num, 'g', -1, 64))
-	case StringKind:
-		return v
+		return NewString("[object " + v.obj.class + "]")
 	default:
 		return NewString("<unknown>")
 	}